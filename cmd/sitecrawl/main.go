@@ -13,8 +13,10 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/sbstn/sitecrawl/internal/config"
 	"github.com/sbstn/sitecrawl/internal/crawler"
 	"github.com/sbstn/sitecrawl/internal/output"
+	"github.com/sbstn/sitecrawl/pkg/pagerank"
 )
 
 func main() {
@@ -30,6 +32,16 @@ func run(args []string) int {
 	switch args[0] {
 	case "crawl":
 		return runCrawl(args[1:])
+	case "recover":
+		return runRecover(args[1:])
+	case "report":
+		return runReport(args[1:])
+	case "diff":
+		return runDiff(args[1:])
+	case "rank":
+		return runRank(args[1:])
+	case "audit":
+		return runAudit(args[1:])
 	case "-h", "--help", "help":
 		printRootUsage(os.Stdout)
 		return 0
@@ -47,32 +59,86 @@ func runCrawl(args []string) int {
 	var domain string
 	var outDir string
 	var formatRaw string
+	var layoutRaw string
+	var archiveRaw string
 	var strategyRaw string
 	var maxPages int
 	var maxDepth int
 	var clean bool
+	var extractorRaw string
 	var headful bool
 	var delayMS int
 	var pageTimeout time.Duration
 	var userAgent string
 	var logLevelRaw string
+	var respectMetaRobots bool
+	var respectAIOptOut bool
+	var languagesRaw string
+	var rulesPath string
+	var chunks bool
+	var chunkTokens int
+	var chunkOverlap int
+	var tokenizerRaw string
+	var llmsTxt bool
+	var packTokens int
+	var packOrderRaw string
+	var sqlitePath string
+	var graphFormatRaw string
+	var externalLinks bool
+	var checkLinksRaw string
+	var checkLinksConcurrency int
+	var sitemapRaw string
+	var audit bool
+	var auditDisableRaw string
+	auditOpts := output.DefaultAuditOptions()
+	var configPath string
+	var profile string
 
 	flagSet.StringVar(&domain, "domain", "", "Domain to crawl (required). Example: example.com")
 	flagSet.StringVar(&outDir, "out", "", "Output directory (required)")
-	flagSet.StringVar(&formatRaw, "format", "", "Output format (required): md|html|json")
+	flagSet.StringVar(&formatRaw, "format", "", "Output format (required): md|html|json|warc, optionally combined as e.g. md,warc")
+	flagSet.StringVar(&archiveRaw, "archive", "", "Write all output into one archive at --out instead of a directory: tar.zst|tar.gz|zip")
+	flagSet.StringVar(&layoutRaw, "layout", "flat", "Page file layout: flat|tree (tree mirrors URL paths as directories)")
 	flagSet.StringVar(&strategyRaw, "strategy", "pagerank", "Crawl strategy: pagerank|limit|depth")
 	flagSet.IntVar(&maxPages, "max-pages", 25, "Max pages to crawl (hard cap for all strategies)")
 	flagSet.IntVar(&maxDepth, "max-depth", 2, "Max crawl depth for strategy=depth")
 	flagSet.BoolVar(&clean, "clean", true, "Strip scripts/styles and output readable/main content")
+	flagSet.StringVar(&extractorRaw, "extractor", "heuristic", "Main-content extractor for --clean: heuristic|readability")
 	flagSet.BoolVar(&headful, "headful", false, "Run Chrome in headful mode")
 	flagSet.IntVar(&delayMS, "delay-ms", 750, "Politeness delay between navigations in milliseconds")
 	flagSet.DurationVar(&pageTimeout, "page-timeout", 20*time.Second, "Per-page timeout, e.g. 20s")
 	flagSet.StringVar(&userAgent, "user-agent", crawler.DefaultUserAgent, "User-Agent string")
 	flagSet.StringVar(&logLevelRaw, "log", "info", "Log level: debug|info|warn|error")
+	flagSet.BoolVar(&respectMetaRobots, "respect-meta-robots", false, "Skip noindex pages and do not follow nofollow pages/links (meta robots, X-Robots-Tag, rel=nofollow)")
+	flagSet.BoolVar(&respectAIOptOut, "respect-ai-optout", false, "Exclude pages opted out of AI/TDM use (noai, tdm-reservation, tdmrep.json, ai.txt)")
+	flagSet.StringVar(&languagesRaw, "lang", "", "Comma-separated languages to crawl and write, e.g. en,de (default: all)")
+	flagSet.StringVar(&rulesPath, "rules", "", "JSON file mapping field names to CSS/XPath extraction rules")
+	flagSet.BoolVar(&chunks, "chunks", false, "Write chunks.jsonl with heading-aware, token-bounded page chunks")
+	flagSet.IntVar(&chunkTokens, "chunk-tokens", 512, "Max tokens per chunk for --chunks")
+	flagSet.IntVar(&chunkOverlap, "chunk-overlap", 64, "Tokens repeated from the previous chunk for --chunks")
+	flagSet.StringVar(&tokenizerRaw, "tokenizer", "gpt", "Tokenizer approximation for --chunks: gpt|claude|llama|gemini")
+	flagSet.BoolVar(&llmsTxt, "llms-txt", false, "Write llms.txt and llms-full.txt")
+	flagSet.IntVar(&packTokens, "pack-tokens", 0, "Write a context pack of at most N tokens (context.md, or context.json with --format json); 0 disables")
+	flagSet.StringVar(&packOrderRaw, "pack-order", "score", "Page ranking for --pack-tokens: score|depth|crawl")
+	flagSet.StringVar(&sqlitePath, "sqlite", "", "Append the crawl to this SQLite database (pages, links, metadata, FTS5 index); created if missing")
+	flagSet.StringVar(&graphFormatRaw, "graph-format", "", "Comma-separated link graph exports: csv|graphml|gexf|dot (csv writes nodes.csv and edges.csv)")
+	flagSet.BoolVar(&externalLinks, "external-links", false, "Write external_links.csv listing every outbound link with anchor text and rel")
+	flagSet.StringVar(&checkLinksRaw, "check-links", "", "After the crawl, validate discovered links and report broken ones: internal|all")
+	flagSet.IntVar(&checkLinksConcurrency, "check-links-concurrency", crawler.DefaultLinkCheckConcurrency, "Hosts checked at once by --check-links (one request at a time per host)")
+	flagSet.StringVar(&sitemapRaw, "sitemap", "", "After the crawl, compare sitemap URLs with the crawled pages: auto (robots.txt, then /sitemap.xml) or comma-separated sitemap URLs")
+	flagSet.BoolVar(&audit, "audit", false, "Write audit.json and audit.md with SEO lint findings (see sitecrawl audit)")
+	flagSet.StringVar(&auditDisableRaw, "audit-disable", "", "Comma-separated audit rule IDs to skip for --audit")
+	flagSet.IntVar(&auditOpts.MaxTitleLength, "audit-max-title-length", auditOpts.MaxTitleLength, "Longest title in characters before title-too-long, for --audit")
+	flagSet.IntVar(&auditOpts.MaxDescriptionLength, "audit-max-description-length", auditOpts.MaxDescriptionLength, "Longest meta description in characters before description-too-long, for --audit")
+	flagSet.IntVar(&auditOpts.MinWords, "audit-min-words", auditOpts.MinWords, "Fewest main-content words before thin-content, for --audit")
+	flagSet.IntVar(&auditOpts.MaxDepth, "audit-max-depth", auditOpts.MaxDepth, "Deepest crawl depth before deep-page, for --audit")
+	flagSet.IntVar(&auditOpts.MinInboundLinks, "audit-min-inbound-links", auditOpts.MinInboundLinks, "Fewest internal links to a page before low-inbound-links, for --audit")
+	flagSet.StringVar(&configPath, "config", "", "YAML, TOML, or JSON file with crawl settings, profiles, and per-domain overrides (env: SITECRAWL_CONFIG)")
+	flagSet.StringVar(&profile, "profile", "", "Named profile from --config to apply (env: SITECRAWL_PROFILE)")
 
 	flagSet.Usage = func() {
 		fmt.Fprintf(flagSet.Output(), "Usage:\n")
-		fmt.Fprintf(flagSet.Output(), "  sitecrawl crawl --domain <domain> --out <dir> --format <md|html|json> [flags]\n\n")
+		fmt.Fprintf(flagSet.Output(), "  sitecrawl crawl --domain <domain> --out <dir> --format <md|html|json|warc> [flags]\n\n")
 		flagSet.PrintDefaults()
 	}
 
@@ -82,6 +148,11 @@ func runCrawl(args []string) int {
 		}
 		return 2
 	}
+	fieldsJSON, err := applyConfig(flagSet, configPath, profile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 2
+	}
 
 	if domain == "" || outDir == "" || formatRaw == "" {
 		fmt.Fprintln(os.Stderr, "error: --domain, --out, and --format are required")
@@ -101,41 +172,176 @@ func runCrawl(args []string) int {
 		fmt.Fprintln(os.Stderr, "error: --delay-ms must be >= 0")
 		return 2
 	}
+	if checkLinksConcurrency <= 0 {
+		fmt.Fprintln(os.Stderr, "error: --check-links-concurrency must be >= 1")
+		return 2
+	}
+	if packTokens < 0 {
+		fmt.Fprintln(os.Stderr, "error: --pack-tokens must be >= 0")
+		return 2
+	}
+	if chunkTokens <= 0 {
+		fmt.Fprintln(os.Stderr, "error: --chunk-tokens must be >= 1")
+		return 2
+	}
+	if chunkOverlap < 0 || chunkOverlap >= chunkTokens {
+		fmt.Fprintln(os.Stderr, "error: --chunk-overlap must be >= 0 and < --chunk-tokens")
+		return 2
+	}
+	if auditOpts.MaxTitleLength < 0 || auditOpts.MaxDescriptionLength < 0 || auditOpts.MinWords < 0 || auditOpts.MaxDepth < 0 || auditOpts.MinInboundLinks < 0 {
+		fmt.Fprintln(os.Stderr, "error: audit thresholds must be >= 0")
+		return 2
+	}
 
-	format, err := output.ParseFormat(formatRaw)
+	format, warc, err := output.ParseFormats(formatRaw)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 2
+	}
+	layout, err := output.ParseLayout(layoutRaw)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err.Error())
 		return 2
 	}
+	var archive output.ArchiveFormat
+	if archiveRaw != "" {
+		archive, err = output.ParseArchiveFormat(archiveRaw)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			return 2
+		}
+		if info, statErr := os.Stat(outDir); statErr == nil && info.IsDir() {
+			fmt.Fprintln(os.Stderr, "error: --out must be an archive file path with --archive, not a directory")
+			return 2
+		}
+	}
 	strategy, err := crawler.ParseStrategy(strategyRaw)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err.Error())
 		return 2
 	}
+	extractor, err := crawler.ParseExtractor(extractorRaw)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 2
+	}
+	tokenizer, err := output.ParseTokenizer(tokenizerRaw)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 2
+	}
+	packOrder, err := output.ParsePackOrder(packOrderRaw)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 2
+	}
+	graphFormats, err := output.ParseGraphFormats(graphFormatRaw)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 2
+	}
+	checkLinks, err := crawler.ParseLinkCheckMode(checkLinksRaw)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 2
+	}
+	sitemaps, err := crawler.ParseSitemaps(sitemapRaw)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 2
+	}
+	auditOpts.Disabled, err = output.ParseAuditRules(auditDisableRaw)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 2
+	}
+	writeOpts := output.Options{Layout: layout, LLMsTxt: llmsTxt, WARC: warc, SQLite: sqlitePath, Graph: graphFormats, ExternalLinksCSV: externalLinks}
+	if audit {
+		writeOpts.Audit = &auditOpts
+	}
+	if packTokens > 0 {
+		packFormat := output.FormatMarkdown
+		if format == output.FormatJSON {
+			packFormat = output.FormatJSON
+		}
+		writeOpts.Pack = &output.PackOptions{
+			MaxTokens: packTokens,
+			Order:     packOrder,
+			Tokenizer: tokenizer,
+			Format:    packFormat,
+		}
+	}
+	if chunks {
+		writeOpts.Chunks = &output.ChunkOptions{
+			MaxTokens:     chunkTokens,
+			OverlapTokens: chunkOverlap,
+			Tokenizer:     tokenizer,
+		}
+	}
+
+	var fieldRules []crawler.FieldRule
+	if rulesPath != "" {
+		fieldRules, err = crawler.LoadFieldRules(rulesPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			return 2
+		}
+	} else if fieldsJSON != "" {
+		// Already validated when the config was loaded.
+		fieldRules, err = crawler.ParseFieldRules([]byte(fieldsJSON))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			return 2
+		}
+	}
 
 	logger := newLogger(logLevelRaw)
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer cancel()
 
 	cfg := crawler.Config{
-		Domain:      domain,
-		Strategy:    strategy,
-		MaxPages:    maxPages,
-		MaxDepth:    maxDepth,
-		Clean:       clean,
-		Headful:     headful,
-		Delay:       time.Duration(delayMS) * time.Millisecond,
-		PageTimeout: pageTimeout,
-		UserAgent:   userAgent,
+		Domain:               domain,
+		Strategy:             strategy,
+		MaxPages:             maxPages,
+		MaxDepth:             maxDepth,
+		Clean:                clean,
+		Extractor:            extractor,
+		Headful:              headful,
+		Delay:                time.Duration(delayMS) * time.Millisecond,
+		PageTimeout:          pageTimeout,
+		UserAgent:            userAgent,
+		RespectMetaRobots:    respectMetaRobots,
+		RespectAIOptOut:      respectAIOptOut,
+		Languages:            crawler.ParseLanguages(languagesRaw),
+		FieldRules:           fieldRules,
+		CaptureHTTP:          warc || format == output.FormatWARC,
+		CheckLinks:           checkLinks,
+		LinkCheckConcurrency: checkLinksConcurrency,
+		Sitemaps:             sitemaps,
+	}
+
+	reportPath := filepath.Join(outDir, output.ReportName)
+	var sink *output.StreamWriter
+	if archive != "" {
+		reportPath = outDir
+		sink, err = output.NewArchiveWriter(outDir, archive, format, writeOpts)
+	} else {
+		sink, err = output.NewStreamWriter(outDir, format, writeOpts)
+	}
+	if err != nil {
+		logger.Error("failed to open output", "error", err)
+		return 1
 	}
+	defer sink.Close()
+	cfg.Sink = sink
 
 	result, crawlErr := crawler.Crawl(ctx, cfg, logger)
 	if result != nil {
-		if writeErr := output.Write(result, outDir, format); writeErr != nil {
+		if writeErr := sink.Finish(result); writeErr != nil {
 			logger.Error("failed to write output", "error", writeErr)
 			return 1
 		}
-		logger.Info("report written", "path", filepath.Join(outDir, "report.json"))
+		logger.Info("report written", "path", reportPath)
 	}
 
 	if crawlErr != nil {
@@ -153,21 +359,397 @@ func runCrawl(args []string) int {
 			"errors", result.Totals.Errors,
 			"skipped_external", result.Totals.SkippedExternal,
 			"skipped_out_of_scope", result.Totals.SkippedOutOfScope,
+			"skipped_noindex", result.Totals.SkippedNoIndex,
+			"skipped_nofollow", result.Totals.SkippedNofollow,
+			"skipped_ai_optout", result.Totals.SkippedAIOptOut,
+			"skipped_language", result.Totals.SkippedLanguage,
 		)
 	}
 	return 0
 }
 
+// applyConfig sets every crawl flag not given on the command line from the
+// config file and SITECRAWL_* environment variables, so precedence is
+// defaults < file < env < flags. It returns the inline field rules as JSON
+// when those layers chose them over a rules file.
+func applyConfig(flagSet *flag.FlagSet, configPath, profile string) (string, error) {
+	explicit := map[string]bool{}
+	flagSet.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+	if configPath == "" {
+		configPath = os.Getenv(config.EnvPrefix + "CONFIG")
+	}
+	if profile == "" {
+		profile = os.Getenv(config.EnvPrefix + "PROFILE")
+	}
+
+	var file *config.File
+	if configPath != "" {
+		var err error
+		file, err = config.Load(configPath)
+		if err != nil {
+			return "", err
+		}
+	}
+	var flagDomain string
+	if explicit["domain"] {
+		flagDomain = flagSet.Lookup("domain").Value.String()
+	}
+	settings, err := config.Resolve(file, profile, os.Environ(), flagDomain)
+	if err != nil {
+		return "", err
+	}
+
+	var fieldsJSON string
+	for _, key := range config.Keys() {
+		setting, ok := settings[key]
+		if !ok || explicit[key] {
+			continue
+		}
+		if key == config.FieldsKey {
+			if !explicit["rules"] {
+				fieldsJSON = setting.Value
+			}
+			continue
+		}
+		if err := flagSet.Set(key, setting.Value); err != nil {
+			return "", fmt.Errorf("%s: %s: %v", setting.Origin, key, err)
+		}
+	}
+	return fieldsJSON, nil
+}
+
+func runRecover(args []string) int {
+	flagSet := flag.NewFlagSet("recover", flag.ContinueOnError)
+	flagSet.SetOutput(os.Stderr)
+
+	var outDir string
+	flagSet.StringVar(&outDir, "out", "", "Output directory of the interrupted crawl (required)")
+
+	flagSet.Usage = func() {
+		fmt.Fprintf(flagSet.Output(), "Usage:\n")
+		fmt.Fprintf(flagSet.Output(), "  sitecrawl recover --out <dir>\n\n")
+		flagSet.PrintDefaults()
+	}
+
+	if err := flagSet.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if outDir == "" {
+		fmt.Fprintln(os.Stderr, "error: --out is required")
+		fmt.Fprintln(os.Stderr)
+		flagSet.Usage()
+		return 2
+	}
+
+	if err := output.RecoverReport(outDir); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 1
+	}
+	fmt.Fprintln(os.Stderr, "report recovered:", filepath.Join(outDir, output.ReportName))
+	return 0
+}
+
+func runReport(args []string) int {
+	flagSet := flag.NewFlagSet("report", flag.ContinueOnError)
+	flagSet.SetOutput(os.Stderr)
+
+	var outPath string
+	var outputRaw string
+	var statusRaw string
+	var minDepth int
+	var maxDepth int
+	var urlRaw string
+	var top int
+	flagSet.StringVar(&outPath, "out", "", "Output directory of a crawl, or a report.json path (required)")
+	flagSet.StringVar(&outputRaw, "output", "table", "Summary output: table|json|csv")
+	flagSet.StringVar(&statusRaw, "status", "", "Comma-separated page statuses to include, e.g. ok,error (default: all)")
+	flagSet.IntVar(&minDepth, "min-depth", 0, "Include pages at this crawl depth or deeper")
+	flagSet.IntVar(&maxDepth, "max-depth", -1, "Include pages at this crawl depth or shallower; -1 for no limit")
+	flagSet.StringVar(&urlRaw, "url", "", "Comma-separated URL globs to include; * matches anything, /-prefixed globs match the path")
+	flagSet.IntVar(&top, "top", 10, "Number of top pages by score to list")
+
+	flagSet.Usage = func() {
+		fmt.Fprintf(flagSet.Output(), "Usage:\n")
+		fmt.Fprintf(flagSet.Output(), "  sitecrawl report --out <dir|report.json> [flags]\n\n")
+		flagSet.PrintDefaults()
+	}
+
+	if err := flagSet.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if outPath == "" {
+		fmt.Fprintln(os.Stderr, "error: --out is required")
+		fmt.Fprintln(os.Stderr)
+		flagSet.Usage()
+		return 2
+	}
+	if minDepth < 0 {
+		fmt.Fprintln(os.Stderr, "error: --min-depth must be >= 0")
+		return 2
+	}
+	if top < 0 {
+		fmt.Fprintln(os.Stderr, "error: --top must be >= 0")
+		return 2
+	}
+	format, err := output.ParseSummaryFormat(outputRaw)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 2
+	}
+
+	filter := output.ReportFilter{MinDepth: minDepth, MaxDepth: maxDepth}
+	for _, status := range strings.Split(statusRaw, ",") {
+		if status = strings.ToLower(strings.TrimSpace(status)); status != "" {
+			filter.Statuses = append(filter.Statuses, status)
+		}
+	}
+	for _, pattern := range strings.Split(urlRaw, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			filter.URLs = append(filter.URLs, crawler.CompileURLPattern(pattern))
+		}
+	}
+
+	rep, err := output.ReadReport(outPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 1
+	}
+	if err := output.WriteSummary(os.Stdout, output.Summarize(rep, filter, top), format); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 1
+	}
+	return 0
+}
+
+func runDiff(args []string) int {
+	flagSet := flag.NewFlagSet("diff", flag.ContinueOnError)
+	flagSet.SetOutput(os.Stderr)
+
+	var oldDir string
+	var newDir string
+	var outputRaw string
+	var content bool
+	var contextLines int
+	var minScoreDelta float64
+	flagSet.StringVar(&oldDir, "old", "", "Output directory of the earlier crawl (required)")
+	flagSet.StringVar(&newDir, "new", "", "Output directory of the later crawl (required)")
+	flagSet.StringVar(&outputRaw, "output", "markdown", "Diff output: markdown|json")
+	flagSet.BoolVar(&content, "content", true, "Include unified diffs of page file content")
+	flagSet.IntVar(&contextLines, "context", 3, "Context lines around each content change")
+	flagSet.Float64Var(&minScoreDelta, "min-score-delta", 0.0001, "Smallest PageRank score change to report")
+
+	flagSet.Usage = func() {
+		fmt.Fprintf(flagSet.Output(), "Usage:\n")
+		fmt.Fprintf(flagSet.Output(), "  sitecrawl diff --old <dir> --new <dir> [flags]\n\n")
+		flagSet.PrintDefaults()
+	}
+
+	if err := flagSet.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if oldDir == "" || newDir == "" {
+		fmt.Fprintln(os.Stderr, "error: --old and --new are required")
+		fmt.Fprintln(os.Stderr)
+		flagSet.Usage()
+		return 2
+	}
+	if contextLines < 0 {
+		fmt.Fprintln(os.Stderr, "error: --context must be >= 0")
+		return 2
+	}
+	if minScoreDelta < 0 {
+		fmt.Fprintln(os.Stderr, "error: --min-score-delta must be >= 0")
+		return 2
+	}
+	format, err := output.ParseDiffFormat(outputRaw)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 2
+	}
+
+	diff, err := output.DiffCrawls(oldDir, newDir, output.DiffOptions{
+		Content:       content,
+		ContextLines:  contextLines,
+		MinScoreDelta: minScoreDelta,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 1
+	}
+	if err := output.WriteDiff(os.Stdout, diff, format); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 1
+	}
+	return 0
+}
+
+func runRank(args []string) int {
+	flagSet := flag.NewFlagSet("rank", flag.ContinueOnError)
+	flagSet.SetOutput(os.Stderr)
+
+	defaults := crawler.DefaultPageRankOptions()
+	var outDir string
+	var alpha float64
+	var tolerance float64
+	var maxIterations uint
+	var personalizationPath string
+	var weightsPath string
+	flagSet.StringVar(&outDir, "out", "", "Output directory of a crawl with --graph-format csv, graphml, or gexf (required)")
+	flagSet.Float64Var(&alpha, "alpha", defaults.Alpha, "PageRank damping factor in [0, 1)")
+	flagSet.Float64Var(&tolerance, "tolerance", defaults.Tolerance, "Convergence threshold on the L1 change of all ranks")
+	flagSet.UintVar(&maxIterations, "max-iterations", defaults.MaxIterations, "Maximum PageRank iterations")
+	flagSet.StringVar(&personalizationPath, "personalization", "", "CSV of url,weight biasing the random jump towards pages")
+	flagSet.StringVar(&weightsPath, "weights", "", "CSV of source,target,weight link weights (unlisted links weigh 1)")
+
+	flagSet.Usage = func() {
+		fmt.Fprintf(flagSet.Output(), "Usage:\n")
+		fmt.Fprintf(flagSet.Output(), "  sitecrawl rank --out <dir> [flags]\n\n")
+		flagSet.PrintDefaults()
+	}
+
+	if err := flagSet.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if outDir == "" {
+		fmt.Fprintln(os.Stderr, "error: --out is required")
+		fmt.Fprintln(os.Stderr)
+		flagSet.Usage()
+		return 2
+	}
+	if alpha < 0 || alpha >= 1 {
+		fmt.Fprintln(os.Stderr, "error: --alpha must be >= 0 and < 1")
+		return 2
+	}
+	if !(tolerance > 0) {
+		fmt.Fprintln(os.Stderr, "error: --tolerance must be > 0")
+		return 2
+	}
+
+	rank, err := output.RankCrawl(outDir, output.RankOptions{
+		PageRank: crawler.PageRankOptions{
+			Alpha:         alpha,
+			Tolerance:     tolerance,
+			MaxIterations: maxIterations,
+		},
+		PersonalizationPath: personalizationPath,
+		WeightsPath:         weightsPath,
+	})
+	if errors.Is(err, pagerank.ErrNotConverged) {
+		fmt.Fprintf(os.Stderr, "warning: %v; scores are from the last iteration\n", err)
+	} else if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "report ranked: %s (%d nodes, %d edges from %s)\n", filepath.Join(outDir, output.ReportName), rank.Nodes, rank.Edges, rank.Graph)
+	return 0
+}
+
+func runAudit(args []string) int {
+	flagSet := flag.NewFlagSet("audit", flag.ContinueOnError)
+	flagSet.SetOutput(os.Stderr)
+
+	defaults := output.DefaultAuditOptions()
+	var ruleIDs []string
+	for _, rule := range output.AuditRules() {
+		ruleIDs = append(ruleIDs, rule.ID)
+	}
+	var outPath string
+	var outputRaw string
+	var rulesRaw string
+	var disableRaw string
+	opts := defaults
+	flagSet.StringVar(&outPath, "out", "", "Output directory of a crawl, or a report.json path (required)")
+	flagSet.StringVar(&outputRaw, "output", "markdown", "Audit output: markdown|json")
+	flagSet.StringVar(&rulesRaw, "rules", "", "Comma-separated rule IDs to run (default: all): "+strings.Join(ruleIDs, ", "))
+	flagSet.StringVar(&disableRaw, "disable", "", "Comma-separated rule IDs to skip")
+	flagSet.IntVar(&opts.MaxTitleLength, "max-title-length", defaults.MaxTitleLength, "Longest title in characters before title-too-long")
+	flagSet.IntVar(&opts.MaxDescriptionLength, "max-description-length", defaults.MaxDescriptionLength, "Longest meta description in characters before description-too-long")
+	flagSet.IntVar(&opts.MinWords, "min-words", defaults.MinWords, "Fewest main-content words before thin-content")
+	flagSet.IntVar(&opts.MaxDepth, "max-depth", defaults.MaxDepth, "Deepest crawl depth before deep-page")
+	flagSet.IntVar(&opts.MinInboundLinks, "min-inbound-links", defaults.MinInboundLinks, "Fewest internal links to a page before low-inbound-links")
+
+	flagSet.Usage = func() {
+		fmt.Fprintf(flagSet.Output(), "Usage:\n")
+		fmt.Fprintf(flagSet.Output(), "  sitecrawl audit --out <dir|report.json> [flags]\n\n")
+		flagSet.PrintDefaults()
+	}
+
+	if err := flagSet.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if outPath == "" {
+		fmt.Fprintln(os.Stderr, "error: --out is required")
+		fmt.Fprintln(os.Stderr)
+		flagSet.Usage()
+		return 2
+	}
+	if opts.MaxTitleLength < 0 || opts.MaxDescriptionLength < 0 || opts.MinWords < 0 || opts.MaxDepth < 0 || opts.MinInboundLinks < 0 {
+		fmt.Fprintln(os.Stderr, "error: audit thresholds must be >= 0")
+		return 2
+	}
+	format, err := output.ParseAuditFormat(outputRaw)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 2
+	}
+	if opts.Rules, err = output.ParseAuditRules(rulesRaw); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 2
+	}
+	if opts.Disabled, err = output.ParseAuditRules(disableRaw); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 2
+	}
+
+	rep, err := output.ReadReport(outPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 1
+	}
+	if err := output.WriteAudit(os.Stdout, output.Audit(rep, opts), format); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 1
+	}
+	return 0
+}
+
 func printRootUsage(out *os.File) {
 	fmt.Fprintln(out, "sitecrawl")
 	fmt.Fprintln(out, "")
 	fmt.Fprintln(out, "Commands:")
 	fmt.Fprintln(out, "  crawl   Crawl a domain and write page outputs + report.json")
+	fmt.Fprintln(out, "  recover Rebuild report.json from pages.jsonl after a crawl died")
+	fmt.Fprintln(out, "  report  Summarize an existing report.json")
+	fmt.Fprintln(out, "  diff    Compare two crawl output directories")
+	fmt.Fprintln(out, "  rank    Recompute PageRank scores from a saved link graph")
+	fmt.Fprintln(out, "  audit   Lint a crawl's titles, descriptions, headings, content, and links")
 	fmt.Fprintln(out, "")
 	fmt.Fprintln(out, "Examples:")
 	fmt.Fprintln(out, "  sitecrawl crawl --domain example.com --format md --out ./out")
 	fmt.Fprintln(out, "  sitecrawl crawl --domain example.com --strategy limit --max-pages 50 --format md --out ./out")
 	fmt.Fprintln(out, "  sitecrawl crawl --domain example.com --strategy depth --max-depth 2 --format json --out ./out --headful")
+	fmt.Fprintln(out, "  sitecrawl crawl --config crawl.yaml --profile docs --domain example.com")
+	fmt.Fprintln(out, "  sitecrawl report --out ./out --status error --output csv")
+	fmt.Fprintln(out, "  sitecrawl diff --old ./out-monday --new ./out-tuesday > changes.md")
+	fmt.Fprintln(out, "  sitecrawl rank --out ./out --alpha 0.9 --personalization boost.csv")
+	fmt.Fprintln(out, "  sitecrawl audit --out ./out --disable deep-page --min-words 300 > audit.md")
 }
 
 func newLogger(levelRaw string) *slog.Logger {
@@ -1,6 +1,13 @@
 package main
 
-import "testing"
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sbstn/sitecrawl/internal/output"
+)
 
 func TestRunHelp(t *testing.T) {
 	if code := run([]string{"--help"}); code != 0 {
@@ -9,6 +16,21 @@ func TestRunHelp(t *testing.T) {
 	if code := run([]string{"crawl", "--help"}); code != 0 {
 		t.Fatalf("expected exit code 0, got %d", code)
 	}
+	if code := run([]string{"recover", "--help"}); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if code := run([]string{"report", "--help"}); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if code := run([]string{"diff", "--help"}); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if code := run([]string{"rank", "--help"}); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if code := run([]string{"audit", "--help"}); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
 }
 
 func TestRunUnknownCommand(t *testing.T) {
@@ -21,4 +43,161 @@ func TestRunMissingRequiredFlags(t *testing.T) {
 	if code := run([]string{"crawl"}); code != 2 {
 		t.Fatalf("expected exit code 2, got %d", code)
 	}
+	if code := run([]string{"recover"}); code != 2 {
+		t.Fatalf("expected exit code 2, got %d", code)
+	}
+}
+
+func TestRunRecoverWithoutReport(t *testing.T) {
+	if code := run([]string{"recover", "--out", t.TempDir()}); code != 1 {
+		t.Fatalf("expected exit code 1, got %d", code)
+	}
+}
+
+func TestRunInvalidRulesFile(t *testing.T) {
+	if code := run([]string{"crawl", "--domain", "example.com", "--out", t.TempDir(), "--format", "md", "--rules", "does-not-exist.json"}); code != 2 {
+		t.Fatalf("expected exit code 2, got %d", code)
+	}
+}
+
+func TestRunRejectsNegativeAuditThreshold(t *testing.T) {
+	if code := run([]string{"crawl", "--domain", "example.com", "--out", t.TempDir(), "--format", "md", "--audit", "--audit-min-words", "-1"}); code != 2 {
+		t.Fatalf("expected exit code 2, got %d", code)
+	}
+}
+
+func TestRunArchiveRejectsDirectory(t *testing.T) {
+	if code := run([]string{"crawl", "--domain", "example.com", "--out", t.TempDir(), "--format", "md", "--archive", "zip"}); code != 2 {
+		t.Fatalf("expected exit code 2, got %d", code)
+	}
+	if code := run([]string{"crawl", "--domain", "example.com", "--out", "out.rar", "--format", "md", "--archive", "rar"}); code != 2 {
+		t.Fatalf("expected exit code 2, got %d", code)
+	}
+}
+
+func TestRunInvalidConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "crawl.yaml")
+	if err := os.WriteFile(path, []byte("domain: example.com\nmax-pages: 0\n"), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	if code := run([]string{"crawl", "--config", path, "--out", t.TempDir(), "--format", "md"}); code != 2 {
+		t.Fatalf("expected exit code 2, got %d", code)
+	}
+	if code := run([]string{"crawl", "--config", "does-not-exist.yaml"}); code != 2 {
+		t.Fatalf("expected exit code 2, got %d", code)
+	}
+}
+
+func TestApplyConfigPrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "crawl.toml")
+	data := "max-pages = 10\ndelay-ms = 100\nuser-agent = \"file\"\n[profiles.docs]\nfields.price = { css = \".price\" }\n"
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	t.Setenv("SITECRAWL_DELAY_MS", "200")
+	t.Setenv("SITECRAWL_USER_AGENT", "env")
+
+	flagSet := flag.NewFlagSet("crawl", flag.ContinueOnError)
+	maxPages := flagSet.Int("max-pages", 25, "")
+	delayMS := flagSet.Int("delay-ms", 750, "")
+	userAgent := flagSet.String("user-agent", "default", "")
+	maxDepth := flagSet.Int("max-depth", 2, "")
+	flagSet.String("domain", "", "")
+	flagSet.String("rules", "", "")
+	if err := flagSet.Parse([]string{"--user-agent", "flag"}); err != nil {
+		t.Fatalf("parse flags: %v", err)
+	}
+
+	fieldsJSON, err := applyConfig(flagSet, path, "docs")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *maxPages != 10 || *delayMS != 200 || *userAgent != "flag" || *maxDepth != 2 {
+		t.Fatalf("unexpected precedence: max-pages=%d delay-ms=%d user-agent=%q max-depth=%d", *maxPages, *delayMS, *userAgent, *maxDepth)
+	}
+	if fieldsJSON != `{"price":{"css":".price"}}` {
+		t.Fatalf("unexpected fields: %s", fieldsJSON)
+	}
+}
+
+func TestRunReport(t *testing.T) {
+	outDir := t.TempDir()
+	report := `{"domain":"example.com","strategy":"limit","pages":[{"url":"https://example.com/","depth":0,"status":"ok","links_count":0}],"totals":{}}`
+	if err := os.WriteFile(filepath.Join(outDir, output.ReportName), []byte(report), 0o644); err != nil {
+		t.Fatalf("write report: %v", err)
+	}
+	if code := run([]string{"report", "--out", outDir, "--output", "json", "--status", "ok", "--url", "/*"}); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if code := run([]string{"report", "--out", outDir, "--output", "yaml"}); code != 2 {
+		t.Fatalf("expected exit code 2, got %d", code)
+	}
+	if code := run([]string{"report", "--out", filepath.Join(outDir, "missing")}); code != 1 {
+		t.Fatalf("expected exit code 1, got %d", code)
+	}
+}
+
+func TestRunDiff(t *testing.T) {
+	oldDir, newDir := t.TempDir(), t.TempDir()
+	report := `{"domain":"example.com","strategy":"limit","pages":[{"url":"https://example.com/","depth":0,"status":"ok","links_count":0}],"totals":{}}`
+	for _, dir := range []string{oldDir, newDir} {
+		if err := os.WriteFile(filepath.Join(dir, output.ReportName), []byte(report), 0o644); err != nil {
+			t.Fatalf("write report: %v", err)
+		}
+	}
+	if code := run([]string{"diff", "--old", oldDir, "--new", newDir, "--output", "json"}); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if code := run([]string{"diff", "--old", oldDir}); code != 2 {
+		t.Fatalf("expected exit code 2, got %d", code)
+	}
+	if code := run([]string{"diff", "--old", oldDir, "--new", filepath.Join(newDir, "missing")}); code != 1 {
+		t.Fatalf("expected exit code 1, got %d", code)
+	}
+}
+
+func TestRunRank(t *testing.T) {
+	dir := t.TempDir()
+	report := `{"domain":"example.com","strategy":"limit","pages":[{"url":"https://example.com/","final_url":"https://example.com/","depth":0,"status":"ok","links_count":1},{"url":"https://example.com/a","final_url":"https://example.com/a","depth":1,"status":"ok","links_count":1}],"totals":{}}`
+	files := map[string]string{
+		output.ReportName:        report,
+		output.GraphEdgesCSVName: "source,target\nhttps://example.com/,https://example.com/a\nhttps://example.com/a,https://example.com/\n",
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	if code := run([]string{"rank", "--out", dir, "--alpha", "0.5"}); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if code := run([]string{"rank"}); code != 2 {
+		t.Fatalf("expected exit code 2, got %d", code)
+	}
+	if code := run([]string{"rank", "--out", dir, "--alpha", "1"}); code != 2 {
+		t.Fatalf("expected exit code 2, got %d", code)
+	}
+	if code := run([]string{"rank", "--out", dir, "--max-iterations", "0"}); code != 0 {
+		t.Fatalf("expected exit code 0 when PageRank does not converge, got %d", code)
+	}
+}
+
+func TestRunAudit(t *testing.T) {
+	outDir := t.TempDir()
+	report := `{"domain":"example.com","strategy":"limit","pages":[{"url":"https://example.com/","depth":0,"status":"ok","links_count":0,"h1_count":0,"word_count":12}],"totals":{}}`
+	if err := os.WriteFile(filepath.Join(outDir, output.ReportName), []byte(report), 0o644); err != nil {
+		t.Fatalf("write report: %v", err)
+	}
+	if code := run([]string{"audit", "--out", outDir, "--output", "json", "--disable", "thin-content"}); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if code := run([]string{"audit", "--out", outDir, "--rules", "title-missing,spelling"}); code != 2 {
+		t.Fatalf("expected exit code 2, got %d", code)
+	}
+	if code := run([]string{"audit", "--out", outDir, "--min-words", "-1"}); code != 2 {
+		t.Fatalf("expected exit code 2, got %d", code)
+	}
+	if code := run([]string{"audit", "--out", filepath.Join(outDir, "missing")}); code != 1 {
+		t.Fatalf("expected exit code 1, got %d", code)
+	}
 }
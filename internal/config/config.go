@@ -0,0 +1,510 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sbstn/sitecrawl/internal/crawler"
+	"github.com/sbstn/sitecrawl/internal/output"
+)
+
+// EnvPrefix starts the environment variables that override file settings,
+// e.g. SITECRAWL_MAX_PAGES for max-pages.
+const EnvPrefix = "SITECRAWL_"
+
+// FieldsKey holds inline field extraction rules, an alternative to a rules
+// file. Its value is the rules encoded as JSON for crawler.ParseFieldRules.
+const FieldsKey = "fields"
+
+// Setting is one resolved option value and where it was set, either
+// "<file>:<line>" or the environment variable name.
+type Setting struct {
+	Value  string
+	Origin string
+}
+
+// Settings maps crawl flag names to values.
+type Settings map[string]Setting
+
+// File is a parsed configuration file: base settings, named profiles, and
+// per-domain overrides keyed by base domain.
+type File struct {
+	Path     string
+	base     Settings
+	profiles map[string]Settings
+	domains  map[string]Settings
+}
+
+type valueKind int
+
+const (
+	stringValue valueKind = iota
+	intValue
+	boolValue
+	durationValue
+	// listValue accepts a list or a comma-separated string.
+	listValue
+	fieldsValue
+)
+
+type spec struct {
+	kind valueKind
+	// min bounds intValue settings.
+	min   int
+	check func(string) error
+}
+
+var specs = map[string]spec{
+	"domain":                       {kind: stringValue, check: checkDomain},
+	"out":                          {kind: stringValue},
+	"format":                       {kind: listValue, check: checkFormat},
+	"archive":                      {kind: stringValue, check: checkArchive},
+	"layout":                       {kind: stringValue, check: checkParse(output.ParseLayout)},
+	"strategy":                     {kind: stringValue, check: checkParse(crawler.ParseStrategy)},
+	"max-pages":                    {kind: intValue, min: 1},
+	"max-depth":                    {kind: intValue},
+	"clean":                        {kind: boolValue},
+	"extractor":                    {kind: stringValue, check: checkParse(crawler.ParseExtractor)},
+	"headful":                      {kind: boolValue},
+	"delay-ms":                     {kind: intValue},
+	"page-timeout":                 {kind: durationValue},
+	"user-agent":                   {kind: stringValue},
+	"log":                          {kind: stringValue, check: checkLogLevel},
+	"respect-meta-robots":          {kind: boolValue},
+	"respect-ai-optout":            {kind: boolValue},
+	"lang":                         {kind: listValue},
+	"rules":                        {kind: stringValue},
+	FieldsKey:                      {kind: fieldsValue},
+	"chunks":                       {kind: boolValue},
+	"chunk-tokens":                 {kind: intValue, min: 1},
+	"chunk-overlap":                {kind: intValue},
+	"tokenizer":                    {kind: stringValue, check: checkParse(output.ParseTokenizer)},
+	"llms-txt":                     {kind: boolValue},
+	"pack-tokens":                  {kind: intValue},
+	"pack-order":                   {kind: stringValue, check: checkParse(output.ParsePackOrder)},
+	"sqlite":                       {kind: stringValue},
+	"graph-format":                 {kind: listValue, check: checkParse(output.ParseGraphFormats)},
+	"external-links":               {kind: boolValue},
+	"check-links":                  {kind: stringValue, check: checkParse(crawler.ParseLinkCheckMode)},
+	"check-links-concurrency":      {kind: intValue, min: 1},
+	"audit":                        {kind: boolValue},
+	"audit-disable":                {kind: listValue, check: checkParse(output.ParseAuditRules)},
+	"audit-max-title-length":       {kind: intValue},
+	"audit-max-description-length": {kind: intValue},
+	"audit-min-words":              {kind: intValue},
+	"audit-max-depth":              {kind: intValue},
+	"audit-min-inbound-links":      {kind: intValue},
+	"sitemap":                      {kind: listValue, check: checkParse(crawler.ParseSitemaps)},
+}
+
+// Keys returns the setting names a configuration file accepts, sorted.
+// Every key except fields is also a crawl flag.
+func Keys() []string {
+	keys := make([]string, 0, len(specs))
+	for key := range specs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Load reads and validates a configuration file. The format follows the
+// extension: .yaml, .yml, .toml, or .json.
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(path, data)
+}
+
+// Parse validates a configuration file's contents. Unknown keys, values of
+// the wrong type, invalid choices, and unset environment variables are all
+// reported together, each prefixed with "<path>:<line>:". Relative rules
+// paths are resolved against the file's directory.
+func Parse(path string, data []byte) (*File, error) {
+	root, err := decode(path, data)
+	if err != nil {
+		return nil, err
+	}
+	l := &loader{path: path, dir: filepath.Dir(path)}
+	file := &File{Path: path, profiles: map[string]Settings{}, domains: map[string]Settings{}}
+	if root.kind != tableNode {
+		return nil, fmt.Errorf("%s:%d: expected a mapping of settings", path, root.line)
+	}
+
+	base := node{kind: tableNode, line: root.line}
+	for _, e := range root.table {
+		switch e.key {
+		case "profiles":
+			if !l.expectTable(e.key, e.value) {
+				continue
+			}
+			for _, profile := range e.value.table {
+				scope := "profiles." + profile.key
+				if l.expectTable(scope, profile.value) {
+					file.profiles[profile.key] = l.settings(scope, profile.value, true)
+				}
+			}
+		case "domains":
+			if !l.expectTable(e.key, e.value) {
+				continue
+			}
+			for _, domain := range e.value.table {
+				scope := "domains." + domain.key
+				if !l.expectTable(scope, domain.value) {
+					continue
+				}
+				target, err := crawler.NewScope(domain.key)
+				if err != nil {
+					l.errorf(domain.value.line, "%s: %v", scope, err)
+					continue
+				}
+				if _, exists := file.domains[target.BaseDomain]; exists {
+					l.errorf(domain.value.line, "%s: duplicate override for %s", scope, target.BaseDomain)
+					continue
+				}
+				file.domains[target.BaseDomain] = l.settings(scope, domain.value, false)
+			}
+		default:
+			base.table = append(base.table, e)
+		}
+	}
+	file.base = l.settings("", base, true)
+	if err := l.err(); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// Profiles returns the profile names defined in the file, sorted.
+func (f *File) Profiles() []string {
+	names := make([]string, 0, len(f.profiles))
+	for name := range f.profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Resolve layers settings from lowest to highest precedence: the file's base
+// settings, the named profile, the override for the crawled domain, then
+// SITECRAWL_* variables from environ. file may be nil. The crawled domain is
+// flagDomain when set, else the highest layer's domain. Flags given on the
+// command line are applied by the caller on top.
+func Resolve(file *File, profile string, environ []string, flagDomain string) (Settings, error) {
+	resolved := Settings{}
+	if file != nil {
+		resolved.merge(file.base)
+		if profile != "" {
+			settings, ok := file.profiles[profile]
+			if !ok {
+				return nil, fmt.Errorf("%s: unknown profile %q (available: %s)", file.Path, profile, strings.Join(file.Profiles(), ", "))
+			}
+			resolved.merge(settings)
+		}
+	} else if profile != "" {
+		return nil, fmt.Errorf("profile %q requires a config file", profile)
+	}
+
+	env, err := envSettings(environ)
+	if err != nil {
+		return nil, err
+	}
+	if file != nil {
+		domain := flagDomain
+		if domain == "" {
+			domain = env["domain"].Value
+		}
+		if domain == "" {
+			domain = resolved["domain"].Value
+		}
+		if scope, err := crawler.NewScope(domain); err == nil {
+			resolved.merge(file.domains[scope.BaseDomain])
+		}
+	}
+	resolved.merge(env)
+	return resolved, nil
+}
+
+// merge copies layer over s. Inline fields and a rules file are
+// alternatives, so setting one drops the other from lower layers.
+func (s Settings) merge(layer Settings) {
+	for key, setting := range layer {
+		switch key {
+		case FieldsKey:
+			delete(s, "rules")
+		case "rules":
+			delete(s, FieldsKey)
+		}
+		s[key] = setting
+	}
+}
+
+func envSettings(environ []string) (Settings, error) {
+	settings := Settings{}
+	var errs []error
+	for _, kv := range environ {
+		name, value, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(name, EnvPrefix) {
+			continue
+		}
+		key := strings.ReplaceAll(strings.ToLower(strings.TrimPrefix(name, EnvPrefix)), "_", "-")
+		s, known := specs[key]
+		if !known || s.kind == fieldsValue {
+			continue
+		}
+		if err := s.validate(value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", name, err))
+			continue
+		}
+		settings[key] = Setting{Value: value, Origin: name}
+	}
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+	return settings, errors.Join(errs...)
+}
+
+type lineError struct {
+	line int
+	msg  string
+}
+
+type loader struct {
+	path string
+	dir  string
+	errs []lineError
+}
+
+func (l *loader) errorf(line int, format string, args ...any) {
+	l.errs = append(l.errs, lineError{line: line, msg: fmt.Sprintf(format, args...)})
+}
+
+func (l *loader) err() error {
+	if len(l.errs) == 0 {
+		return nil
+	}
+	sort.SliceStable(l.errs, func(i, j int) bool { return l.errs[i].line < l.errs[j].line })
+	errs := make([]error, 0, len(l.errs))
+	for _, e := range l.errs {
+		errs = append(errs, fmt.Errorf("%s:%d: %s", l.path, e.line, e.msg))
+	}
+	return errors.Join(errs...)
+}
+
+func (l *loader) expectTable(name string, n node) bool {
+	if n.kind != tableNode {
+		l.errorf(n.line, "%s: expected a mapping, got a %s", name, n.kind)
+		return false
+	}
+	return true
+}
+
+// settings validates one layer. scope prefixes key names in errors.
+func (l *loader) settings(scope string, n node, allowDomain bool) Settings {
+	settings := Settings{}
+	for _, e := range n.table {
+		name := e.key
+		if scope != "" {
+			name = scope + "." + e.key
+		}
+		s, ok := specs[e.key]
+		if !ok {
+			l.errorf(e.value.line, "%s: unknown key", name)
+			continue
+		}
+		if e.key == "domain" && !allowDomain {
+			l.errorf(e.value.line, "%s: not allowed in a domain override", name)
+			continue
+		}
+		if _, both := settings[otherRulesKey(e.key)]; both {
+			l.errorf(e.value.line, "%s: rules and fields are mutually exclusive", name)
+			continue
+		}
+		value, err := l.value(s, e.value)
+		if err != nil {
+			l.errorf(e.value.line, "%s: %v", name, err)
+			continue
+		}
+		// A rules file is an input that belongs with the config; out and
+		// sqlite stay relative to the working directory like their flags.
+		if e.key == "rules" && value != "" && !filepath.IsAbs(value) {
+			value = filepath.Join(l.dir, value)
+		}
+		settings[e.key] = Setting{Value: value, Origin: fmt.Sprintf("%s:%d", l.path, e.value.line)}
+	}
+	return settings
+}
+
+func otherRulesKey(key string) string {
+	switch key {
+	case "rules":
+		return FieldsKey
+	case FieldsKey:
+		return "rules"
+	default:
+		return ""
+	}
+}
+
+// value flattens n to its setting string, expands environment variables,
+// and validates the result.
+func (l *loader) value(s spec, n node) (string, error) {
+	if s.kind == fieldsValue {
+		return fieldsJSON(n)
+	}
+	var raw string
+	switch {
+	case n.kind == scalarNode:
+		raw = n.scalar
+	case n.kind == listNode && s.kind == listValue:
+		items := make([]string, 0, len(n.list))
+		for _, item := range n.list {
+			if item.kind != scalarNode {
+				return "", fmt.Errorf("expected a list of strings, got a %s item", item.kind)
+			}
+			items = append(items, item.scalar)
+		}
+		raw = strings.Join(items, ",")
+	default:
+		return "", fmt.Errorf("expected a scalar, got a %s", n.kind)
+	}
+	value, err := expand(raw)
+	if err != nil {
+		return "", err
+	}
+	if err := s.validate(value); err != nil {
+		return "", err
+	}
+	return value, nil
+}
+
+func (s spec) validate(value string) error {
+	switch s.kind {
+	case intValue:
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", value)
+		}
+		if n < s.min {
+			return fmt.Errorf("must be >= %d", s.min)
+		}
+	case boolValue:
+		if _, err := strconv.ParseBool(strings.TrimSpace(value)); err != nil {
+			return fmt.Errorf("invalid boolean %q", value)
+		}
+	case durationValue:
+		d, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("invalid duration %q (example: 20s)", value)
+		}
+		if d <= 0 {
+			return fmt.Errorf("must be > 0")
+		}
+	}
+	if s.check != nil {
+		return s.check(value)
+	}
+	return nil
+}
+
+func checkParse[T any](parse func(string) (T, error)) func(string) error {
+	return func(raw string) error {
+		_, err := parse(raw)
+		return err
+	}
+}
+
+func checkDomain(raw string) error {
+	_, err := crawler.NewScope(raw)
+	return err
+}
+
+func checkFormat(raw string) error {
+	_, _, err := output.ParseFormats(raw)
+	return err
+}
+
+func checkArchive(raw string) error {
+	if raw == "" {
+		return nil
+	}
+	_, err := output.ParseArchiveFormat(raw)
+	return err
+}
+
+func checkLogLevel(raw string) error {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "debug", "info", "warn", "warning", "error":
+		return nil
+	default:
+		return fmt.Errorf("invalid log level %q (allowed: debug, info, warn, error)", raw)
+	}
+}
+
+// fieldsJSON encodes inline field rules for crawler.ParseFieldRules,
+// validating them on the way.
+func fieldsJSON(n node) (string, error) {
+	if n.kind != tableNode {
+		return "", fmt.Errorf("expected a mapping of field rules, got a %s", n.kind)
+	}
+	data, err := json.Marshal(n.plain())
+	if err != nil {
+		return "", err
+	}
+	if _, err := crawler.ParseFieldRules(data); err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// expand replaces ${NAME} with the environment variable's value and
+// ${NAME:-default} with the value or, when unset or empty, the default.
+// "$$" is a literal "$". An unset variable without a default is an error.
+func expand(raw string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(raw); i++ {
+		if raw[i] != '$' || i+1 == len(raw) {
+			b.WriteByte(raw[i])
+			continue
+		}
+		switch raw[i+1] {
+		case '$':
+			b.WriteByte('$')
+			i++
+			continue
+		case '{':
+		default:
+			b.WriteByte('$')
+			continue
+		}
+		end := strings.IndexByte(raw[i+2:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated ${ in %q", raw)
+		}
+		expr := raw[i+2 : i+2+end]
+		name, fallback, hasDefault := strings.Cut(expr, ":-")
+		if !envNamePattern.MatchString(name) {
+			return "", fmt.Errorf("invalid environment variable name %q", name)
+		}
+		value, ok := os.LookupEnv(name)
+		switch {
+		case hasDefault && value == "":
+			value = fallback
+		case !ok:
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		b.WriteString(value)
+		i += 2 + end
+	}
+	return b.String(), nil
+}
+
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
@@ -0,0 +1,320 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const yamlConfig = `# crawl defaults
+domain: example.com
+format: [md, warc]
+max-pages: 50
+page-timeout: 30s
+rules: rules.json
+profiles:
+  docs:
+    strategy: depth
+    max-depth: 3
+    fields:
+      price: {css: .price}
+      tags:
+        css: a.tag
+        cardinality: multiple
+domains:
+  www.Example.com:
+    delay-ms: ${CRAWL_DELAY:-2000}
+    user-agent: ${CRAWL_UA}
+`
+
+const tomlConfig = `domain = "example.com"
+format = ["md", "warc"]
+max-pages = 50
+page-timeout = "30s"
+rules = "rules.json"
+
+[profiles.docs]
+strategy = "depth"
+max-depth = 3
+fields.price = { css = ".price" }
+fields.tags = { css = "a.tag", cardinality = "multiple" }
+
+[domains."www.Example.com"]
+delay-ms = "${CRAWL_DELAY:-2000}"
+user-agent = "${CRAWL_UA}"
+`
+
+const jsonConfig = `{
+  "domain": "example.com",
+  "format": ["md", "warc"],
+  "max-pages": 50,
+  "page-timeout": "30s",
+  "rules": "rules.json",
+  "profiles": {
+    "docs": {
+      "strategy": "depth",
+      "max-depth": 3,
+      "fields": {"price": {"css": ".price"}, "tags": {"css": "a.tag", "cardinality": "multiple"}}
+    }
+  },
+  "domains": {
+    "www.Example.com": {"delay-ms": "${CRAWL_DELAY:-2000}", "user-agent": "${CRAWL_UA}"}
+  }
+}
+`
+
+func TestParseFormatsAgree(t *testing.T) {
+	t.Setenv("CRAWL_UA", "bot/1.0")
+	dir := t.TempDir()
+
+	for name, data := range map[string]string{
+		"crawl.yaml": yamlConfig,
+		"crawl.toml": tomlConfig,
+		"crawl.json": jsonConfig,
+	} {
+		file, err := Parse(filepath.Join(dir, name), []byte(data))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		settings, err := Resolve(file, "docs", nil, "")
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		want := map[string]string{
+			"domain":       "example.com",
+			"format":       "md,warc",
+			"max-pages":    "50",
+			"page-timeout": "30s",
+			"strategy":     "depth",
+			"max-depth":    "3",
+			FieldsKey:      `{"price":{"css":".price"},"tags":{"cardinality":"multiple","css":"a.tag"}}`,
+			"delay-ms":     "2000",
+			"user-agent":   "bot/1.0",
+		}
+		if len(settings) != len(want) {
+			t.Fatalf("%s: expected %d settings, got %v", name, len(want), settings)
+		}
+		for key, value := range want {
+			if settings[key].Value != value {
+				t.Fatalf("%s: expected %s=%q, got %q", name, key, value, settings[key].Value)
+			}
+		}
+		if _, ok := settings["rules"]; ok {
+			t.Fatalf("%s: expected profile fields to replace base rules", name)
+		}
+	}
+}
+
+func TestParseReportsLineNumbers(t *testing.T) {
+	data := `domain: example.com
+max-pages: zero
+profiles:
+  fast:
+    delay: 0
+    strategy: breadth
+domains:
+  example.com:
+    domain: other.com
+    user-agent: ${NOT_SET_ANYWHERE}
+`
+	_, err := Parse("crawl.yaml", []byte(data))
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+	want := []string{
+		`crawl.yaml:2: max-pages: invalid integer "zero"`,
+		`crawl.yaml:5: profiles.fast.delay: unknown key`,
+		`crawl.yaml:6: profiles.fast.strategy: invalid strategy "breadth"`,
+		`crawl.yaml:9: domains.example.com.domain: not allowed in a domain override`,
+		`crawl.yaml:10: domains.example.com.user-agent: environment variable NOT_SET_ANYWHERE is not set`,
+	}
+	lines := strings.Split(err.Error(), "\n")
+	if len(lines) != len(want) {
+		t.Fatalf("expected %d errors, got:\n%v", len(want), err)
+	}
+	for i, prefix := range want {
+		if !strings.HasPrefix(lines[i], prefix) {
+			t.Fatalf("expected error %d to start with %q, got %q", i, prefix, lines[i])
+		}
+	}
+}
+
+func TestParseTOMLReportsLineNumbers(t *testing.T) {
+	data := "domain = \"example.com\"\n\n[profiles.fast]\nclean = \"maybe\"\n"
+	_, err := Parse("crawl.toml", []byte(data))
+	if err == nil || !strings.HasPrefix(err.Error(), `crawl.toml:4: profiles.fast.clean: invalid boolean "maybe"`) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err = Parse("crawl.toml", []byte("domain = \"example.com\"\nmax-pages = \n"))
+	if err == nil || !strings.HasPrefix(err.Error(), "crawl.toml:2:") {
+		t.Fatalf("expected syntax error on line 2, got %v", err)
+	}
+}
+
+func TestParseJSONReportsLineNumbers(t *testing.T) {
+	file, err := Parse("crawl.json", []byte(`{"domain": "example.com", "user-agent": "a\/b"}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	settings, err := Resolve(file, "", nil, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := settings["user-agent"].Value; got != "a/b" {
+		t.Fatalf("expected escaped slash to decode, got %q", got)
+	}
+
+	_, err = Parse("crawl.json", []byte("{\n  \"domain\": \"example.com\",\n  max-pages: 10\n}\n"))
+	if err == nil || !strings.HasPrefix(err.Error(), "crawl.json:3:") {
+		t.Fatalf("expected syntax error on line 3, got %v", err)
+	}
+}
+
+func TestParseRejectsDuplicateKeys(t *testing.T) {
+	for name, data := range map[string]string{
+		"crawl.yaml": "domain: example.com\nprofiles:\n  fast:\n    delay-ms: 1\n    delay-ms: 2\n",
+		"crawl.json": "{\n  \"domain\": \"example.com\",\n  \"profiles\": {\n    \"fast\": {\"delay-ms\": 1,\n      \"delay-ms\": 2}\n  }\n}\n",
+		"crawl.toml": "domain = \"example.com\"\n[profiles.fast]\ndelay-ms = 1\n\ndelay-ms = 2\n",
+	} {
+		_, err := Parse(name, []byte(data))
+		if want := name + `:5: duplicate key "delay-ms"`; err == nil || err.Error() != want {
+			t.Fatalf("expected %q, got %v", want, err)
+		}
+	}
+}
+
+func TestParseRejectsRulesAndFieldsTogether(t *testing.T) {
+	data := "rules: rules.json\nfields:\n  price: {css: .price}\n"
+	_, err := Parse("crawl.yaml", []byte(data))
+	if err == nil || !strings.Contains(err.Error(), "crawl.yaml:2: fields: rules and fields are mutually exclusive") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestParseRejectsUnsupportedExtension(t *testing.T) {
+	if _, err := Parse("crawl.ini", []byte("domain=example.com")); err == nil {
+		t.Fatalf("expected error for .ini config")
+	}
+}
+
+func TestParseResolvesRulesRelativeToFile(t *testing.T) {
+	file, err := Parse(filepath.Join("conf", "crawl.yaml"), []byte("rules: rules.json\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	settings, err := Resolve(file, "", nil, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := settings["rules"].Value, filepath.Join("conf", "rules.json"); got != want {
+		t.Fatalf("expected rules %q, got %q", want, got)
+	}
+}
+
+func TestResolvePrecedence(t *testing.T) {
+	data := `domain: example.com
+max-pages: 10
+delay-ms: 100
+clean: false
+profiles:
+  deep:
+    max-pages: 20
+domains:
+  example.org:
+    delay-ms: 300
+  example.com:
+    delay-ms: 200
+`
+	file, err := Parse("crawl.yaml", []byte(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	environ := []string{"SITECRAWL_CLEAN=true", "SITECRAWL_UNKNOWN=1", "PATH=/bin"}
+
+	tests := []struct {
+		name       string
+		profile    string
+		flagDomain string
+		want       map[string]string
+	}{
+		{
+			name: "domain override beats base",
+			want: map[string]string{"max-pages": "10", "delay-ms": "200", "clean": "true"},
+		},
+		{
+			name:    "profile beats base",
+			profile: "deep",
+			want:    map[string]string{"max-pages": "20", "delay-ms": "200"},
+		},
+		{
+			name:       "flag domain picks override",
+			flagDomain: "https://www.example.org/",
+			want:       map[string]string{"max-pages": "10", "delay-ms": "300"},
+		},
+	}
+	for _, tt := range tests {
+		settings, err := Resolve(file, tt.profile, environ, tt.flagDomain)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		for key, value := range tt.want {
+			if settings[key].Value != value {
+				t.Fatalf("%s: expected %s=%q, got %q", tt.name, key, value, settings[key].Value)
+			}
+		}
+		if settings["clean"].Origin != "SITECRAWL_CLEAN" {
+			t.Fatalf("%s: expected clean from environment, got %+v", tt.name, settings["clean"])
+		}
+		if _, ok := settings["unknown"]; ok {
+			t.Fatalf("%s: unexpected unknown setting", tt.name)
+		}
+	}
+}
+
+func TestResolveErrors(t *testing.T) {
+	file, err := Parse("crawl.yaml", []byte("profiles:\n  docs:\n    max-depth: 1\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := Resolve(file, "blog", nil, ""); err == nil || !strings.Contains(err.Error(), "available: docs") {
+		t.Fatalf("expected unknown profile error, got %v", err)
+	}
+	if _, err := Resolve(nil, "docs", nil, ""); err == nil {
+		t.Fatalf("expected error for profile without config file")
+	}
+	if _, err := Resolve(nil, "", []string{"SITECRAWL_MAX_PAGES=lots"}, ""); err == nil || !strings.HasPrefix(err.Error(), "SITECRAWL_MAX_PAGES:") {
+		t.Fatalf("expected environment validation error, got %v", err)
+	}
+}
+
+func TestExpand(t *testing.T) {
+	t.Setenv("SITECRAWL_TEST_HOST", "example.com")
+	t.Setenv("SITECRAWL_TEST_EMPTY", "")
+
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "plain", want: "plain"},
+		{in: "https://${SITECRAWL_TEST_HOST}/", want: "https://example.com/"},
+		{in: "${SITECRAWL_TEST_EMPTY:-fallback}", want: "fallback"},
+		{in: "${SITECRAWL_TEST_EMPTY}", want: ""},
+		{in: "cost $5 and $${literal}", want: "cost $5 and ${literal}"},
+	}
+	for _, tt := range tests {
+		got, err := expand(tt.in)
+		if err != nil {
+			t.Fatalf("expand(%q): unexpected error: %v", tt.in, err)
+		}
+		if got != tt.want {
+			t.Fatalf("expand(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"${SITECRAWL_TEST_UNSET_VAR}", "${unterminated", "${1BAD}"} {
+		if _, err := expand(in); err == nil {
+			t.Fatalf("expand(%q): expected error", in)
+		}
+	}
+}
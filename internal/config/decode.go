@@ -0,0 +1,365 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2/unstable"
+	"go.yaml.in/yaml/v3"
+)
+
+// node is a decoded config value. Scalars keep their source text, so
+// interpolation and type checks work the same for every file format.
+type node struct {
+	line   int
+	scalar string
+	list   []node
+	// table holds mapping entries in file order; nil for scalars and lists.
+	table []entry
+	kind  nodeKind
+}
+
+type nodeKind int
+
+const (
+	scalarNode nodeKind = iota
+	listNode
+	tableNode
+)
+
+type entry struct {
+	key   string
+	value node
+}
+
+func (k nodeKind) String() string {
+	switch k {
+	case listNode:
+		return "list"
+	case tableNode:
+		return "table"
+	default:
+		return "scalar"
+	}
+}
+
+func (n *node) lookup(key string) *node {
+	for i := range n.table {
+		if n.table[i].key == key {
+			return &n.table[i].value
+		}
+	}
+	return nil
+}
+
+// plain converts n to strings, slices, and maps for JSON encoding.
+func (n node) plain() any {
+	switch n.kind {
+	case listNode:
+		values := make([]any, 0, len(n.list))
+		for _, item := range n.list {
+			values = append(values, item.plain())
+		}
+		return values
+	case tableNode:
+		values := make(map[string]any, len(n.table))
+		for _, e := range n.table {
+			values[e.key] = e.value.plain()
+		}
+		return values
+	default:
+		return n.scalar
+	}
+}
+
+// yamlErrorLine matches the line number yaml puts in most syntax errors.
+var yamlErrorLine = regexp.MustCompile(`^yaml: line (\d+): `)
+
+// decode parses data by the extension of path.
+func decode(path string, data []byte) (node, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return decodeYAML(path, data)
+	case ".json":
+		return decodeJSON(path, data)
+	case ".toml":
+		return decodeTOML(path, data)
+	default:
+		return node{}, fmt.Errorf("%s: unsupported config format %q (allowed: .yaml, .yml, .toml, .json)", path, filepath.Ext(path))
+	}
+}
+
+func decodeYAML(path string, data []byte) (node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		// Most yaml errors name the line as "yaml: line 3: ..."; the rest
+		// (e.g. unexpected end of stream) carry no position.
+		if m := yamlErrorLine.FindStringSubmatch(err.Error()); m != nil {
+			return node{}, fmt.Errorf("%s:%s: %s", path, m[1], err.Error()[len(m[0]):])
+		}
+		return node{}, fmt.Errorf("%s: %v", path, err)
+	}
+	if len(doc.Content) == 0 {
+		return node{kind: tableNode, line: 1}, nil
+	}
+	return fromYAML(path, doc.Content[0])
+}
+
+func fromYAML(path string, n *yaml.Node) (node, error) {
+	switch n.Kind {
+	case yaml.AliasNode:
+		return fromYAML(path, n.Alias)
+	case yaml.ScalarNode:
+		if n.Tag == "!!null" {
+			return node{}, fmt.Errorf("%s:%d: empty value", path, n.Line)
+		}
+		return node{line: n.Line, scalar: n.Value}, nil
+	case yaml.SequenceNode:
+		out := node{line: n.Line, kind: listNode}
+		for _, item := range n.Content {
+			value, err := fromYAML(path, item)
+			if err != nil {
+				return node{}, err
+			}
+			out.list = append(out.list, value)
+		}
+		return out, nil
+	case yaml.MappingNode:
+		out := node{line: n.Line, kind: tableNode, table: []entry{}}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, raw := n.Content[i], n.Content[i+1]
+			if key.Tag == "!!merge" {
+				return node{}, fmt.Errorf("%s:%d: merge keys are not supported", path, key.Line)
+			}
+			if out.lookup(key.Value) != nil {
+				return node{}, fmt.Errorf("%s:%d: duplicate key %q", path, key.Line, key.Value)
+			}
+			value, err := fromYAML(path, raw)
+			if err != nil {
+				return node{}, err
+			}
+			value.line = key.Line
+			out.table = append(out.table, entry{key: key.Value, value: value})
+		}
+		return out, nil
+	default:
+		return node{}, fmt.Errorf("%s:%d: unsupported YAML value", path, n.Line)
+	}
+}
+
+// decodeJSON walks the token stream so every value keeps the line of its
+// key and syntax errors are reported by line.
+func decodeJSON(path string, data []byte) (node, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return node{kind: tableNode, line: 1}, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	d := jsonDecoder{path: path, data: data, dec: dec}
+	root, err := d.value()
+	if err != nil {
+		return node{}, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return node{}, fmt.Errorf("%s:%d: unexpected data after the top-level value", path, d.line(dec.InputOffset()))
+	}
+	return root, nil
+}
+
+type jsonDecoder struct {
+	path string
+	data []byte
+	dec  *json.Decoder
+}
+
+// line returns the line of byte offset in the source.
+func (d jsonDecoder) line(offset int64) int {
+	return 1 + bytes.Count(d.data[:min(offset, int64(len(d.data)))], []byte("\n"))
+}
+
+// token reads the next token and the line it ends on. Tokens never span
+// lines, so that is also the line it starts on.
+func (d jsonDecoder) token() (json.Token, int, error) {
+	tok, err := d.dec.Token()
+	if err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			return nil, 0, fmt.Errorf("%s:%d: %s", d.path, d.line(syntaxErr.Offset), syntaxErr.Error())
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, 0, fmt.Errorf("%s:%d: unexpected end of JSON input", d.path, d.line(int64(len(d.data))))
+		}
+		return nil, 0, fmt.Errorf("%s: %v", d.path, err)
+	}
+	return tok, d.line(d.dec.InputOffset()), nil
+}
+
+func (d jsonDecoder) value() (node, error) {
+	tok, line, err := d.token()
+	if err != nil {
+		return node{}, err
+	}
+	switch tok := tok.(type) {
+	case json.Delim:
+		if tok == '[' {
+			out := node{line: line, kind: listNode}
+			for d.dec.More() {
+				item, err := d.value()
+				if err != nil {
+					return node{}, err
+				}
+				out.list = append(out.list, item)
+			}
+			_, _, err := d.token()
+			return out, err
+		}
+		out := node{line: line, kind: tableNode, table: []entry{}}
+		for d.dec.More() {
+			tok, keyLine, err := d.token()
+			if err != nil {
+				return node{}, err
+			}
+			key := tok.(string)
+			if out.lookup(key) != nil {
+				return node{}, fmt.Errorf("%s:%d: duplicate key %q", d.path, keyLine, key)
+			}
+			value, err := d.value()
+			if err != nil {
+				return node{}, err
+			}
+			value.line = keyLine
+			out.table = append(out.table, entry{key: key, value: value})
+		}
+		_, _, err := d.token()
+		return out, err
+	case string:
+		return node{line: line, scalar: tok}, nil
+	case json.Number:
+		return node{line: line, scalar: tok.String()}, nil
+	case bool:
+		return node{line: line, scalar: strconv.FormatBool(tok)}, nil
+	default:
+		return node{}, fmt.Errorf("%s:%d: empty value", d.path, line)
+	}
+}
+
+func decodeTOML(path string, data []byte) (node, error) {
+	root := node{line: 1, kind: tableNode, table: []entry{}}
+	parser := unstable.Parser{}
+	parser.Reset(data)
+	current := &root
+	for parser.NextExpression() {
+		expr := parser.Expression()
+		switch expr.Kind {
+		case unstable.Table:
+			table, err := tomlTable(path, &parser, &root, expr.Key())
+			if err != nil {
+				return node{}, err
+			}
+			current = table
+		case unstable.ArrayTable:
+			keys := expr.Key()
+			keys.Next()
+			line := parser.Shape(keys.Node().Raw).Start.Line
+			return node{}, fmt.Errorf("%s:%d: arrays of tables are not supported", path, line)
+		case unstable.KeyValue:
+			if err := tomlKeyValue(path, &parser, current, expr); err != nil {
+				return node{}, err
+			}
+		}
+	}
+	if err := parser.Error(); err != nil {
+		var parseErr *unstable.ParserError
+		if errors.As(err, &parseErr) && len(parseErr.Highlight) > 0 {
+			line := parser.Shape(parser.Range(parseErr.Highlight)).Start.Line
+			return node{}, fmt.Errorf("%s:%d: %s", path, line, parseErr.Message)
+		}
+		return node{}, fmt.Errorf("%s: %v", path, err)
+	}
+	return root, nil
+}
+
+// tomlTable walks a dotted table key from root, creating tables as needed.
+func tomlTable(path string, parser *unstable.Parser, root *node, keys unstable.Iterator) (*node, error) {
+	table := root
+	for keys.Next() {
+		key := keys.Node()
+		line := parser.Shape(key.Raw).Start.Line
+		next := table.lookup(string(key.Data))
+		if next == nil {
+			table.table = append(table.table, entry{key: string(key.Data), value: node{line: line, kind: tableNode, table: []entry{}}})
+			next = &table.table[len(table.table)-1].value
+		}
+		if next.kind != tableNode {
+			return nil, fmt.Errorf("%s:%d: %s is not a table", path, line, key.Data)
+		}
+		table = next
+	}
+	return table, nil
+}
+
+func tomlKeyValue(path string, parser *unstable.Parser, table *node, expr *unstable.Node) error {
+	keys := expr.Key()
+	var parts []string
+	var line int
+	for keys.Next() {
+		key := keys.Node()
+		parts = append(parts, string(key.Data))
+		line = parser.Shape(key.Raw).Start.Line
+	}
+	for _, part := range parts[:len(parts)-1] {
+		next := table.lookup(part)
+		if next == nil {
+			table.table = append(table.table, entry{key: part, value: node{line: line, kind: tableNode, table: []entry{}}})
+			next = &table.table[len(table.table)-1].value
+		}
+		if next.kind != tableNode {
+			return fmt.Errorf("%s:%d: %s is not a table", path, line, part)
+		}
+		table = next
+	}
+	last := parts[len(parts)-1]
+	if table.lookup(last) != nil {
+		return fmt.Errorf("%s:%d: duplicate key %q", path, line, last)
+	}
+	value, err := fromTOML(path, parser, expr.Value(), line)
+	if err != nil {
+		return err
+	}
+	table.table = append(table.table, entry{key: last, value: value})
+	return nil
+}
+
+func fromTOML(path string, parser *unstable.Parser, value *unstable.Node, line int) (node, error) {
+	switch value.Kind {
+	case unstable.Array:
+		out := node{line: line, kind: listNode}
+		items := value.Children()
+		for items.Next() {
+			item, err := fromTOML(path, parser, items.Node(), line)
+			if err != nil {
+				return node{}, err
+			}
+			out.list = append(out.list, item)
+		}
+		return out, nil
+	case unstable.InlineTable:
+		out := node{line: line, kind: tableNode, table: []entry{}}
+		children := value.Children()
+		for children.Next() {
+			if err := tomlKeyValue(path, parser, &out, children.Node()); err != nil {
+				return node{}, err
+			}
+		}
+		return out, nil
+	default:
+		return node{line: line, scalar: string(value.Data)}, nil
+	}
+}
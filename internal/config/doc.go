@@ -0,0 +1,19 @@
+// Package config loads crawl settings from YAML, TOML, or JSON files.
+//
+// A file holds base settings named like the crawl flags, named profiles
+// under "profiles", and per-domain overrides under "domains":
+//
+//	max-pages: 50
+//	format: md
+//	profiles:
+//	  docs:
+//	    strategy: depth
+//	    max-depth: 3
+//	domains:
+//	  example.com:
+//	    delay-ms: 2000
+//	    user-agent: ${CRAWL_UA:-sitecrawl}
+//
+// Settings resolve with precedence flag defaults < file (base < profile <
+// domain) < SITECRAWL_* environment variables < explicit flags.
+package config
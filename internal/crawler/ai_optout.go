@@ -0,0 +1,283 @@
+package crawler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/temoto/robotstxt"
+)
+
+const (
+	// AISignalNoAI is the noai robots directive (meta robots or X-Robots-Tag).
+	AISignalNoAI = "noai"
+	// AISignalNoImageAI is the noimageai robots directive. It is recorded but
+	// does not exclude a page because it only concerns images.
+	AISignalNoImageAI = "noimageai"
+	// AISignalTDMReservation is a tdm-reservation=1 response header or meta tag.
+	AISignalTDMReservation = "tdm-reservation"
+	// AISignalTDMRep is a matching reservation in /.well-known/tdmrep.json.
+	AISignalTDMRep = "tdmrep.json"
+	// AISignalAITxt is a disallow rule in the host's ai.txt.
+	AISignalAITxt = "ai.txt"
+)
+
+const maxPolicyFileBytes = 512 * 1024
+
+// TDMRepRule is one entry of a host's /.well-known/tdmrep.json file.
+type TDMRepRule struct {
+	Location       string `json:"location"`
+	TDMReservation int    `json:"tdm-reservation"`
+	TDMPolicy      string `json:"tdm-policy,omitempty"`
+}
+
+// HostAIPolicy records the host-level AI/TDM opt-out files found for a host.
+type HostAIPolicy struct {
+	Host        string
+	TDMRepRules []TDMRepRule
+	AITxt       bool
+}
+
+type aiPolicyEntry struct {
+	policy    HostAIPolicy
+	aiTxt     *robotstxt.Group
+	tdmRepExp []*regexp.Regexp
+}
+
+type aiPolicyCache struct {
+	userAgent string
+	logger    *slog.Logger
+	client    *http.Client
+	mu        sync.Mutex
+	entries   map[string]*aiPolicyEntry
+}
+
+func newAIPolicyCache(userAgent string, logger *slog.Logger) *aiPolicyCache {
+	return &aiPolicyCache{
+		userAgent: userAgent,
+		logger:    logger,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		entries: map[string]*aiPolicyEntry{},
+	}
+}
+
+// HostSignals returns the host-level opt-out signals (tdmrep.json, ai.txt)
+// that apply to rawURL.
+func (ac *aiPolicyCache) HostSignals(rawURL string) ([]string, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	host, err := normalizeHost(parsed.Hostname())
+	if err != nil {
+		return nil, err
+	}
+	entry := ac.getOrLoad(host)
+
+	path := parsed.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if parsed.RawQuery != "" {
+		path += "?" + parsed.RawQuery
+	}
+
+	var signals []string
+	if reserved, ok := entry.tdmReservation(path); ok && reserved {
+		signals = append(signals, AISignalTDMRep)
+	}
+	if entry.aiTxt != nil && !entry.aiTxt.Test(path) {
+		signals = append(signals, AISignalAITxt)
+	}
+	return signals, nil
+}
+
+// Policies returns the loaded host policies sorted by host.
+func (ac *aiPolicyCache) Policies() []HostAIPolicy {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+	policies := make([]HostAIPolicy, 0, len(ac.entries))
+	for _, entry := range ac.entries {
+		policies = append(policies, entry.policy)
+	}
+	sort.Slice(policies, func(i, j int) bool {
+		return policies[i].Host < policies[j].Host
+	})
+	return policies
+}
+
+func (ac *aiPolicyCache) getOrLoad(host string) *aiPolicyEntry {
+	ac.mu.Lock()
+	entry, ok := ac.entries[host]
+	ac.mu.Unlock()
+	if ok {
+		return entry
+	}
+
+	entry = &aiPolicyEntry{policy: HostAIPolicy{Host: host}}
+	if body, found, err := ac.fetch(host, "/.well-known/tdmrep.json"); err != nil {
+		ac.logger.Debug("tdmrep.json fetch failed", "host", host, "error", err)
+	} else if found {
+		rules, parseErr := parseTDMRep(body)
+		if parseErr != nil {
+			ac.logger.Warn("invalid tdmrep.json ignored", "host", host, "error", parseErr)
+		} else {
+			entry.policy.TDMRepRules = rules
+			for _, rule := range rules {
+				entry.tdmRepExp = append(entry.tdmRepExp, compileLocationPattern(rule.Location))
+			}
+		}
+	}
+	if body, found, err := ac.fetch(host, "/ai.txt"); err != nil {
+		ac.logger.Debug("ai.txt fetch failed", "host", host, "error", err)
+	} else if found {
+		data, parseErr := robotstxt.FromBytes(body)
+		if parseErr != nil {
+			ac.logger.Warn("invalid ai.txt ignored", "host", host, "error", parseErr)
+		} else {
+			entry.policy.AITxt = true
+			entry.aiTxt = data.FindGroup(ac.userAgent)
+		}
+	}
+
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+	if existing, ok := ac.entries[host]; ok {
+		return existing
+	}
+	ac.entries[host] = entry
+	return entry
+}
+
+// fetch downloads a policy file. Only 200 responses count as found; missing
+// or failing files never imply an opt-out.
+func (ac *aiPolicyCache) fetch(host, path string) ([]byte, bool, error) {
+	var lastErr error
+	for _, scheme := range []string{"https", "http"} {
+		req, err := http.NewRequest(http.MethodGet, scheme+"://"+host+path, nil)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		req.Header.Set("User-Agent", ac.userAgent)
+		resp, err := ac.client.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxPolicyFileBytes))
+		_ = resp.Body.Close()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return body, resp.StatusCode == http.StatusOK, nil
+	}
+	return nil, false, lastErr
+}
+
+// tdmReservation returns the reservation of the longest matching tdmrep.json
+// location for path.
+func (e *aiPolicyEntry) tdmReservation(path string) (bool, bool) {
+	matched := -1
+	for i, exp := range e.tdmRepExp {
+		if !exp.MatchString(path) {
+			continue
+		}
+		if matched < 0 || len(e.policy.TDMRepRules[i].Location) > len(e.policy.TDMRepRules[matched].Location) {
+			matched = i
+		}
+	}
+	if matched < 0 {
+		return false, false
+	}
+	return e.policy.TDMRepRules[matched].TDMReservation == 1, true
+}
+
+func parseTDMRep(body []byte) ([]TDMRepRule, error) {
+	var rules []TDMRepRule
+	if err := json.Unmarshal(body, &rules); err != nil {
+		return nil, err
+	}
+	for _, rule := range rules {
+		if rule.Location == "" {
+			return nil, fmt.Errorf("tdmrep rule without location")
+		}
+	}
+	return rules, nil
+}
+
+// compileLocationPattern turns a robots-style path pattern ("*" wildcard, "$"
+// end anchor) into an anchored regular expression.
+func compileLocationPattern(location string) *regexp.Regexp {
+	pattern := location
+	if !strings.HasPrefix(pattern, "/") && !strings.HasPrefix(pattern, "*") {
+		pattern = "/" + pattern
+	}
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+	parts := strings.Split(pattern, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	expr := "^" + strings.Join(parts, ".*")
+	if anchored {
+		expr += "$"
+	}
+	return regexp.MustCompile(expr)
+}
+
+// pageAISignals collects page-level opt-out signals from robots directives,
+// the tdm-reservation response header, and the tdm-reservation meta tag.
+func pageAISignals(robotsDirectives []string, headers http.Header, metaTDMReservation string) []string {
+	var signals []string
+	if hasRobotsDirective(robotsDirectives, AISignalNoAI) {
+		signals = append(signals, AISignalNoAI)
+	}
+	if hasRobotsDirective(robotsDirectives, AISignalNoImageAI) {
+		signals = append(signals, AISignalNoImageAI)
+	}
+	if strings.TrimSpace(headers.Get("Tdm-Reservation")) == "1" || strings.TrimSpace(metaTDMReservation) == "1" {
+		signals = append(signals, AISignalTDMReservation)
+	}
+	return signals
+}
+
+// isAIOptOut reports whether any signal reserves the page against AI/TDM use.
+func isAIOptOut(signals []string) bool {
+	for _, signal := range signals {
+		if signal != AISignalNoImageAI {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeSignals returns the sorted union of signal lists.
+func mergeSignals(lists ...[]string) []string {
+	set := map[string]struct{}{}
+	for _, list := range lists {
+		for _, signal := range list {
+			set[signal] = struct{}{}
+		}
+	}
+	if len(set) == 0 {
+		return nil
+	}
+	merged := make([]string, 0, len(set))
+	for signal := range set {
+		merged = append(merged, signal)
+	}
+	sort.Strings(merged)
+	return merged
+}
@@ -0,0 +1,72 @@
+package crawler
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestTDMRepLongestLocationWins(t *testing.T) {
+	rules, err := parseTDMRep([]byte(`[
+		{"location": "/*", "tdm-reservation": 1, "tdm-policy": "https://example.com/policy.json"},
+		{"location": "/blog/*", "tdm-reservation": 0},
+		{"location": "/blog/private$", "tdm-reservation": 1}
+	]`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	entry := &aiPolicyEntry{policy: HostAIPolicy{Host: "example.com", TDMRepRules: rules}}
+	for _, rule := range rules {
+		entry.tdmRepExp = append(entry.tdmRepExp, compileLocationPattern(rule.Location))
+	}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/", true},
+		{"/docs/a", true},
+		{"/blog/post", false},
+		{"/blog/private", true},
+		{"/blog/private/x", false},
+	}
+	for _, tt := range tests {
+		got, matched := entry.tdmReservation(tt.path)
+		if !matched {
+			t.Fatalf("expected a rule to match %s", tt.path)
+		}
+		if got != tt.want {
+			t.Fatalf("expected reservation %v for %s, got %v", tt.want, tt.path, got)
+		}
+	}
+}
+
+func TestParseTDMRepRejectsRuleWithoutLocation(t *testing.T) {
+	if _, err := parseTDMRep([]byte(`[{"tdm-reservation": 1}]`)); err == nil {
+		t.Fatalf("expected error for rule without location")
+	}
+}
+
+func TestPageAISignals(t *testing.T) {
+	headers := http.Header{}
+	headers.Set("tdm-reservation", "1")
+
+	got := pageAISignals([]string{"noai", "noimageai"}, headers, "")
+	want := []string{AISignalNoAI, AISignalNoImageAI, AISignalTDMReservation}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	if got := pageAISignals(nil, http.Header{}, "1"); !reflect.DeepEqual(got, []string{AISignalTDMReservation}) {
+		t.Fatalf("expected meta tdm-reservation signal, got %v", got)
+	}
+}
+
+func TestIsAIOptOutIgnoresImageOnlySignal(t *testing.T) {
+	if isAIOptOut([]string{AISignalNoImageAI}) {
+		t.Fatalf("expected noimageai alone not to exclude a page")
+	}
+	if !isAIOptOut([]string{AISignalNoImageAI, AISignalAITxt}) {
+		t.Fatalf("expected ai.txt signal to exclude a page")
+	}
+}
@@ -6,22 +6,56 @@ import (
 	"fmt"
 	"log/slog"
 	"math/rand"
+	"net/http"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
 	"github.com/chromedp/chromedp"
 )
 
 type fetchedPage struct {
-	FinalURL    string
-	Title       string
-	Description string
-	Links       []string
-	BodyHTML    string
-	MainHTML    string
-	MainText    string
-	RawHTML     string
+	FinalURL       string
+	StatusCode     int
+	Headers        http.Header
+	Title          string
+	Description    string
+	MetaRobots     []string
+	TDMReservation string
+	HTMLLang       string
+	H1             []string
+	Canonical      string
+	Alternates     []fetchedAlternate
+	Links          []fetchedLink
+	Fields         map[string]fetchedField
+	BodyHTML       string
+	MainHTML       string
+	MainText       string
+	MainPath       string
+	RawHTML        string
+	Capture        *HTTPCapture
+}
+
+type fetchedLink struct {
+	Href string `json:"href"`
+	Rel  string `json:"rel"`
+	Text string `json:"text"`
+}
+
+// documentResponse is the HTTP response observed for a top-level navigation.
+type documentResponse struct {
+	frameID    cdp.FrameID
+	requestID  network.RequestID
+	url        string
+	protocol   string
+	statusCode int
+	statusText string
+	headers    http.Header
+	mimeType   string
+	remoteIP   string
 }
 
 func newBrowserContext(parent context.Context, cfg Config) (context.Context, func()) {
@@ -47,6 +81,8 @@ func fetchPageWithRetry(
 	browserCtx context.Context,
 	targetURL string,
 	clean bool,
+	fieldRules []FieldRule,
+	capture bool,
 	pageTimeout time.Duration,
 	retries int,
 	logger *slog.Logger,
@@ -61,7 +97,7 @@ func fetchPageWithRetry(
 			}
 			logger.Warn("retrying page navigation", "url", targetURL, "attempt", attempt+1)
 		}
-		page, err := fetchPageOnce(ctx, browserCtx, targetURL, clean, pageTimeout)
+		page, err := fetchPageOnce(ctx, browserCtx, targetURL, clean, fieldRules, capture, pageTimeout)
 		if err == nil {
 			return page, nil
 		}
@@ -81,6 +117,8 @@ func fetchPageOnce(
 	browserCtx context.Context,
 	targetURL string,
 	clean bool,
+	fieldRules []FieldRule,
+	capture bool,
 	pageTimeout time.Duration,
 ) (fetchedPage, error) {
 	// Use a fresh target context for each fetch. Some sites close or poison the
@@ -92,26 +130,81 @@ func fetchPageOnce(
 	pageCtx, cancel := context.WithTimeout(tabCtx, pageTimeout)
 	defer cancel()
 
+	var responsesMu sync.Mutex
+	var responses []documentResponse
+	requests := map[network.RequestID]documentRequest{}
+	chromedp.ListenTarget(tabCtx, func(ev any) {
+		switch e := ev.(type) {
+		case *network.EventRequestWillBeSent:
+			if e.Type != network.ResourceTypeDocument || e.Request == nil {
+				return
+			}
+			responsesMu.Lock()
+			defer responsesMu.Unlock()
+			requests[e.RequestID] = documentRequest{
+				method:  e.Request.Method,
+				url:     e.Request.URL,
+				headers: headersFromCDP(e.Request.Headers),
+			}
+		case *network.EventResponseReceived:
+			if e.Type != network.ResourceTypeDocument || e.Response == nil {
+				return
+			}
+			responsesMu.Lock()
+			defer responsesMu.Unlock()
+			responses = append(responses, documentResponse{
+				frameID:    e.FrameID,
+				requestID:  e.RequestID,
+				url:        e.Response.URL,
+				protocol:   e.Response.Protocol,
+				statusCode: int(e.Response.Status),
+				statusText: e.Response.StatusText,
+				headers:    headersFromCDP(e.Response.Headers),
+				mimeType:   e.Response.MimeType,
+				remoteIP:   e.Response.RemoteIPAddress,
+			})
+		}
+	})
+	fetchedAt := time.Now().UTC()
+
 	var html string
 	var finalURL string
 	var extracted struct {
-		Title       string   `json:"title"`
-		Description string   `json:"description"`
-		Links       []string `json:"links"`
-		BodyHTML    string   `json:"bodyHTML"`
-		MainHTML    string   `json:"mainHTML"`
-		MainText    string   `json:"mainText"`
+		Title          string             `json:"title"`
+		Description    string             `json:"description"`
+		MetaRobots     []string           `json:"metaRobots"`
+		TDMReservation string             `json:"tdmReservation"`
+		HTMLLang       string             `json:"htmlLang"`
+		H1             []string           `json:"h1"`
+		Canonical      string             `json:"canonical"`
+		Alternates     []fetchedAlternate `json:"alternates"`
+		Links          []fetchedLink      `json:"links"`
+		BodyHTML       string             `json:"bodyHTML"`
+		MainHTML       string             `json:"mainHTML"`
+		MainText       string             `json:"mainText"`
+		MainPath       string             `json:"mainPath"`
 	}
 
-	err := chromedp.Run(pageCtx,
+	var fields map[string]fetchedField
+
+	actions := []chromedp.Action{
 		chromedp.Navigate(targetURL),
 		chromedp.WaitReady("html", chromedp.ByQuery),
 		chromedp.ActionFunc(waitForReadyStateComplete),
-		chromedp.Sleep(350*time.Millisecond),
+		chromedp.Sleep(350 * time.Millisecond),
 		chromedp.OuterHTML("html", &html, chromedp.ByQuery),
 		chromedp.Evaluate(extractionScript(clean), &extracted),
-		chromedp.Location(&finalURL),
-	)
+	}
+	if len(fieldRules) > 0 {
+		script, err := fieldsScript(fieldRules)
+		if err != nil {
+			return fetchedPage{}, err
+		}
+		actions = append(actions, chromedp.Evaluate(script, &fields))
+	}
+	actions = append(actions, chromedp.Location(&finalURL))
+
+	err := chromedp.Run(pageCtx, actions...)
 	if err != nil {
 		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
 			return fetchedPage{}, err
@@ -121,15 +214,37 @@ func fetchPageOnce(
 	if finalURL == "" {
 		finalURL = targetURL
 	}
+
+	responsesMu.Lock()
+	mainResponse := mainDocumentResponse(responses, mainFrameID(tabCtx))
+	mainRequest := requests[mainResponse.requestID]
+	responsesMu.Unlock()
+
+	var exchange *HTTPCapture
+	if capture {
+		exchange = captureExchange(tabCtx, mainResponse, mainRequest, fetchedAt)
+	}
+
 	return fetchedPage{
-		FinalURL:    finalURL,
-		Title:       strings.TrimSpace(extracted.Title),
-		Description: strings.TrimSpace(extracted.Description),
-		Links:       extracted.Links,
-		BodyHTML:    extracted.BodyHTML,
-		MainHTML:    extracted.MainHTML,
-		MainText:    strings.TrimSpace(extracted.MainText),
-		RawHTML:     html,
+		FinalURL:       finalURL,
+		StatusCode:     mainResponse.statusCode,
+		Headers:        mainResponse.headers,
+		Title:          strings.TrimSpace(extracted.Title),
+		Description:    strings.TrimSpace(extracted.Description),
+		MetaRobots:     extracted.MetaRobots,
+		TDMReservation: strings.TrimSpace(extracted.TDMReservation),
+		HTMLLang:       strings.TrimSpace(extracted.HTMLLang),
+		H1:             extracted.H1,
+		Canonical:      strings.TrimSpace(extracted.Canonical),
+		Alternates:     extracted.Alternates,
+		Links:          extracted.Links,
+		Fields:         fields,
+		BodyHTML:       extracted.BodyHTML,
+		MainHTML:       extracted.MainHTML,
+		MainText:       strings.TrimSpace(extracted.MainText),
+		MainPath:       extracted.MainPath,
+		RawHTML:        html,
+		Capture:        exchange,
 	}, nil
 }
 
@@ -161,12 +276,49 @@ func extractionScript(clean bool) string {
 			mainText = normalize(body.textContent);
 		}
 
+		const cssPath = (el) => {
+			const segments = [];
+			for (let node = el; node && node.nodeType === 1; node = node.parentElement) {
+				const tag = node.tagName.toLowerCase();
+				const id = node.getAttribute('id');
+				if (id && !/\s/.test(id)) {
+					segments.unshift(tag + '#' + id);
+					break;
+				}
+				let segment = tag;
+				if (node.parentElement) {
+					const same = Array.from(node.parentElement.children).filter(c => c.tagName === node.tagName);
+					if (same.length > 1) {
+						segment += ':nth-of-type(' + (same.indexOf(node) + 1) + ')';
+					}
+				}
+				segments.unshift(segment);
+			}
+			return segments.join(' > ');
+		};
+
 		return {
+			mainPath: cssPath(main),
 			title: normalize((document.querySelector('title') || {}).textContent || ''),
 			description: normalize((document.querySelector('meta[name="description"]') || {}).content || ''),
-			links: Array.from(document.querySelectorAll('a[href]'))
-				.map(a => normalize(a.getAttribute('href')))
+			metaRobots: Array.from(document.querySelectorAll('meta[name]'))
+				.filter(m => (m.getAttribute('name') || '').toLowerCase() === 'robots')
+				.map(m => normalize(m.getAttribute('content')))
 				.filter(Boolean),
+			tdmReservation: normalize((document.querySelector('meta[name="tdm-reservation"]') || {}).content || ''),
+			htmlLang: normalize(document.documentElement.getAttribute('lang')),
+			h1: Array.from(document.querySelectorAll('h1')).map(h => normalize(h.textContent)),
+			canonical: normalize((document.querySelector('link[rel~="canonical"][href]') || {getAttribute: () => ''}).getAttribute('href')),
+			alternates: Array.from(document.querySelectorAll('link[rel~="alternate"][hreflang][href]'))
+				.map(l => ({hreflang: normalize(l.getAttribute('hreflang')), href: normalize(l.getAttribute('href'))}))
+				.filter(l => l.hreflang && l.href),
+			links: Array.from(document.querySelectorAll('a[href]'))
+				.map(a => ({
+					href: normalize(a.getAttribute('href')),
+					rel: normalize(a.getAttribute('rel')),
+					text: normalize(a.textContent) || normalize(a.getAttribute('aria-label')) || normalize(a.getAttribute('title'))
+				}))
+				.filter(l => l.href),
 			bodyHTML: body.innerHTML || '',
 			mainHTML: main.innerHTML || '',
 			mainText: mainText
@@ -174,6 +326,44 @@ func extractionScript(clean bool) string {
 	})()`
 }
 
+// mainFrameID returns the frame ID of the tab's top-level document. Chrome
+// uses the target ID as the main frame ID.
+func mainFrameID(tabCtx context.Context) cdp.FrameID {
+	c := chromedp.FromContext(tabCtx)
+	if c == nil || c.Target == nil {
+		return ""
+	}
+	return cdp.FrameID(c.Target.TargetID)
+}
+
+// mainDocumentResponse picks the last document response of the main frame,
+// which is the final hop of any redirect chain. Without a known frame ID the
+// last document response is used.
+func mainDocumentResponse(responses []documentResponse, frameID cdp.FrameID) documentResponse {
+	for i := len(responses) - 1; i >= 0; i-- {
+		if frameID == "" || responses[i].frameID == frameID {
+			return responses[i]
+		}
+	}
+	return documentResponse{headers: http.Header{}}
+}
+
+// headersFromCDP converts CDP headers into canonical http.Header values.
+// CDP joins repeated headers with newlines.
+func headersFromCDP(raw network.Headers) http.Header {
+	headers := http.Header{}
+	for key, value := range raw {
+		str, ok := value.(string)
+		if !ok {
+			str = fmt.Sprint(value)
+		}
+		for _, line := range strings.Split(str, "\n") {
+			headers.Add(key, strings.TrimSpace(line))
+		}
+	}
+	return headers
+}
+
 func waitForReadyStateComplete(ctx context.Context) error {
 	const maxWait = 5 * time.Second
 	deadline := time.Now().Add(maxWait)
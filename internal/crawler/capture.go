@@ -0,0 +1,77 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+// HTTPCapture is the raw HTTP exchange of a page's main document, recorded
+// for archival output. Body is the payload as delivered to the browser, with
+// any content encoding already removed.
+type HTTPCapture struct {
+	FetchedAt       time.Time
+	RequestMethod   string
+	RequestURL      string
+	RequestHeaders  http.Header
+	Protocol        string
+	StatusCode      int
+	StatusText      string
+	ResponseURL     string
+	ResponseHeaders http.Header
+	MIMEType        string
+	RemoteIP        string
+	Body            []byte
+}
+
+type documentRequest struct {
+	method  string
+	url     string
+	headers http.Header
+}
+
+// captureExchange assembles the capture of the main document response,
+// reading its body from the browser. It returns nil when the body is no
+// longer available.
+func captureExchange(tabCtx context.Context, response documentResponse, request documentRequest, fetchedAt time.Time) *HTTPCapture {
+	if response.requestID == "" {
+		return nil
+	}
+	var body []byte
+	err := chromedp.Run(tabCtx, chromedp.ActionFunc(func(ctx context.Context) error {
+		var err error
+		body, err = network.GetResponseBody(response.requestID).Do(ctx)
+		return err
+	}))
+	if err != nil {
+		return nil
+	}
+	if body == nil {
+		body = []byte{}
+	}
+	method := request.method
+	if method == "" {
+		method = http.MethodGet
+	}
+	requestURL := request.url
+	if requestURL == "" {
+		requestURL = response.url
+	}
+	return &HTTPCapture{
+		FetchedAt:       fetchedAt,
+		RequestMethod:   method,
+		RequestURL:      requestURL,
+		RequestHeaders:  request.headers,
+		Protocol:        response.protocol,
+		StatusCode:      response.statusCode,
+		StatusText:      response.statusText,
+		ResponseURL:     response.url,
+		ResponseHeaders: response.headers,
+		MIMEType:        response.mimeType,
+		RemoteIP:        response.remoteIP,
+		Body:            body,
+	}
+}
@@ -5,8 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"net/url"
 	"sort"
+	"strings"
 	"time"
 )
 
@@ -39,6 +41,12 @@ func Crawl(ctx context.Context, cfg Config, logger *slog.Logger) (*CrawlResult,
 	if cfg.UserAgent == "" {
 		cfg.UserAgent = DefaultUserAgent
 	}
+	if cfg.Extractor == "" {
+		cfg.Extractor = ExtractorHeuristic
+	}
+	if cfg.LinkCheckConcurrency <= 0 {
+		cfg.LinkCheckConcurrency = DefaultLinkCheckConcurrency
+	}
 
 	result := &CrawlResult{
 		Domain:       scope.BaseDomain,
@@ -46,22 +54,37 @@ func Crawl(ctx context.Context, cfg Config, logger *slog.Logger) (*CrawlResult,
 		StartedAt:    time.Now().UTC(),
 		Strategy:     cfg.Strategy,
 		MaxPages:     cfg.MaxPages,
+		FieldNames:   FieldNames(cfg.FieldRules),
 		MaxDepth:     cfg.MaxDepth,
 		Clean:        cfg.Clean,
+		Extractor:    cfg.Extractor,
 		Headful:      cfg.Headful,
 		Pages:        []*Page{},
 	}
 
+	if cfg.Sink != nil {
+		if err := cfg.Sink.Begin(result); err != nil {
+			return nil, err
+		}
+	}
+	var sinkErr error
+	addPage := func(page *Page) {
+		result.Pages = append(result.Pages, page)
+		if cfg.Sink != nil && sinkErr == nil {
+			sinkErr = cfg.Sink.WritePage(page)
+		}
+	}
+
 	browserCtx, cleanup := newBrowserContext(ctx, cfg)
 	defer cleanup()
 
 	startHTTPS := fmt.Sprintf("https://%s/", scope.BaseDomain)
 	startHTTP := fmt.Sprintf("http://%s/", scope.BaseDomain)
 	startURL := startHTTPS
-	startFetch, err := fetchPageWithRetry(ctx, browserCtx, startHTTPS, cfg.Clean, cfg.PageTimeout, 1, logger)
+	startFetch, err := fetchPageWithRetry(ctx, browserCtx, startHTTPS, cfg.Clean, cfg.FieldRules, cfg.CaptureHTTP, cfg.PageTimeout, 1, logger)
 	if err != nil && shouldFallbackToHTTP(err) {
 		logger.Warn("https start failed, trying http", "url", startHTTPS, "error", err)
-		startFetch, err = fetchPageWithRetry(ctx, browserCtx, startHTTP, cfg.Clean, cfg.PageTimeout, 1, logger)
+		startFetch, err = fetchPageWithRetry(ctx, browserCtx, startHTTP, cfg.Clean, cfg.FieldRules, cfg.CaptureHTTP, cfg.PageTimeout, 1, logger)
 		if err != nil {
 			result.FinishedAt = time.Now().UTC()
 			return result, err
@@ -78,14 +101,17 @@ func Crawl(ctx context.Context, cfg Config, logger *slog.Logger) (*CrawlResult,
 		startURL: startFetch,
 	}
 	robots := newRobotsCache(scope, cfg.UserAgent, logger)
+	aiPolicies := newAIPolicyCache(cfg.UserAgent, logger)
+	alternateLangs := map[string]string{}
 	graph := NewLinkGraph()
+	links := newLinkCollector(cfg.CheckLinks)
 
 	queue := []queueItem{{URL: startURL, Depth: 0}}
 	enqueued := map[string]struct{}{startURL: {}}
 	processed := map[string]struct{}{}
 	firstNavigation := true
 
-	for len(queue) > 0 && result.Totals.Visited < cfg.MaxPages {
+	for len(queue) > 0 && result.Totals.Visited < cfg.MaxPages && sinkErr == nil {
 		if ctx.Err() != nil {
 			break
 		}
@@ -106,7 +132,7 @@ func Crawl(ctx context.Context, cfg Config, logger *slog.Logger) (*CrawlResult,
 			logger.Warn("robots check failed, allowing crawl", "url", current.URL, "error", robotsErr)
 		}
 		if !allowedByRobots {
-			result.Pages = append(result.Pages, &Page{
+			addPage(&Page{
 				URL:      current.URL,
 				FinalURL: current.URL,
 				Depth:    current.Depth,
@@ -116,6 +142,36 @@ func Crawl(ctx context.Context, cfg Config, logger *slog.Logger) (*CrawlResult,
 			continue
 		}
 
+		hostSignals, aiErr := aiPolicies.HostSignals(current.URL)
+		if aiErr != nil {
+			logger.Warn("ai opt-out check failed", "url", current.URL, "error", aiErr)
+		}
+		if cfg.RespectAIOptOut && isAIOptOut(hostSignals) {
+			result.Totals.SkippedAIOptOut++
+			addPage(&Page{
+				URL:       current.URL,
+				FinalURL:  current.URL,
+				Depth:     current.Depth,
+				Status:    StatusSkippedAIOptOut,
+				AISignals: hostSignals,
+				Error:     "reserved against AI/TDM use by host policy",
+			})
+			continue
+		}
+
+		if lang, known := alternateLangs[current.URL]; known && !languageAllowed(lang, cfg.Languages) {
+			result.Totals.SkippedLanguage++
+			addPage(&Page{
+				URL:      current.URL,
+				FinalURL: current.URL,
+				Depth:    current.Depth,
+				Status:   StatusSkippedLanguage,
+				Language: lang,
+				Error:    fmt.Sprintf("hreflang %q excluded by language filter", lang),
+			})
+			continue
+		}
+
 		var fetched fetchedPage
 		if seed, ok := seedPages[current.URL]; ok {
 			fetched = seed
@@ -127,11 +183,11 @@ func Crawl(ctx context.Context, cfg Config, logger *slog.Logger) (*CrawlResult,
 			firstNavigation = false
 
 			var fetchErr error
-			fetched, fetchErr = fetchPageWithRetry(ctx, browserCtx, current.URL, cfg.Clean, cfg.PageTimeout, 1, logger)
+			fetched, fetchErr = fetchPageWithRetry(ctx, browserCtx, current.URL, cfg.Clean, cfg.FieldRules, cfg.CaptureHTTP, cfg.PageTimeout, 1, logger)
 			if fetchErr != nil {
 				result.Totals.Errors++
 				result.Totals.Visited++
-				result.Pages = append(result.Pages, &Page{
+				addPage(&Page{
 					URL:      current.URL,
 					FinalURL: current.URL,
 					Depth:    current.Depth,
@@ -151,7 +207,7 @@ func Crawl(ctx context.Context, cfg Config, logger *slog.Logger) (*CrawlResult,
 		}
 		if !scope.IsAllowedURL(normalizedFinal) {
 			result.Totals.SkippedOutOfScope++
-			result.Pages = append(result.Pages, &Page{
+			addPage(&Page{
 				URL:      current.URL,
 				FinalURL: normalizedFinal,
 				Depth:    current.Depth,
@@ -161,10 +217,55 @@ func Crawl(ctx context.Context, cfg Config, logger *slog.Logger) (*CrawlResult,
 			continue
 		}
 
+		contentRoot := fetched.MainPath
+		var contentConfidence *float64
+		if cfg.Clean && cfg.Extractor == ExtractorReadability {
+			if readable, ok := extractReadable(fetched.RawHTML); ok {
+				fetched.MainHTML = readable.HTML
+				fetched.MainText = readable.Text
+				contentRoot = readable.RootPath
+				confidence := readable.Confidence
+				contentConfidence = &confidence
+			} else {
+				logger.Warn("readability extraction failed, keeping heuristic content", "url", normalizedFinal)
+			}
+		}
+
+		robotsDirectives := parseRobotsDirectives(append(
+			append([]string(nil), fetched.MetaRobots...),
+			fetched.Headers.Values("X-Robots-Tag")...,
+		))
+		noIndex := cfg.RespectMetaRobots && hasRobotsDirective(robotsDirectives, RobotsNoIndex)
+		noFollow := cfg.RespectMetaRobots && hasRobotsDirective(robotsDirectives, RobotsNoFollow)
+
+		aiSignals := pageAISignals(robotsDirectives, fetched.Headers, fetched.TDMReservation)
+		if normalizedFinal != current.URL {
+			finalSignals, finalErr := aiPolicies.HostSignals(normalizedFinal)
+			if finalErr != nil {
+				logger.Warn("ai opt-out check failed", "url", normalizedFinal, "error", finalErr)
+			}
+			hostSignals = finalSignals
+		}
+		aiSignals = mergeSignals(aiSignals, hostSignals)
+
+		detectedLanguage, languageConfidence := DetectLanguage(fetched.MainText)
+		language := resolvePageLanguage(detectedLanguage, languageConfidence, fetched.HTMLLang, fetched.Headers.Get("Content-Language"))
+		alternates := resolveAlternates(normalizedFinal, fetched.Alternates, cfg.Clean)
+		for _, alt := range alternates {
+			if _, known := alternateLangs[alt.URL]; !known {
+				alternateLangs[alt.URL] = alt.Lang
+			}
+		}
+
 		internalLinks := make([]string, 0, len(fetched.Links))
 		linkSet := map[string]struct{}{}
-		for _, href := range fetched.Links {
-			normalizedLink, linkErr := ResolveAndNormalize(normalizedFinal, href, cfg.Clean)
+		nofollowSet := map[string]struct{}{}
+		var external externalLinks
+		for _, link := range fetched.Links {
+			// Nofollow links are still inventoried and checked, but not
+			// followed.
+			nofollow := noFollow || (cfg.RespectMetaRobots && isNofollowRel(link.Rel))
+			normalizedLink, linkErr := ResolveAndNormalize(normalizedFinal, link.Href, cfg.Clean)
 			if linkErr != nil {
 				continue
 			}
@@ -172,7 +273,19 @@ func Crawl(ctx context.Context, cfg Config, logger *slog.Logger) (*CrawlResult,
 			if parseErr != nil {
 				continue
 			}
-			switch scope.ClassifyHost(parsedLink.Hostname()) {
+			class := scope.ClassifyHost(parsedLink.Hostname())
+			if class == ScopeClassExternal {
+				external.add(normalizedLink, link.Text, link.Rel)
+			}
+			links.add(normalizedFinal, normalizedLink, link.Text, class)
+			if nofollow {
+				if _, exists := nofollowSet[normalizedLink]; !exists {
+					nofollowSet[normalizedLink] = struct{}{}
+					result.Totals.SkippedNofollow++
+				}
+				continue
+			}
+			switch class {
 			case ScopeClassAllowed:
 				if _, exists := linkSet[normalizedLink]; exists {
 					continue
@@ -207,29 +320,123 @@ func Crawl(ctx context.Context, cfg Config, logger *slog.Logger) (*CrawlResult,
 			})
 		}
 
-		result.Pages = append(result.Pages, &Page{
-			URL:         current.URL,
-			FinalURL:    normalizedFinal,
-			Depth:       current.Depth,
-			Status:      StatusOK,
-			Title:       fetched.Title,
-			Description: fetched.Description,
-			Links:       internalLinks,
-			MainText:    fetched.MainText,
-			MainHTML:    fetched.MainHTML,
-			BodyHTML:    fetched.BodyHTML,
-			RawHTML:     fetched.RawHTML,
-		})
+		var canonical string
+		if fetched.Canonical != "" {
+			if resolved, err := ResolveAndNormalize(normalizedFinal, fetched.Canonical, cfg.Clean); err == nil {
+				canonical = resolved
+			}
+		}
+
+		fieldValues, fieldFailures := applyFieldRules(cfg.FieldRules, normalizedFinal, fetched.Fields)
+		for name, failure := range fieldFailures {
+			logger.Warn("field rule failed", "url", normalizedFinal, "field", name, "error", failure)
+		}
+
+		page := &Page{
+			URL:               current.URL,
+			FinalURL:          normalizedFinal,
+			Depth:             current.Depth,
+			Status:            StatusOK,
+			Title:             fetched.Title,
+			Description:       fetched.Description,
+			Robots:            robotsDirectives,
+			AISignals:         aiSignals,
+			Language:          language,
+			DeclaredLanguage:  normalizeLanguageTag(fetched.HTMLLang),
+			ContentLanguage:   normalizeLanguageTag(fetched.Headers.Get("Content-Language")),
+			DetectedLanguage:  detectedLanguage,
+			Alternates:        alternates,
+			H1:                fetched.H1,
+			Canonical:         canonical,
+			WordCount:         len(strings.Fields(fetched.MainText)),
+			Links:             internalLinks,
+			ExternalLinks:     external.sorted(),
+			Images:            extractImages(normalizedFinal, fetched.MainHTML, cfg.Clean),
+			Tables:            extractTables(fetched.MainHTML),
+			Fields:            fieldValues,
+			ContentRoot:       contentRoot,
+			ContentConfidence: contentConfidence,
+			MainText:          fetched.MainText,
+			MainHTML:          fetched.MainHTML,
+			BodyHTML:          fetched.BodyHTML,
+			RawHTML:           fetched.RawHTML,
+			Capture:           fetched.Capture,
+		}
+		switch {
+		case noIndex:
+			result.Totals.SkippedNoIndex++
+			excludePage(page, StatusSkippedNoIndex, "excluded by robots noindex directive")
+		case cfg.RespectAIOptOut && isAIOptOut(aiSignals):
+			result.Totals.SkippedAIOptOut++
+			excludePage(page, StatusSkippedAIOptOut, "reserved against AI/TDM use")
+		case !languageAllowed(language, cfg.Languages):
+			result.Totals.SkippedLanguage++
+			excludePage(page, StatusSkippedLanguage, fmt.Sprintf("language %q excluded by language filter", language))
+		}
 		result.Totals.Visited++
+		addPage(page)
 	}
 
+	if cfg.CheckLinks != LinkCheckOff && ctx.Err() == nil && sinkErr == nil {
+		logger.Info("checking links", "mode", cfg.CheckLinks, "targets", len(links.sources))
+		checker := &linkChecker{
+			client:      &http.Client{Timeout: cfg.PageTimeout},
+			userAgent:   cfg.UserAgent,
+			delay:       cfg.Delay,
+			concurrency: cfg.LinkCheckConcurrency,
+			logger:      logger,
+		}
+		result.LinkCheck = checkLinks(ctx, links, checker, robots, scope)
+		if ctx.Err() == nil {
+			logger.Info("links checked", "checked", result.LinkCheck.Checked, "broken", len(result.LinkCheck.Broken))
+		}
+	}
+	if len(cfg.Sitemaps) > 0 && ctx.Err() == nil && sinkErr == nil {
+		loader := &sitemapLoader{
+			client:    &http.Client{Timeout: cfg.PageTimeout},
+			userAgent: cfg.UserAgent,
+			logger:    logger,
+		}
+		listed, read, failed := loader.load(ctx, sitemapSources(cfg.Sitemaps, startURL, robots, logger))
+		inScope, outOfScope := scopeSitemapURLs(listed, scope, cfg.Clean)
+		coverage := buildSitemapCoverage(inScope, result.Pages, graph)
+		coverage.Sitemaps = read
+		coverage.Errors = failed
+		coverage.OutOfScope = outOfScope
+		result.SitemapCoverage = coverage
+		logger.Info("sitemap coverage", "sitemaps", len(read), "urls", coverage.URLs, "orphans", len(coverage.Orphans), "uncrawled", len(coverage.Uncrawled))
+	}
 	if cfg.Strategy == StrategyPageRank {
-		ApplyPageRankScores(result, graph)
+		if err := ApplyPageRankScores(result, graph); err != nil {
+			logger.Warn("pagerank scores are from the last iteration", "error", err)
+		}
 	}
+	result.AIPolicies = aiPolicies.Policies()
+	result.TranslationSets = BuildTranslationSets(result.Pages)
+	result.LinkGraph = graph
 
 	result.FinishedAt = time.Now().UTC()
+	if sinkErr != nil {
+		return result, fmt.Errorf("write page: %w", sinkErr)
+	}
 	if ctx.Err() != nil && !errors.Is(ctx.Err(), context.Canceled) {
 		return result, ctx.Err()
 	}
 	return result, ctx.Err()
 }
+
+// excludePage marks a fetched page as excluded from output and drops its
+// content, keeping metadata for the report.
+func excludePage(page *Page, status, reason string) {
+	page.Status = status
+	page.Error = reason
+	page.Images = nil
+	page.ExternalLinks = nil
+	page.Tables = nil
+	page.Capture = nil
+	page.Fields = nil
+	page.MainText = ""
+	page.MainHTML = ""
+	page.BodyHTML = ""
+	page.RawHTML = ""
+}
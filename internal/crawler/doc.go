@@ -2,6 +2,12 @@
 //   - strict host scoping (<domain> + www.<domain>)
 //   - URL normalization and deduplication
 //   - robots.txt compliance
+//   - meta robots / X-Robots-Tag / rel=nofollow compliance
+//   - AI/TDM opt-out detection (noai, tdm-reservation, tdmrep.json, ai.txt)
+//   - main-content extraction (heuristic or Readability-style scoring)
+//   - language detection, hreflang translation sets, and language filtering
 //   - crawl strategy execution (pagerank, limit, depth)
 //   - PageRank integration through the local pkg/pagerank adapter
+//   - post-crawl checking of discovered links (--check-links)
+//   - sitemap coverage against the link graph (--sitemap)
 package crawler
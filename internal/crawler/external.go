@@ -0,0 +1,54 @@
+package crawler
+
+import (
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ExternalLink is a link from a crawled page to a host outside the base
+// domain.
+type ExternalLink struct {
+	URL  string
+	Text string
+	// Rel holds the link's rel tokens, lowercased and sorted, such as
+	// nofollow, sponsored, or ugc.
+	Rel []string
+}
+
+// externalLinks collects a page's external links, keeping the first
+// occurrence of each URL.
+type externalLinks struct {
+	links []ExternalLink
+	seen  map[string]struct{}
+}
+
+func (e *externalLinks) add(url, text, rel string) {
+	if e.seen == nil {
+		e.seen = map[string]struct{}{}
+	}
+	if _, ok := e.seen[url]; ok {
+		return
+	}
+	e.seen[url] = struct{}{}
+	e.links = append(e.links, ExternalLink{URL: url, Text: text, Rel: relTokens(rel)})
+}
+
+// sorted returns the links ordered by URL.
+func (e *externalLinks) sorted() []ExternalLink {
+	sort.Slice(e.links, func(i, j int) bool {
+		return e.links[i].URL < e.links[j].URL
+	})
+	return e.links
+}
+
+func relTokens(rel string) []string {
+	var tokens []string
+	for _, token := range strings.Fields(strings.ToLower(rel)) {
+		if !slices.Contains(tokens, token) {
+			tokens = append(tokens, token)
+		}
+	}
+	sort.Strings(tokens)
+	return tokens
+}
@@ -0,0 +1,21 @@
+package crawler
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExternalLinksKeepFirstOccurrenceSortedByURL(t *testing.T) {
+	var links externalLinks
+	links.add("https://partner.example.org/b", "Partner B", "Sponsored  NOFOLLOW sponsored")
+	links.add("https://partner.example.org/a", "Partner A", "")
+	links.add("https://partner.example.org/b", "Partner B again", "ugc")
+
+	want := []ExternalLink{
+		{URL: "https://partner.example.org/a", Text: "Partner A"},
+		{URL: "https://partner.example.org/b", Text: "Partner B", Rel: []string{"nofollow", "sponsored"}},
+	}
+	if got := links.sorted(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected external links: %+v", got)
+	}
+}
@@ -0,0 +1,295 @@
+package crawler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var ErrInvalidFieldRules = errors.New("invalid field rules")
+
+const (
+	// FieldOutputText extracts normalized text content (default).
+	FieldOutputText = "text"
+	// FieldOutputHTML extracts outer HTML.
+	FieldOutputHTML = "html"
+	// FieldOutputAttribute extracts the value of FieldRule.Attribute.
+	FieldOutputAttribute = "attribute"
+
+	// FieldCardinalitySingle keeps the first match (default).
+	FieldCardinalitySingle = "single"
+	// FieldCardinalityMultiple keeps all matches in document order.
+	FieldCardinalityMultiple = "multiple"
+)
+
+var fieldNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// FieldRule declares how one named field is extracted from a page.
+type FieldRule struct {
+	Name        string   `json:"-"`
+	CSS         string   `json:"css,omitempty"`
+	XPath       string   `json:"xpath,omitempty"`
+	Output      string   `json:"output,omitempty"`
+	Attribute   string   `json:"attribute,omitempty"`
+	Cardinality string   `json:"cardinality,omitempty"`
+	URLs        []string `json:"urls,omitempty"`
+
+	urlPatterns []URLPattern
+}
+
+// FieldValue is the extracted result of one rule on one page.
+type FieldValue struct {
+	Name     string
+	Values   []string
+	Multiple bool
+}
+
+type fetchedField struct {
+	Values []string `json:"values"`
+	Error  string   `json:"error"`
+}
+
+// LoadFieldRules reads and validates a JSON rules file.
+func LoadFieldRules(path string) ([]FieldRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := ParseFieldRules(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return rules, nil
+}
+
+// ParseFieldRules parses a JSON object mapping field names to rules, e.g.
+//
+//	{
+//	  "price":   {"css": ".price"},
+//	  "author":  {"xpath": "//meta[@name='author']/@content"},
+//	  "tags":    {"css": "a.tag", "cardinality": "multiple"},
+//	  "version": {"css": ".version", "urls": ["https://example.com/docs/*"]}
+//	}
+//
+// Rules are returned sorted by name.
+func ParseFieldRules(data []byte) ([]FieldRule, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFieldRules, err)
+	}
+	rules := make([]FieldRule, 0, len(raw))
+	for name, body := range raw {
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.DisallowUnknownFields()
+		var rule FieldRule
+		if err := decoder.Decode(&rule); err != nil {
+			return nil, fmt.Errorf("%w: field %q: %v", ErrInvalidFieldRules, name, err)
+		}
+		rule.Name = name
+		if err := rule.validate(); err != nil {
+			return nil, fmt.Errorf("%w: field %q: %v", ErrInvalidFieldRules, name, err)
+		}
+		rules = append(rules, rule)
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i].Name < rules[j].Name
+	})
+	return rules, nil
+}
+
+func (r *FieldRule) validate() error {
+	if !fieldNamePattern.MatchString(r.Name) {
+		return errors.New("name must start with a letter or underscore and contain only letters, digits, '_', '-', or '.'")
+	}
+	r.CSS = strings.TrimSpace(r.CSS)
+	r.XPath = strings.TrimSpace(r.XPath)
+	if (r.CSS == "") == (r.XPath == "") {
+		return errors.New("exactly one of css or xpath is required")
+	}
+
+	r.Output = strings.ToLower(strings.TrimSpace(r.Output))
+	switch r.Output {
+	case "":
+		r.Output = FieldOutputText
+	case FieldOutputText, FieldOutputHTML, FieldOutputAttribute:
+	default:
+		return fmt.Errorf("invalid output %q (allowed: text, html, attribute)", r.Output)
+	}
+	r.Attribute = strings.TrimSpace(r.Attribute)
+	if r.Output == FieldOutputAttribute && r.Attribute == "" {
+		return errors.New("attribute is required when output is attribute")
+	}
+	if r.Output != FieldOutputAttribute && r.Attribute != "" {
+		return errors.New("attribute is only allowed when output is attribute")
+	}
+
+	r.Cardinality = strings.ToLower(strings.TrimSpace(r.Cardinality))
+	switch r.Cardinality {
+	case "":
+		r.Cardinality = FieldCardinalitySingle
+	case FieldCardinalitySingle, FieldCardinalityMultiple:
+	default:
+		return fmt.Errorf("invalid cardinality %q (allowed: single, multiple)", r.Cardinality)
+	}
+
+	r.urlPatterns = r.urlPatterns[:0]
+	for _, pattern := range r.URLs {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			return errors.New("empty url pattern")
+		}
+		r.urlPatterns = append(r.urlPatterns, CompileURLPattern(pattern))
+	}
+	return nil
+}
+
+// Multiple reports whether the rule keeps all matches.
+func (r FieldRule) Multiple() bool {
+	return r.Cardinality == FieldCardinalityMultiple
+}
+
+// AppliesTo reports whether the rule is scoped to rawURL. Rules without URL
+// patterns apply everywhere; see URLPattern for the pattern syntax.
+func (r FieldRule) AppliesTo(rawURL string) bool {
+	if len(r.urlPatterns) == 0 {
+		return true
+	}
+	for _, pattern := range r.urlPatterns {
+		if pattern.Match(rawURL) {
+			return true
+		}
+	}
+	return false
+}
+
+// FieldNames returns the names of rules in order.
+func FieldNames(rules []FieldRule) []string {
+	names := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		names = append(names, rule.Name)
+	}
+	return names
+}
+
+// applyFieldRules turns raw in-browser results into field values for the
+// rules scoped to pageURL. Rules that failed in the browser are returned as
+// errors keyed by field name.
+func applyFieldRules(rules []FieldRule, pageURL string, raw map[string]fetchedField) ([]FieldValue, map[string]string) {
+	var values []FieldValue
+	var failures map[string]string
+	for _, rule := range rules {
+		if !rule.AppliesTo(pageURL) {
+			continue
+		}
+		result := raw[rule.Name]
+		if result.Error != "" {
+			if failures == nil {
+				failures = map[string]string{}
+			}
+			failures[rule.Name] = result.Error
+			continue
+		}
+		matches := result.Values
+		if !rule.Multiple() && len(matches) > 1 {
+			matches = matches[:1]
+		}
+		values = append(values, FieldValue{
+			Name:     rule.Name,
+			Values:   matches,
+			Multiple: rule.Multiple(),
+		})
+	}
+	return values, failures
+}
+
+// fieldsScript evaluates every rule against the live document. XPath
+// expressions may select elements, attributes, text nodes, or scalar values.
+func fieldsScript(rules []FieldRule) (string, error) {
+	type scriptRule struct {
+		Name      string `json:"name"`
+		CSS       string `json:"css"`
+		XPath     string `json:"xpath"`
+		Output    string `json:"output"`
+		Attribute string `json:"attribute"`
+		Multiple  bool   `json:"multiple"`
+	}
+	payload := make([]scriptRule, 0, len(rules))
+	for _, rule := range rules {
+		payload = append(payload, scriptRule{
+			Name:      rule.Name,
+			CSS:       rule.CSS,
+			XPath:     rule.XPath,
+			Output:    rule.Output,
+			Attribute: rule.Attribute,
+			Multiple:  rule.Multiple(),
+		})
+	}
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	return `(function (rules) {
+		const normalize = (v) => (v || '').replace(/\s+/g, ' ').trim();
+		const out = {};
+		for (const rule of rules) {
+			let nodes = [];
+			let scalars = null;
+			try {
+				if (rule.css) {
+					nodes = Array.from(document.querySelectorAll(rule.css));
+				} else {
+					const res = document.evaluate(rule.xpath, document, null, XPathResult.ANY_TYPE, null);
+					switch (res.resultType) {
+					case XPathResult.NUMBER_TYPE:
+						scalars = [String(res.numberValue)];
+						break;
+					case XPathResult.STRING_TYPE:
+						scalars = [normalize(res.stringValue)];
+						break;
+					case XPathResult.BOOLEAN_TYPE:
+						scalars = [String(res.booleanValue)];
+						break;
+					default:
+						for (let node = res.iterateNext(); node; node = res.iterateNext()) {
+							nodes.push(node);
+						}
+					}
+				}
+			} catch (e) {
+				out[rule.name] = {values: [], error: String(e && e.message || e)};
+				continue;
+			}
+			if (scalars !== null) {
+				out[rule.name] = {values: scalars.filter(Boolean), error: ''};
+				continue;
+			}
+			let values = nodes.map(node => {
+				if (node.nodeType === Node.ATTRIBUTE_NODE) {
+					return normalize(node.value);
+				}
+				if (node.nodeType !== Node.ELEMENT_NODE) {
+					return normalize(node.textContent);
+				}
+				switch (rule.output) {
+				case 'html':
+					return node.outerHTML;
+				case 'attribute':
+					return node.getAttribute(rule.attribute);
+				default:
+					return normalize(node.textContent);
+				}
+			}).filter(v => v !== null && v !== '');
+			// Single-valued fields take the first non-empty match.
+			if (!rule.multiple) {
+				values = values.slice(0, 1);
+			}
+			out[rule.name] = {values: values, error: ''};
+		}
+		return out;
+	})(` + string(encoded) + `)`, nil
+}
@@ -0,0 +1,101 @@
+package crawler
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseFieldRules(t *testing.T) {
+	rules, err := ParseFieldRules([]byte(`{
+		"price": {"css": ".price"},
+		"author": {"xpath": "//meta[@name='author']/@content"},
+		"tags": {"css": "a.tag", "output": "attribute", "attribute": "href", "cardinality": "Multiple"}
+	}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := FieldNames(rules); !reflect.DeepEqual(got, []string{"author", "price", "tags"}) {
+		t.Fatalf("expected rules sorted by name, got %v", got)
+	}
+	if rules[1].Output != FieldOutputText || rules[1].Cardinality != FieldCardinalitySingle {
+		t.Fatalf("expected defaults for price rule, got %+v", rules[1])
+	}
+	if !rules[2].Multiple() || rules[2].Attribute != "href" {
+		t.Fatalf("unexpected tags rule: %+v", rules[2])
+	}
+}
+
+func TestParseFieldRulesRejectsInvalidRules(t *testing.T) {
+	inputs := []string{
+		`[]`,
+		`{"price": {}}`,
+		`{"price": {"css": ".a", "xpath": "//a"}}`,
+		`{"price": {"css": ".a", "output": "json"}}`,
+		`{"price": {"css": ".a", "output": "attribute"}}`,
+		`{"price": {"css": ".a", "attribute": "href"}}`,
+		`{"price": {"css": ".a", "cardinality": "many"}}`,
+		`{"price": {"css": ".a", "selector": ".b"}}`,
+		`{"1price": {"css": ".a"}}`,
+	}
+	for _, input := range inputs {
+		if _, err := ParseFieldRules([]byte(input)); !errors.Is(err, ErrInvalidFieldRules) {
+			t.Fatalf("expected ErrInvalidFieldRules for %s, got %v", input, err)
+		}
+	}
+}
+
+func TestFieldRuleAppliesTo(t *testing.T) {
+	rules, err := ParseFieldRules([]byte(`{
+		"version": {"css": ".v", "urls": ["/docs/*", "https://www.example.com/releases"]}
+	}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rule := rules[0]
+
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"https://example.com/docs/setup", true},
+		{"https://example.com/docs", false},
+		{"https://www.example.com/releases", true},
+		{"https://example.com/releases", false},
+		{"https://example.com/blog/docs/x", false},
+	}
+	for _, tt := range tests {
+		if got := rule.AppliesTo(tt.url); got != tt.want {
+			t.Fatalf("expected %v for %s, got %v", tt.want, tt.url, got)
+		}
+	}
+}
+
+func TestApplyFieldRules(t *testing.T) {
+	rules, err := ParseFieldRules([]byte(`{
+		"title": {"css": "h1"},
+		"tags": {"css": ".tag", "cardinality": "multiple"},
+		"broken": {"css": "[["},
+		"scoped": {"css": ".x", "urls": ["/other"]}
+	}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	values, failures := applyFieldRules(rules, "https://example.com/page", map[string]fetchedField{
+		"title":  {Values: []string{"First", "Second"}},
+		"tags":   {Values: []string{"a", "b"}},
+		"broken": {Error: "SyntaxError"},
+		"scoped": {Values: []string{"x"}},
+	})
+	want := []FieldValue{
+		{Name: "tags", Values: []string{"a", "b"}, Multiple: true},
+		{Name: "title", Values: []string{"First"}},
+	}
+	if !reflect.DeepEqual(values, want) {
+		t.Fatalf("expected %+v, got %+v", want, values)
+	}
+	if failures["broken"] != "SyntaxError" || len(failures) != 1 {
+		t.Fatalf("expected broken rule failure, got %v", failures)
+	}
+}
@@ -0,0 +1,140 @@
+package crawler
+
+import (
+	"sort"
+)
+
+// Alternate is one hreflang alternate declared by a page.
+type Alternate struct {
+	Lang string
+	URL  string
+}
+
+// TranslationSet groups URLs that declare each other as language alternates.
+type TranslationSet struct {
+	Members []Alternate
+}
+
+type fetchedAlternate struct {
+	Hreflang string `json:"hreflang"`
+	Href     string `json:"href"`
+}
+
+// resolveAlternates normalizes hreflang alternates against the page URL and
+// drops unusable entries.
+func resolveAlternates(baseURL string, raw []fetchedAlternate, clean bool) []Alternate {
+	seen := map[Alternate]struct{}{}
+	alternates := make([]Alternate, 0, len(raw))
+	for _, alt := range raw {
+		lang := normalizeLanguageTag(alt.Hreflang)
+		if lang == "" {
+			continue
+		}
+		resolved, err := ResolveAndNormalize(baseURL, alt.Href, clean)
+		if err != nil {
+			continue
+		}
+		candidate := Alternate{Lang: lang, URL: resolved}
+		if _, ok := seen[candidate]; ok {
+			continue
+		}
+		seen[candidate] = struct{}{}
+		alternates = append(alternates, candidate)
+	}
+	sort.Slice(alternates, func(i, j int) bool {
+		if alternates[i].Lang != alternates[j].Lang {
+			return alternates[i].Lang < alternates[j].Lang
+		}
+		return alternates[i].URL < alternates[j].URL
+	})
+	return alternates
+}
+
+// BuildTranslationSets groups pages and their hreflang alternates into
+// translation sets. Pages connected through any alternate annotation end up
+// in the same set; crawled pages without a declared alternate for themselves
+// contribute their resolved Language. Sets with a single URL are omitted.
+func BuildTranslationSets(pages []*Page) []TranslationSet {
+	parent := map[string]string{}
+	var find func(string) string
+	find = func(u string) string {
+		if _, ok := parent[u]; !ok {
+			parent[u] = u
+		}
+		if parent[u] != u {
+			parent[u] = find(parent[u])
+		}
+		return parent[u]
+	}
+	union := func(a, b string) {
+		rootA, rootB := find(a), find(b)
+		if rootA == rootB {
+			return
+		}
+		if rootA < rootB {
+			parent[rootB] = rootA
+		} else {
+			parent[rootA] = rootB
+		}
+	}
+
+	members := map[string]map[Alternate]struct{}{}
+	addMember := func(alt Alternate) {
+		if _, ok := members[alt.URL]; !ok {
+			members[alt.URL] = map[Alternate]struct{}{}
+		}
+		members[alt.URL][alt] = struct{}{}
+	}
+
+	for _, page := range pages {
+		if len(page.Alternates) == 0 {
+			continue
+		}
+		pageURL := page.FinalURL
+		if pageURL == "" {
+			pageURL = page.URL
+		}
+		find(pageURL)
+		declaresSelf := false
+		for _, alt := range page.Alternates {
+			union(pageURL, alt.URL)
+			addMember(alt)
+			if alt.URL == pageURL {
+				declaresSelf = true
+			}
+		}
+		if !declaresSelf && page.Language != "" {
+			addMember(Alternate{Lang: page.Language, URL: pageURL})
+		}
+	}
+
+	grouped := map[string][]Alternate{}
+	for u := range parent {
+		root := find(u)
+		for alt := range members[u] {
+			grouped[root] = append(grouped[root], alt)
+		}
+	}
+
+	sets := make([]TranslationSet, 0, len(grouped))
+	for _, alts := range grouped {
+		urls := map[string]struct{}{}
+		for _, alt := range alts {
+			urls[alt.URL] = struct{}{}
+		}
+		if len(urls) < 2 {
+			continue
+		}
+		sort.Slice(alts, func(i, j int) bool {
+			if alts[i].Lang != alts[j].Lang {
+				return alts[i].Lang < alts[j].Lang
+			}
+			return alts[i].URL < alts[j].URL
+		})
+		sets = append(sets, TranslationSet{Members: alts})
+	}
+	sort.Slice(sets, func(i, j int) bool {
+		return sets[i].Members[0].URL < sets[j].Members[0].URL
+	})
+	return sets
+}
@@ -0,0 +1,52 @@
+package crawler
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResolveAlternates(t *testing.T) {
+	got := resolveAlternates("https://example.com/en/docs", []fetchedAlternate{
+		{Hreflang: "de", Href: "/de/docs"},
+		{Hreflang: "EN", Href: "https://example.com/en/docs#top"},
+		{Hreflang: "", Href: "/fr/docs"},
+		{Hreflang: "fr", Href: "mailto:docs@example.com"},
+	}, true)
+	want := []Alternate{
+		{Lang: "de", URL: "https://example.com/de/docs"},
+		{Lang: "en", URL: "https://example.com/en/docs"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestBuildTranslationSets(t *testing.T) {
+	en := "https://example.com/en"
+	de := "https://example.com/de"
+	fr := "https://example.com/fr"
+	pages := []*Page{
+		{
+			URL: en, FinalURL: en, Language: "en",
+			Alternates: []Alternate{{Lang: "de", URL: de}, {Lang: "en", URL: en}},
+		},
+		{
+			URL: de, FinalURL: de, Language: "de",
+			Alternates: []Alternate{{Lang: "fr", URL: fr}},
+		},
+		{URL: "https://example.com/solo", FinalURL: "https://example.com/solo", Language: "en"},
+	}
+
+	sets := BuildTranslationSets(pages)
+	if len(sets) != 1 {
+		t.Fatalf("expected 1 translation set, got %d", len(sets))
+	}
+	want := []Alternate{
+		{Lang: "de", URL: de},
+		{Lang: "en", URL: en},
+		{Lang: "fr", URL: fr},
+	}
+	if !reflect.DeepEqual(sets[0].Members, want) {
+		t.Fatalf("expected members %v, got %v", want, sets[0].Members)
+	}
+}
@@ -0,0 +1,132 @@
+package crawler
+
+import (
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Image is one image shown in a page's main content.
+type Image struct {
+	URL        string
+	Alt        string
+	AltMissing bool
+	Width      int
+	Height     int
+	Srcset     []string
+	Lazy       bool
+}
+
+// lazySrcAttrs are attributes lazy-loading libraries use for the real source
+// while src holds a placeholder.
+var lazySrcAttrs = []string{"data-src", "data-lazy-src", "data-original"}
+
+// lazySrcsetAttrs are the srcset counterparts of lazySrcAttrs.
+var lazySrcsetAttrs = []string{"data-srcset", "data-lazy-srcset"}
+
+// extractImages collects <img> elements from mainHTML, resolving sources and
+// srcset candidates against baseURL. Lazy-load attributes win over src, and
+// srcset is used when no other source resolves. Images are de-duplicated by
+// resolved URL in document order.
+func extractImages(baseURL, mainHTML string, clean bool) []Image {
+	if strings.TrimSpace(mainHTML) == "" {
+		return nil
+	}
+	doc, err := html.Parse(strings.NewReader(mainHTML))
+	if err != nil {
+		return nil
+	}
+
+	var images []Image
+	seen := map[string]struct{}{}
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.DataAtom == atom.Img {
+			if image, ok := imageFromNode(baseURL, n, clean); ok {
+				if _, dup := seen[image.URL]; !dup {
+					seen[image.URL] = struct{}{}
+					images = append(images, image)
+				}
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(doc)
+	return images
+}
+
+func imageFromNode(baseURL string, n *html.Node, clean bool) (Image, bool) {
+	attrs := map[string]string{}
+	for _, attr := range n.Attr {
+		attrs[strings.ToLower(attr.Key)] = attr.Val
+	}
+
+	image := Image{}
+	if alt, ok := attrs["alt"]; ok {
+		image.Alt = strings.Join(strings.Fields(alt), " ")
+	} else {
+		image.AltMissing = true
+	}
+	image.Width = parseDimension(attrs["width"])
+	image.Height = parseDimension(attrs["height"])
+
+	srcset := attrs["srcset"]
+	for _, key := range lazySrcsetAttrs {
+		if value := strings.TrimSpace(attrs[key]); value != "" {
+			srcset = value
+			image.Lazy = true
+			break
+		}
+	}
+	for _, candidate := range parseSrcset(srcset) {
+		if resolved, err := ResolveAndNormalize(baseURL, candidate, clean); err == nil {
+			image.Srcset = append(image.Srcset, resolved)
+		}
+	}
+
+	for _, key := range lazySrcAttrs {
+		if resolved, err := ResolveAndNormalize(baseURL, attrs[key], clean); err == nil {
+			image.URL = resolved
+			image.Lazy = true
+			break
+		}
+	}
+	if image.URL == "" {
+		if resolved, err := ResolveAndNormalize(baseURL, attrs["src"], clean); err == nil {
+			image.URL = resolved
+		}
+	}
+	if image.URL == "" && len(image.Srcset) > 0 {
+		image.URL = image.Srcset[0]
+	}
+	if strings.EqualFold(attrs["loading"], "lazy") {
+		image.Lazy = true
+	}
+	return image, image.URL != ""
+}
+
+// parseSrcset returns the candidate URLs of a srcset attribute.
+func parseSrcset(srcset string) []string {
+	var urls []string
+	for _, candidate := range strings.Split(srcset, ",") {
+		fields := strings.Fields(candidate)
+		if len(fields) == 0 {
+			continue
+		}
+		urls = append(urls, fields[0])
+	}
+	return urls
+}
+
+// parseDimension parses width/height attributes such as "640" or "640px".
+func parseDimension(raw string) int {
+	value, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(raw), "px"))
+	if err != nil || value < 0 {
+		return 0
+	}
+	return value
+}
@@ -0,0 +1,37 @@
+package crawler
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractImages(t *testing.T) {
+	mainHTML := `
+		<p>Intro</p>
+		<img src="/img/a.png" alt="  Diagram   of A " width="640" height="480px">
+		<img src="data:image/gif;base64,R0lGOD" data-src="img/lazy.jpg" alt="">
+		<img srcset="/img/b-1x.png 1x, /img/b-2x.png 2x">
+		<img src="/img/a.png" alt="duplicate">
+		<img src="javascript:void(0)">
+	`
+
+	got := extractImages("https://example.com/docs/page", mainHTML, true)
+	want := []Image{
+		{URL: "https://example.com/img/a.png", Alt: "Diagram of A", Width: 640, Height: 480},
+		{URL: "https://example.com/docs/img/lazy.jpg", Alt: "", Lazy: true},
+		{
+			URL:        "https://example.com/img/b-1x.png",
+			AltMissing: true,
+			Srcset:     []string{"https://example.com/img/b-1x.png", "https://example.com/img/b-2x.png"},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestExtractImagesEmpty(t *testing.T) {
+	if got := extractImages("https://example.com/", "", true); got != nil {
+		t.Fatalf("expected no images, got %+v", got)
+	}
+}
@@ -0,0 +1,241 @@
+package crawler
+
+import (
+	"strings"
+	"unicode"
+)
+
+// maxLanguageSampleRunes bounds how much page text is inspected for detection.
+const maxLanguageSampleRunes = 20000
+
+// minLanguageHits is the minimum number of stopword hits for a Latin or
+// Cyrillic script guess to be trusted.
+const minLanguageHits = 3
+
+// languageStopwords holds high-frequency function words per language. Counting
+// them is a cheap, offline frequency model that separates languages sharing a
+// script well once a page has a few sentences of text.
+var languageStopwords = map[string][]string{
+	"en": {"the", "and", "of", "to", "in", "is", "that", "for", "it", "with", "as", "was", "on", "are", "be", "this", "by", "you", "or", "have", "from", "not", "which", "an", "your", "can", "will", "we", "they", "has"},
+	"de": {"der", "die", "und", "das", "ist", "nicht", "ein", "eine", "zu", "den", "mit", "sich", "des", "auf", "für", "von", "dem", "auch", "es", "sie", "wir", "ich", "werden", "oder", "aber", "bei", "nach", "wie", "einer", "über"},
+	"fr": {"le", "la", "les", "de", "des", "et", "est", "un", "une", "du", "que", "qui", "dans", "pour", "pas", "sur", "au", "avec", "ce", "il", "sont", "par", "plus", "vous", "nous", "aux", "cette", "ou", "mais", "été"},
+	"es": {"el", "la", "los", "las", "de", "que", "y", "en", "un", "una", "es", "por", "con", "para", "del", "se", "no", "al", "lo", "como", "más", "pero", "sus", "su", "está", "son", "también", "fue", "muy", "hay"},
+	"it": {"il", "di", "che", "la", "e", "un", "una", "per", "non", "sono", "del", "della", "con", "gli", "le", "da", "si", "nel", "alla", "anche", "come", "più", "questo", "ma", "delle", "dei", "è", "essere", "hanno", "stato"},
+	"pt": {"o", "a", "os", "as", "de", "que", "e", "do", "da", "em", "um", "uma", "para", "com", "não", "por", "se", "mais", "dos", "das", "como", "mas", "ao", "ele", "foi", "são", "está", "também", "já", "você"},
+	"nl": {"de", "het", "een", "en", "van", "is", "dat", "op", "te", "in", "zijn", "voor", "niet", "met", "die", "aan", "er", "ook", "als", "bij", "maar", "om", "wordt", "naar", "deze", "dan", "nog", "wij", "worden", "kan"},
+	"sv": {"och", "att", "det", "som", "en", "på", "är", "av", "för", "med", "till", "den", "har", "de", "inte", "om", "ett", "var", "jag", "men", "så", "kan", "vi", "eller", "från", "också", "när", "vid", "sig", "efter"},
+	"da": {"og", "at", "det", "er", "en", "til", "på", "som", "med", "for", "af", "den", "har", "de", "ikke", "et", "der", "var", "om", "vi", "kan", "jeg", "men", "fra", "eller", "også", "når", "være", "efter", "hvor"},
+	"no": {"og", "det", "er", "en", "til", "på", "som", "med", "for", "av", "ikke", "har", "de", "et", "den", "var", "om", "vi", "kan", "jeg", "men", "fra", "eller", "også", "når", "være", "etter", "hvor", "seg", "ble"},
+	"fi": {"ja", "on", "ei", "se", "että", "oli", "ole", "kun", "mutta", "tai", "hän", "sen", "myös", "joka", "ovat", "vain", "niin", "kuin", "jos", "tämä", "olla", "me", "he", "nyt", "mukaan", "voi", "jo", "sekä", "koska", "siitä"},
+	"pl": {"i", "w", "na", "z", "się", "nie", "do", "to", "że", "jest", "o", "jak", "po", "co", "ale", "od", "za", "dla", "jego", "tak", "przez", "są", "lub", "może", "tym", "czy", "oraz", "już", "także", "które"},
+	"cs": {"a", "je", "se", "na", "v", "že", "to", "s", "z", "do", "o", "jako", "ale", "pro", "by", "tak", "jsou", "k", "od", "po", "jeho", "také", "nebo", "už", "bylo", "být", "který", "která", "které", "podle"},
+	"tr": {"ve", "bir", "bu", "da", "de", "için", "ile", "olan", "çok", "daha", "gibi", "ama", "en", "ne", "var", "olarak", "kadar", "sonra", "her", "değil", "ya", "mi", "şey", "o", "ben", "biz", "onun", "ise", "veya", "göre"},
+	"ro": {"și", "în", "de", "la", "cu", "să", "nu", "este", "pe", "o", "un", "din", "care", "pentru", "mai", "ce", "se", "sunt", "sau", "fost", "au", "lui", "dar", "acest", "această", "fi", "ca", "după", "prin", "foarte"},
+	"hu": {"a", "az", "és", "hogy", "nem", "is", "egy", "van", "meg", "de", "csak", "már", "mint", "el", "ki", "még", "volt", "vagy", "kell", "ez", "azt", "ha", "lesz", "minden", "után", "szerint", "pedig", "nagyon", "lehet", "akkor"},
+	"ru": {"и", "в", "не", "на", "что", "с", "по", "это", "как", "для", "он", "из", "но", "к", "у", "от", "о", "так", "же", "все", "его", "она", "они", "мы", "вы", "был", "или", "если", "только", "уже"},
+	"uk": {"і", "в", "не", "на", "що", "з", "та", "це", "як", "для", "він", "до", "але", "у", "від", "про", "так", "же", "все", "його", "вона", "вони", "ми", "ви", "був", "або", "якщо", "тільки", "вже", "є"},
+	"bg": {"и", "в", "на", "не", "да", "е", "се", "за", "с", "от", "че", "по", "са", "като", "но", "това", "той", "тя", "те", "ние", "вие", "беше", "или", "ако", "само", "вече", "може", "има", "към", "при"},
+}
+
+var languageStopwordSets = buildStopwordSets()
+
+func buildStopwordSets() map[string]map[string]struct{} {
+	sets := make(map[string]map[string]struct{}, len(languageStopwords))
+	for lang, words := range languageStopwords {
+		set := make(map[string]struct{}, len(words))
+		for _, word := range words {
+			set[word] = struct{}{}
+		}
+		sets[lang] = set
+	}
+	return sets
+}
+
+// DetectLanguage guesses the ISO 639-1 language of text without any network
+// access. It first classifies the dominant script and, for scripts shared by
+// several languages, scores stopword frequencies. It returns an empty code when
+// the text is too short or ambiguous; confidence is in [0, 1].
+func DetectLanguage(text string) (string, float64) {
+	sample := []rune(text)
+	if len(sample) > maxLanguageSampleRunes {
+		sample = sample[:maxLanguageSampleRunes]
+	}
+
+	scripts := map[string]int{}
+	letters := 0
+	for _, r := range sample {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		scripts[scriptOf(r)]++
+	}
+	if letters == 0 {
+		return "", 0
+	}
+
+	dominant := ""
+	for script, count := range scripts {
+		if dominant == "" || count > scripts[dominant] || (count == scripts[dominant] && script < dominant) {
+			dominant = script
+		}
+	}
+	share := float64(scripts[dominant]) / float64(letters)
+
+	switch dominant {
+	case "han", "kana":
+		// Japanese mixes kanji with kana; any meaningful kana share means ja.
+		if float64(scripts["kana"])/float64(letters) > 0.1 {
+			return "ja", share
+		}
+		return "zh", share
+	case "hangul":
+		return "ko", share
+	case "arabic":
+		return "ar", share
+	case "hebrew":
+		return "he", share
+	case "greek":
+		return "el", share
+	case "thai":
+		return "th", share
+	case "devanagari":
+		return "hi", share
+	case "latin", "cyrillic":
+		return detectByStopwords(string(sample), dominant)
+	default:
+		return "", 0
+	}
+}
+
+func detectByStopwords(text, script string) (string, float64) {
+	scores := map[string]int{}
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}) {
+		for lang, set := range languageStopwordSets {
+			if isCyrillicLanguage(lang) != (script == "cyrillic") {
+				continue
+			}
+			if _, ok := set[word]; ok {
+				scores[lang]++
+			}
+		}
+	}
+
+	best, second := "", ""
+	for lang, score := range scores {
+		switch {
+		case best == "" || score > scores[best] || (score == scores[best] && lang < best):
+			second = best
+			best = lang
+		case second == "" || score > scores[second] || (score == scores[second] && lang < second):
+			second = lang
+		}
+	}
+	if best == "" || scores[best] < minLanguageHits {
+		return "", 0
+	}
+	confidence := float64(scores[best]-scores[second]) / float64(scores[best])
+	return best, confidence
+}
+
+func isCyrillicLanguage(lang string) bool {
+	return lang == "ru" || lang == "uk" || lang == "bg"
+}
+
+func scriptOf(r rune) string {
+	switch {
+	case unicode.Is(unicode.Latin, r):
+		return "latin"
+	case unicode.Is(unicode.Cyrillic, r):
+		return "cyrillic"
+	case unicode.Is(unicode.Hiragana, r), unicode.Is(unicode.Katakana, r):
+		return "kana"
+	case unicode.Is(unicode.Han, r):
+		return "han"
+	case unicode.Is(unicode.Hangul, r):
+		return "hangul"
+	case unicode.Is(unicode.Arabic, r):
+		return "arabic"
+	case unicode.Is(unicode.Hebrew, r):
+		return "hebrew"
+	case unicode.Is(unicode.Greek, r):
+		return "greek"
+	case unicode.Is(unicode.Thai, r):
+		return "thai"
+	case unicode.Is(unicode.Devanagari, r):
+		return "devanagari"
+	default:
+		return "other"
+	}
+}
+
+// normalizeLanguageTag lowercases a BCP 47 tag and uses "-" separators.
+// Content-Language lists are reduced to their first entry.
+func normalizeLanguageTag(tag string) string {
+	tag, _, _ = strings.Cut(tag, ",")
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	return strings.ReplaceAll(tag, "_", "-")
+}
+
+// primaryLanguage returns the primary subtag of a language tag ("en-us" -> "en").
+func primaryLanguage(tag string) string {
+	primary, _, _ := strings.Cut(normalizeLanguageTag(tag), "-")
+	return primary
+}
+
+// resolvePageLanguage picks the page language: a confident detection wins over
+// declared values because templates often hard-code <html lang>; otherwise the
+// declared <html lang>, then Content-Language is used. A declared tag sharing
+// the detected primary subtag is kept for its region information.
+func resolvePageLanguage(detected string, confidence float64, htmlLang, contentLanguage string) string {
+	declared := normalizeLanguageTag(htmlLang)
+	if declared == "" {
+		declared = normalizeLanguageTag(contentLanguage)
+	}
+	if detected != "" && confidence >= 0.3 {
+		if primaryLanguage(declared) == detected {
+			return declared
+		}
+		return detected
+	}
+	if declared != "" {
+		return declared
+	}
+	return detected
+}
+
+// ParseLanguages parses a comma-separated language filter such as "en,de-at".
+func ParseLanguages(raw string) []string {
+	var langs []string
+	seen := map[string]struct{}{}
+	for _, part := range strings.Split(raw, ",") {
+		lang := normalizeLanguageTag(part)
+		if lang == "" {
+			continue
+		}
+		if _, ok := seen[lang]; ok {
+			continue
+		}
+		seen[lang] = struct{}{}
+		langs = append(langs, lang)
+	}
+	return langs
+}
+
+// languageAllowed reports whether lang passes the allowed filter. An empty
+// filter or an unknown language always passes. "en" admits "en-gb", and a
+// bare "pt" admits a "pt-br" filter entry.
+func languageAllowed(lang string, allowed []string) bool {
+	lang = normalizeLanguageTag(lang)
+	if len(allowed) == 0 || lang == "" || lang == "x-default" {
+		return true
+	}
+	for _, want := range allowed {
+		if lang == want || strings.HasPrefix(lang, want+"-") || strings.HasPrefix(want, lang+"-") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+package crawler
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"The quick brown fox jumps over the lazy dog. This is a page that is written in English for the readers of the site.", "en"},
+		{"Die Katze sitzt auf der Matte und das ist nicht schlimm, denn sie ist mit dem Hund befreundet und wir freuen uns.", "de"},
+		{"Le chat est sur le tapis et il ne veut pas partir, car il fait froid dans la maison pour les enfants.", "fr"},
+		{"El gato está en la alfombra y no quiere salir, porque hace frío en la casa para los niños que viven con su madre.", "es"},
+		{"Кошка сидит на ковре, и это не страшно, потому что она дружит с собакой, и мы все этому рады.", "ru"},
+		{"これは日本語のテキストです。ウェブサイトの内容を説明しています。", "ja"},
+		{"这是一个中文网站的内容说明文本。", "zh"},
+	}
+	for _, tt := range tests {
+		got, confidence := DetectLanguage(tt.text)
+		if got != tt.want {
+			t.Fatalf("expected %s, got %s (confidence %.2f) for %q", tt.want, got, confidence, tt.text)
+		}
+	}
+}
+
+func TestDetectLanguageShortTextIsUnknown(t *testing.T) {
+	if got, _ := DetectLanguage("Login"); got != "" {
+		t.Fatalf("expected no detection for short text, got %s", got)
+	}
+}
+
+func TestResolvePageLanguage(t *testing.T) {
+	if got := resolvePageLanguage("de", 0.8, "en", ""); got != "de" {
+		t.Fatalf("expected confident detection to win, got %s", got)
+	}
+	if got := resolvePageLanguage("en", 0.8, "en-GB", ""); got != "en-gb" {
+		t.Fatalf("expected declared region to be kept, got %s", got)
+	}
+	if got := resolvePageLanguage("", 0, "", "fr-CA, en"); got != "fr-ca" {
+		t.Fatalf("expected content-language fallback, got %s", got)
+	}
+}
+
+func TestLanguageFilter(t *testing.T) {
+	allowed := ParseLanguages("EN, de_AT,en")
+	if !reflect.DeepEqual(allowed, []string{"en", "de-at"}) {
+		t.Fatalf("unexpected parsed languages: %v", allowed)
+	}
+
+	tests := []struct {
+		lang string
+		want bool
+	}{
+		{"en", true},
+		{"en-gb", true},
+		{"de", true},
+		{"de-de", false},
+		{"fr", false},
+		{"", true},
+		{"x-default", true},
+	}
+	for _, tt := range tests {
+		if got := languageAllowed(tt.lang, allowed); got != tt.want {
+			t.Fatalf("expected %v for %q, got %v", tt.want, tt.lang, got)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LinkCheckMode selects which discovered links are validated after a crawl.
+type LinkCheckMode string
+
+const (
+	// LinkCheckOff disables link checking.
+	LinkCheckOff LinkCheckMode = ""
+	// LinkCheckInternal validates links to hosts inside the crawl scope.
+	LinkCheckInternal LinkCheckMode = "internal"
+	// LinkCheckAll also validates links to external and out-of-scope hosts.
+	LinkCheckAll LinkCheckMode = "all"
+)
+
+// DefaultLinkCheckConcurrency is the number of hosts checked at once.
+const DefaultLinkCheckConcurrency = 8
+
+// linkCheckBodyLimit bounds how much of a GET response is read before the
+// connection is released.
+const linkCheckBodyLimit = 64 << 10
+
+// ParseLinkCheckMode validates and normalizes a link check flag value. An
+// empty value or "off" disables checking.
+func ParseLinkCheckMode(raw string) (LinkCheckMode, error) {
+	switch mode := LinkCheckMode(strings.ToLower(strings.TrimSpace(raw))); mode {
+	case LinkCheckOff, "off":
+		return LinkCheckOff, nil
+	case LinkCheckInternal, LinkCheckAll:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid link check mode %q (allowed: internal, all)", raw)
+	}
+}
+
+// includes reports whether links to a host of the given scope class are
+// checked.
+func (m LinkCheckMode) includes(class string) bool {
+	switch m {
+	case LinkCheckAll:
+		return true
+	case LinkCheckInternal:
+		return class == ScopeClassAllowed
+	default:
+		return false
+	}
+}
+
+// LinkSource is a page linking to a checked URL.
+type LinkSource struct {
+	URL  string
+	Text string
+}
+
+// BrokenLink is a link target that failed the check, with every crawled
+// page linking to it.
+type BrokenLink struct {
+	URL string
+	// StatusCode is the final HTTP status after redirects, or 0 when no
+	// response arrived.
+	StatusCode int
+	Error      string
+	Sources    []LinkSource
+}
+
+// LinkCheck is the outcome of validating the links found during a crawl.
+type LinkCheck struct {
+	Mode LinkCheckMode
+	// Links counts distinct source/target pairs; Checked counts the
+	// distinct targets requested.
+	Links         int
+	Checked       int
+	SkippedRobots int
+	Broken        []BrokenLink
+}
+
+// linkCollector gathers the links to check while pages are crawled.
+type linkCollector struct {
+	mode    LinkCheckMode
+	links   int
+	sources map[string][]LinkSource
+	seen    map[[2]string]struct{}
+}
+
+func newLinkCollector(mode LinkCheckMode) *linkCollector {
+	return &linkCollector{
+		mode:    mode,
+		sources: map[string][]LinkSource{},
+		seen:    map[[2]string]struct{}{},
+	}
+}
+
+// add records a link from source to target, keeping the first anchor text
+// of repeated links.
+func (c *linkCollector) add(source, target, text, class string) {
+	if !c.mode.includes(class) {
+		return
+	}
+	key := [2]string{source, target}
+	if _, ok := c.seen[key]; ok {
+		return
+	}
+	c.seen[key] = struct{}{}
+	c.links++
+	c.sources[target] = append(c.sources[target], LinkSource{URL: source, Text: text})
+}
+
+type linkStatus struct {
+	code int
+	err  string
+}
+
+func (s linkStatus) broken() bool {
+	return s.err != "" || s.code >= http.StatusBadRequest
+}
+
+// linkChecker requests link targets with at most one request in flight per
+// host and the crawl delay between requests to the same host.
+type linkChecker struct {
+	client      *http.Client
+	userAgent   string
+	delay       time.Duration
+	concurrency int
+	logger      *slog.Logger
+}
+
+// checkLinks validates every collected target. Internal targets disallowed by
+// robots.txt are not requested.
+func checkLinks(ctx context.Context, collector *linkCollector, checker *linkChecker, robots *robotsCache, scope Scope) *LinkCheck {
+	check := &LinkCheck{Mode: collector.mode, Links: collector.links, Broken: []BrokenLink{}}
+	var targets []string
+	for target := range collector.sources {
+		if parsed, err := url.Parse(target); robots != nil && err == nil && scope.IsAllowedHost(parsed.Hostname()) {
+			if allowed, _ := robots.Allowed(target); !allowed {
+				check.SkippedRobots++
+				continue
+			}
+		}
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+
+	statuses := checker.check(ctx, targets)
+	check.Checked = len(statuses)
+	for _, target := range targets {
+		status, ok := statuses[target]
+		if !ok || !status.broken() {
+			continue
+		}
+		sources := append([]LinkSource(nil), collector.sources[target]...)
+		sort.Slice(sources, func(i, j int) bool {
+			if sources[i].URL != sources[j].URL {
+				return sources[i].URL < sources[j].URL
+			}
+			return sources[i].Text < sources[j].Text
+		})
+		check.Broken = append(check.Broken, BrokenLink{
+			URL:        target,
+			StatusCode: status.code,
+			Error:      status.err,
+			Sources:    sources,
+		})
+	}
+	return check
+}
+
+// check requests targets host by host. Targets left when ctx is done are
+// missing from the result.
+func (lc *linkChecker) check(ctx context.Context, targets []string) map[string]linkStatus {
+	byHost := map[string][]string{}
+	var hosts []string
+	for _, target := range targets {
+		host := target
+		if parsed, err := url.Parse(target); err == nil {
+			host = parsed.Host
+		}
+		if _, ok := byHost[host]; !ok {
+			hosts = append(hosts, host)
+		}
+		byHost[host] = append(byHost[host], target)
+	}
+
+	var mu sync.Mutex
+	statuses := make(map[string]linkStatus, len(targets))
+	hostCh := make(chan string)
+	var wg sync.WaitGroup
+	for range min(max(lc.concurrency, 1), len(hosts)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for host := range hostCh {
+				for i, target := range byHost[host] {
+					if i > 0 && !sleepWithJitter(ctx, lc.delay) {
+						break
+					}
+					status := lc.checkURL(ctx, target)
+					if ctx.Err() != nil {
+						break
+					}
+					mu.Lock()
+					statuses[target] = status
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+feed:
+	for _, host := range hosts {
+		select {
+		case hostCh <- host:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(hostCh)
+	wg.Wait()
+	return statuses
+}
+
+// checkURL sends HEAD and falls back to GET when HEAD fails, since some
+// servers reject or mishandle it.
+func (lc *linkChecker) checkURL(ctx context.Context, target string) linkStatus {
+	code, err := lc.request(ctx, http.MethodHead, target)
+	if err == nil && code < http.StatusBadRequest {
+		return linkStatus{code: code}
+	}
+	code, err = lc.request(ctx, http.MethodGet, target)
+	if err != nil {
+		lc.logger.Debug("link check failed", "url", target, "error", err)
+		return linkStatus{err: err.Error()}
+	}
+	return linkStatus{code: code}
+}
+
+func (lc *linkChecker) request(ctx context.Context, method, target string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("User-Agent", lc.userAgent)
+	resp, err := lc.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, linkCheckBodyLimit))
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,144 @@
+package crawler
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseLinkCheckMode(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    LinkCheckMode
+		wantErr bool
+	}{
+		{raw: "", want: LinkCheckOff},
+		{raw: "off", want: LinkCheckOff},
+		{raw: " Internal ", want: LinkCheckInternal},
+		{raw: "all", want: LinkCheckAll},
+		{raw: "external", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseLinkCheckMode(tt.raw)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Fatalf("ParseLinkCheckMode(%q) = %q, %v", tt.raw, got, err)
+		}
+	}
+}
+
+func TestLinkCollectorFiltersByMode(t *testing.T) {
+	collector := newLinkCollector(LinkCheckInternal)
+	collector.add("https://example.com/", "https://example.com/a", "A", ScopeClassAllowed)
+	collector.add("https://example.com/", "https://example.com/a", "A again", ScopeClassAllowed)
+	collector.add("https://example.com/", "https://other.org/", "Other", ScopeClassExternal)
+	if collector.links != 1 || len(collector.sources) != 1 {
+		t.Fatalf("expected one internal link, got %d links, %v", collector.links, collector.sources)
+	}
+	if got := collector.sources["https://example.com/a"]; !reflect.DeepEqual(got, []LinkSource{{URL: "https://example.com/", Text: "A"}}) {
+		t.Fatalf("expected first anchor text to be kept, got %v", got)
+	}
+
+	collector = newLinkCollector(LinkCheckAll)
+	collector.add("https://example.com/", "https://other.org/", "Other", ScopeClassExternal)
+	collector.add("https://example.com/", "https://blog.example.com/", "Blog", ScopeClassOutOfScope)
+	if collector.links != 2 {
+		t.Fatalf("expected all links, got %d", collector.links)
+	}
+}
+
+func TestCheckLinksReportsBrokenTargets(t *testing.T) {
+	var mu sync.Mutex
+	methods := map[string][]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		methods[r.URL.Path] = append(methods[r.URL.Path], r.Method)
+		mu.Unlock()
+		if r.Header.Get("User-Agent") != "sitecrawl-test" {
+			t.Errorf("unexpected user agent %q", r.Header.Get("User-Agent"))
+		}
+		switch r.URL.Path {
+		case "/ok":
+		case "/no-head":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+		case "/moved":
+			http.Redirect(w, r, "/gone", http.StatusMovedPermanently)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closedURL := closed.URL + "/down"
+	closed.Close()
+
+	collector := newLinkCollector(LinkCheckAll)
+	for _, link := range []struct{ source, path, text string }{
+		{"https://example.com/b", "/gone", "Gone"},
+		{"https://example.com/a", "/gone", "Old page"},
+		{"https://example.com/a", "/ok", "OK"},
+		{"https://example.com/a", "/no-head", "No HEAD"},
+		{"https://example.com/a", "/moved", "Moved"},
+	} {
+		collector.add(link.source, server.URL+link.path, link.text, ScopeClassExternal)
+	}
+	collector.add("https://example.com/a", closedURL, "Down", ScopeClassExternal)
+
+	scope, err := NewScope("example.com")
+	if err != nil {
+		t.Fatalf("scope: %v", err)
+	}
+	checker := &linkChecker{
+		client:      &http.Client{Timeout: 5 * time.Second},
+		userAgent:   "sitecrawl-test",
+		delay:       time.Millisecond,
+		concurrency: 2,
+		logger:      slog.Default(),
+	}
+	check := checkLinks(context.Background(), collector, checker, nil, scope)
+
+	if check.Mode != LinkCheckAll || check.Links != 6 || check.Checked != 5 {
+		t.Fatalf("unexpected summary: %+v", check)
+	}
+	if len(check.Broken) != 3 {
+		t.Fatalf("expected 3 broken links, got %+v", check.Broken)
+	}
+	byURL := map[string]BrokenLink{}
+	for _, broken := range check.Broken {
+		byURL[broken.URL] = broken
+	}
+	gone := byURL[server.URL+"/gone"]
+	if gone.StatusCode != http.StatusNotFound || !reflect.DeepEqual(gone.Sources, []LinkSource{
+		{URL: "https://example.com/a", Text: "Old page"},
+		{URL: "https://example.com/b", Text: "Gone"},
+	}) {
+		t.Fatalf("unexpected /gone entry: %+v", gone)
+	}
+	if moved := byURL[server.URL+"/moved"]; moved.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected redirect to be followed to a 404, got %+v", moved)
+	}
+	if down := byURL[closedURL]; down.StatusCode != 0 || down.Error == "" {
+		t.Fatalf("expected a connection error, got %+v", down)
+	}
+	if got := methods["/no-head"]; !reflect.DeepEqual(got, []string{http.MethodHead, http.MethodGet}) {
+		t.Fatalf("expected GET fallback after a failed HEAD, got %v", got)
+	}
+	if got := methods["/ok"]; !reflect.DeepEqual(got, []string{http.MethodHead}) {
+		t.Fatalf("expected a single HEAD for a healthy link, got %v", got)
+	}
+}
+
+func TestLinkCheckerStopsWhenCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	checker := &linkChecker{client: http.DefaultClient, concurrency: 1, logger: slog.Default()}
+	if statuses := checker.check(ctx, []string{"http://127.0.0.1:1/a", "http://127.0.0.1:1/b"}); len(statuses) != 0 {
+		t.Fatalf("expected no statuses after cancellation, got %v", statuses)
+	}
+}
@@ -1,11 +1,13 @@
 package crawler
 
 import (
+	"errors"
 	"sort"
 
 	"github.com/sbstn/sitecrawl/pkg/pagerank"
 )
 
+// LinkGraph is the directed graph of internal links between normalized URLs.
 type LinkGraph struct {
 	nodes map[string]struct{}
 	edges map[string]map[string]struct{}
@@ -40,11 +42,78 @@ func (g *LinkGraph) AddEdge(from, to string) {
 	g.edges[from][to] = struct{}{}
 }
 
+// Edge is a directed link between two normalized URLs.
+type Edge struct {
+	From string
+	To   string
+}
+
+// Nodes returns the graph's URLs, sorted.
+func (g *LinkGraph) Nodes() []string {
+	if g == nil {
+		return nil
+	}
+	nodes := make([]string, 0, len(g.nodes))
+	for node := range g.nodes {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	return nodes
+}
+
+// Edges returns the graph's links sorted by source, then target.
+func (g *LinkGraph) Edges() []Edge {
+	if g == nil {
+		return nil
+	}
+	var edges []Edge
+	for from, targets := range g.edges {
+		for to := range targets {
+			edges = append(edges, Edge{From: from, To: to})
+		}
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].From != edges[j].From {
+			return edges[i].From < edges[j].From
+		}
+		return edges[i].To < edges[j].To
+	})
+	return edges
+}
+
+// PageRankOptions tunes ComputePageRank.
+type PageRankOptions struct {
+	Alpha         float64
+	Tolerance     float64
+	MaxIterations uint
+	// Personalization biases the random jump towards URLs in proportion to
+	// their weight. Nil jumps uniformly.
+	Personalization map[string]float64
+	// Weights sets link weights; links without an entry weigh 1. Nil
+	// distributes a page's rank evenly over its links.
+	Weights map[Edge]float64
+}
+
+// DefaultPageRankOptions returns the parameters used during crawls.
+func DefaultPageRankOptions() PageRankOptions {
+	pr := pagerank.NewPageRank(nil)
+	return PageRankOptions{Alpha: pr.Alpha, Tolerance: pr.Tolerance, MaxIterations: pr.MaxIter}
+}
+
 // ComputePageRankScores adapts LinkGraph into pkg/pagerank and returns score + order.
+// A computation that does not converge keeps the scores of its last iteration.
 func ComputePageRankScores(g *LinkGraph) (map[string]float64, []string) {
+	scores, order, _ := ComputePageRank(g, DefaultPageRankOptions())
+	return scores, order
+}
+
+// ComputePageRank is ComputePageRankScores with tuned parameters. The order
+// is by descending score, then URL. On pagerank.ErrNotConverged the scores
+// of the last iteration are returned along with the error.
+func ComputePageRank(g *LinkGraph, opts PageRankOptions) (map[string]float64, []string, error) {
 	scores := map[string]float64{}
 	if g == nil || len(g.nodes) == 0 {
-		return scores, nil
+		return scores, nil, nil
 	}
 
 	prGraph := pagerank.NewGraph()
@@ -54,44 +123,66 @@ func ComputePageRankScores(g *LinkGraph) (map[string]float64, []string) {
 	for from, targets := range g.edges {
 		for to := range targets {
 			prGraph.AddEdge(from, to)
+			if opts.Weights != nil {
+				weight, ok := opts.Weights[Edge{From: from, To: to}]
+				if !ok {
+					weight = 1
+				}
+				prGraph.GetEdge(string(pagerank.GenerateEdgeID(prGraph.GetNode(from), prGraph.GetNode(to)))).Weight = weight
+			}
 		}
 	}
 
 	pr := pagerank.NewPageRank(prGraph)
-	pr.CalcPageRank()
+	pr.Alpha = opts.Alpha
+	pr.Tolerance = opts.Tolerance
+	pr.MaxIter = opts.MaxIterations
+	pr.Weighted = opts.Weights != nil
+	if opts.Personalization != nil {
+		pr.Personalization = map[pagerank.NodeID]float64{}
+		for url, weight := range opts.Personalization {
+			pr.Personalization[pagerank.NodeID(url)] = weight
+		}
+	}
+	runErr := pr.Run()
+	if runErr != nil && !errors.Is(runErr, pagerank.ErrNotConverged) {
+		return nil, nil, runErr
+	}
 
+	order := make([]string, 0, len(g.nodes))
 	if len(prGraph.Edges) == 0 {
-		order := make([]string, 0, len(g.nodes))
 		uniform := 1.0 / float64(len(g.nodes))
 		for node := range g.nodes {
 			scores[node] = uniform
 			order = append(order, node)
 		}
 		sort.Strings(order)
-		return scores, order
+		return scores, order, runErr
 	}
 
 	for nodeID, node := range prGraph.Nodes {
 		scores[string(nodeID)] = node.Rank
-	}
-
-	pr.OrderResults()
-	ids := pr.GetMaxToMinOrder()
-	order := make([]string, 0, len(ids))
-	for _, nodeID := range ids {
 		order = append(order, string(nodeID))
 	}
-	return scores, order
+	sort.Slice(order, func(i, j int) bool {
+		if scores[order[i]] != scores[order[j]] {
+			return scores[order[i]] > scores[order[j]]
+		}
+		return order[i] < order[j]
+	})
+	return scores, order, runErr
 }
 
 // ApplyPageRankScores annotates crawl pages with scores and score-desc ordering.
-func ApplyPageRankScores(result *CrawlResult, g *LinkGraph) {
+// When PageRank does not converge, the pages are scored with the last
+// iteration and pagerank.ErrNotConverged is returned.
+func ApplyPageRankScores(result *CrawlResult, g *LinkGraph) error {
 	if result == nil {
-		return
+		return nil
 	}
 
 	result.PageRankImplementation = PageRankImplementation
-	scores, order := ComputePageRankScores(g)
+	scores, order, err := ComputePageRank(g, DefaultPageRankOptions())
 	orderPos := map[string]int{}
 	for idx, url := range order {
 		orderPos[url] = idx
@@ -136,4 +227,5 @@ func ApplyPageRankScores(result *CrawlResult, g *LinkGraph) {
 		}
 		return iURL < jURL
 	})
+	return err
 }
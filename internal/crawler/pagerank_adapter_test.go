@@ -1,8 +1,11 @@
 package crawler
 
 import (
+	"errors"
 	"strings"
 	"testing"
+
+	"github.com/sbstn/sitecrawl/pkg/pagerank"
 )
 
 func TestPageRankIntegrationProducesScoresAndOrdering(t *testing.T) {
@@ -24,7 +27,9 @@ func TestPageRankIntegrationProducesScoresAndOrdering(t *testing.T) {
 		},
 	}
 
-	ApplyPageRankScores(result, graph)
+	if err := ApplyPageRankScores(result, graph); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
 
 	if !strings.Contains(result.PageRankImplementation, "pkg/pagerank") {
 		t.Fatalf("expected pagerank implementation metadata to reference pkg/pagerank, got %q", result.PageRankImplementation)
@@ -43,3 +48,88 @@ func TestPageRankIntegrationProducesScoresAndOrdering(t *testing.T) {
 		t.Fatalf("expected page %s to be ranked first, got %s", b, result.Pages[0].FinalURL)
 	}
 }
+
+func TestLinkGraphNodesAndEdgesAreSorted(t *testing.T) {
+	graph := NewLinkGraph()
+	graph.AddEdge("https://example.com/b", "https://example.com/a")
+	graph.AddEdge("https://example.com/a", "https://example.com/c")
+	graph.AddEdge("https://example.com/a", "https://example.com/b")
+	graph.AddEdge("https://example.com/a", "https://example.com/b")
+	graph.AddNode("https://example.com/d")
+
+	nodes := graph.Nodes()
+	if strings.Join(nodes, " ") != "https://example.com/a https://example.com/b https://example.com/c https://example.com/d" {
+		t.Fatalf("unexpected nodes: %v", nodes)
+	}
+	edges := graph.Edges()
+	want := []Edge{
+		{From: "https://example.com/a", To: "https://example.com/b"},
+		{From: "https://example.com/a", To: "https://example.com/c"},
+		{From: "https://example.com/b", To: "https://example.com/a"},
+	}
+	if len(edges) != len(want) {
+		t.Fatalf("unexpected edges: %v", edges)
+	}
+	for i := range want {
+		if edges[i] != want[i] {
+			t.Fatalf("unexpected edge %d: %v", i, edges[i])
+		}
+	}
+
+	var empty *LinkGraph
+	if empty.Nodes() != nil || empty.Edges() != nil {
+		t.Fatalf("expected nil graph to have no nodes or edges")
+	}
+}
+
+func TestComputePageRankOptions(t *testing.T) {
+	graph := NewLinkGraph()
+	graph.AddEdge("https://example.com/", "https://example.com/a")
+	graph.AddEdge("https://example.com/", "https://example.com/b")
+	graph.AddEdge("https://example.com/a", "https://example.com/")
+	graph.AddEdge("https://example.com/b", "https://example.com/")
+
+	base, order, err := ComputePageRank(graph, DefaultPageRankOptions())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if base["https://example.com/a"] != base["https://example.com/b"] {
+		t.Fatalf("expected symmetric pages to tie, got %v", base)
+	}
+	if len(order) != 3 || order[0] != "https://example.com/" || order[1] != "https://example.com/a" {
+		t.Fatalf("unexpected order: %v", order)
+	}
+
+	opts := DefaultPageRankOptions()
+	opts.Weights = map[Edge]float64{{From: "https://example.com/", To: "https://example.com/b"}: 3}
+	weighted, order, err := ComputePageRank(graph, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if weighted["https://example.com/b"] <= weighted["https://example.com/a"] || order[1] != "https://example.com/b" {
+		t.Fatalf("expected the heavier link to favor b, got %v (order %v)", weighted, order)
+	}
+
+	opts = DefaultPageRankOptions()
+	opts.Personalization = map[string]float64{"https://example.com/a": 1}
+	personalized, _, err := ComputePageRank(graph, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if personalized["https://example.com/a"] <= personalized["https://example.com/b"] {
+		t.Fatalf("expected personalization to favor a, got %v", personalized)
+	}
+
+	opts = DefaultPageRankOptions()
+	opts.MaxIterations = 1
+	scores, _, err := ComputePageRank(graph, opts)
+	if !errors.Is(err, pagerank.ErrNotConverged) || len(scores) != 3 {
+		t.Fatalf("expected last-iteration scores with ErrNotConverged, got %v, %v", scores, err)
+	}
+
+	opts = DefaultPageRankOptions()
+	opts.Alpha = 1
+	if _, _, err := ComputePageRank(graph, opts); err == nil {
+		t.Fatalf("expected error for alpha 1")
+	}
+}
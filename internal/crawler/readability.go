@@ -0,0 +1,531 @@
+package crawler
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Extractor selects how the main content of a page is located.
+type Extractor string
+
+const (
+	// ExtractorHeuristic picks main, article, [role=main], or body in the browser.
+	ExtractorHeuristic Extractor = "heuristic"
+	// ExtractorReadability scores the rendered DOM in Go, Readability-style.
+	ExtractorReadability Extractor = "readability"
+)
+
+// ParseExtractor validates and normalizes an extractor flag value.
+func ParseExtractor(raw string) (Extractor, error) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case string(ExtractorHeuristic):
+		return ExtractorHeuristic, nil
+	case string(ExtractorReadability):
+		return ExtractorReadability, nil
+	default:
+		return "", fmt.Errorf("invalid extractor %q (allowed: heuristic, readability)", raw)
+	}
+}
+
+var (
+	unlikelyCandidatePattern = regexp.MustCompile(`(?i)-ad-|ai2html|banner|breadcrumbs|combx|comment|community|consent|cookie|cover-wrap|disqus|extra|footer|gdpr|header|legends|menu|modal|newsletter|pager|pagination|popup|related|remark|replies|rss|share|shoutbox|sidebar|skyscraper|social|sponsor|subscribe|supplemental|yom-remote`)
+	maybeCandidatePattern    = regexp.MustCompile(`(?i)and|article|body|column|content|main|shadow`)
+	positiveClassPattern     = regexp.MustCompile(`(?i)article|body|content|entry|hentry|h-entry|main|page|pagination|post|text|blog|story`)
+	negativeClassPattern     = regexp.MustCompile(`(?i)-ad-|hidden|^hid$| hid$| hid |^hid |banner|combx|comment|com-|contact|cookie|consent|footer|gdpr|masthead|media|meta|modal|outbrain|promo|related|scroll|share|shoutbox|sidebar|skyscraper|sponsor|shopping|tags|tool|widget`)
+)
+
+// readabilityRemovedTags never contribute readable content.
+var readabilityRemovedTags = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Template: true,
+	atom.Iframe:   true,
+	atom.Svg:      true,
+	atom.Canvas:   true,
+	atom.Button:   true,
+	atom.Input:    true,
+	atom.Select:   true,
+	atom.Textarea: true,
+}
+
+// readabilityStructuralTags are layout elements that rarely hold the article.
+// <header> is kept because articles often carry their title in one.
+var readabilityStructuralTags = map[atom.Atom]bool{
+	atom.Nav:    true,
+	atom.Aside:  true,
+	atom.Footer: true,
+}
+
+// readabilityBlockTags are elements that make a <div> a container rather than
+// a paragraph-like leaf.
+var readabilityBlockTags = map[atom.Atom]bool{
+	atom.Blockquote: true, atom.Dl: true, atom.Div: true, atom.Ol: true, atom.P: true,
+	atom.Pre: true, atom.Table: true, atom.Ul: true, atom.Section: true, atom.Article: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Figure: true,
+}
+
+// readableResult is the outcome of scoring one document.
+type readableResult struct {
+	HTML       string
+	Text       string
+	RootPath   string
+	Confidence float64
+}
+
+// extractReadable locates the main content of rawHTML with a Readability-style
+// scorer: paragraphs earn points for length and commas, propagate them to
+// their ancestors, candidates are weighted by tag and class/id hints, and the
+// final score is discounted by link density. Siblings of the winner that
+// score well or read like prose are merged into the content.
+//
+// Confidence combines how clearly the winner beats the best unrelated
+// candidate, how much text it holds, and how little of it is link text.
+func extractReadable(rawHTML string) (readableResult, bool) {
+	doc, err := html.Parse(strings.NewReader(rawHTML))
+	if err != nil {
+		return readableResult{}, false
+	}
+	body := findFirst(doc, atom.Body)
+	if body == nil {
+		return readableResult{}, false
+	}
+	// Selectors must address the fetched page, so sibling positions are
+	// recorded before pruning removes elements.
+	positions := typePositions(doc)
+	pruneUnlikely(body)
+
+	scores := map[*html.Node]float64{}
+	var candidates []*html.Node
+	addCandidate := func(n *html.Node) {
+		if _, ok := scores[n]; ok {
+			return
+		}
+		scores[n] = initialScore(n)
+		candidates = append(candidates, n)
+	}
+
+	walkElements(body, func(n *html.Node) {
+		if !isParagraphLike(n) {
+			return
+		}
+		text := nodeText(n)
+		if len([]rune(text)) < 25 {
+			return
+		}
+		score := 1 + float64(strings.Count(text, ",")+strings.Count(text, "，")) +
+			math.Min(math.Floor(float64(len([]rune(text)))/100), 3)
+
+		level := 0
+		for ancestor := n.Parent; ancestor != nil && level < 5; ancestor = ancestor.Parent {
+			if ancestor.Type != html.ElementNode {
+				break
+			}
+			addCandidate(ancestor)
+			// Parent gets the full score, grandparent half, then score / (level * 3).
+			divider := float64(level * 3)
+			if level < 2 {
+				divider = float64(level + 1)
+			}
+			scores[ancestor] += score / divider
+			level++
+			if ancestor == body {
+				break
+			}
+		}
+	})
+
+	if len(candidates) == 0 {
+		return readableResult{
+			HTML:     renderChildren(body),
+			Text:     blockText(body),
+			RootPath: cssPath(body, positions),
+		}, true
+	}
+
+	for _, candidate := range candidates {
+		scores[candidate] *= 1 - linkDensity(candidate)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return scores[candidates[i]] > scores[candidates[j]]
+	})
+	top := candidates[0]
+	topScore := scores[top]
+
+	runnerUp := 0.0
+	for _, candidate := range candidates[1:] {
+		if isAncestor(candidate, top) || isAncestor(top, candidate) {
+			continue
+		}
+		runnerUp = scores[candidate]
+		break
+	}
+
+	content := []*html.Node{top}
+	if top.Parent != nil && top != body {
+		content = content[:0]
+		threshold := math.Max(10, topScore*0.2)
+		for sibling := top.Parent.FirstChild; sibling != nil; sibling = sibling.NextSibling {
+			if sibling.Type != html.ElementNode {
+				continue
+			}
+			if sibling == top {
+				content = append(content, sibling)
+				continue
+			}
+			if score, ok := scores[sibling]; ok && score >= threshold {
+				content = append(content, sibling)
+				continue
+			}
+			if sibling.DataAtom == atom.P {
+				text := nodeText(sibling)
+				density := linkDensity(sibling)
+				if (len(text) > 80 && density < 0.25) || (len(text) > 0 && density == 0 && strings.Contains(text, ". ")) {
+					content = append(content, sibling)
+				}
+			}
+		}
+	}
+
+	var htmlParts, textParts []string
+	textLen := 0
+	for _, node := range content {
+		cleanConditionally(node)
+		if node == top {
+			htmlParts = append(htmlParts, renderChildren(node))
+		} else {
+			htmlParts = append(htmlParts, renderNode(node))
+		}
+		if text := blockText(node); text != "" {
+			textParts = append(textParts, text)
+			textLen += len([]rune(text))
+		}
+	}
+
+	margin := 0.0
+	if topScore > 0 {
+		margin = math.Max(0, (topScore-runnerUp)/topScore)
+	}
+	lengthFactor := math.Min(1, float64(textLen)/1000)
+	linkFactor := 1 - linkDensity(top)
+	confidence := 0.4*margin + 0.3*lengthFactor + 0.3*linkFactor
+	confidence = math.Round(confidence*1000) / 1000
+
+	return readableResult{
+		HTML:       strings.Join(htmlParts, "\n"),
+		Text:       strings.Join(textParts, "\n\n"),
+		RootPath:   cssPath(top, positions),
+		Confidence: confidence,
+	}, true
+}
+
+// pruneUnlikely removes non-content elements and unlikely candidates.
+func pruneUnlikely(root *html.Node) {
+	var remove []*html.Node
+	walkElements(root, func(n *html.Node) {
+		if n == root {
+			return
+		}
+		if readabilityRemovedTags[n.DataAtom] || isHidden(n) {
+			remove = append(remove, n)
+			return
+		}
+		if readabilityStructuralTags[n.DataAtom] {
+			remove = append(remove, n)
+			return
+		}
+		if n.DataAtom == atom.Body || n.DataAtom == atom.Article || n.DataAtom == atom.Main {
+			return
+		}
+		hint := attr(n, "class") + " " + attr(n, "id")
+		if unlikelyCandidatePattern.MatchString(hint) && !maybeCandidatePattern.MatchString(hint) {
+			remove = append(remove, n)
+			return
+		}
+		role := strings.ToLower(attr(n, "role"))
+		if role == "navigation" || role == "complementary" || role == "banner" || role == "contentinfo" || role == "dialog" {
+			remove = append(remove, n)
+		}
+	})
+	for _, n := range remove {
+		if n.Parent != nil {
+			n.Parent.RemoveChild(n)
+		}
+	}
+}
+
+// cleanConditionally drops link-heavy or negatively weighted containers from
+// the selected content.
+func cleanConditionally(root *html.Node) {
+	var remove []*html.Node
+	walkElements(root, func(n *html.Node) {
+		if n == root {
+			return
+		}
+		switch n.DataAtom {
+		case atom.Div, atom.Section, atom.Ul, atom.Ol, atom.Table, atom.Form:
+		default:
+			return
+		}
+		textLen := len([]rune(nodeText(n)))
+		density := linkDensity(n)
+		if classWeight(n) < 0 && textLen < 500 {
+			remove = append(remove, n)
+			return
+		}
+		if density > 0.5 && textLen < 500 {
+			remove = append(remove, n)
+		}
+	})
+	for _, n := range remove {
+		if n.Parent != nil {
+			n.Parent.RemoveChild(n)
+		}
+	}
+}
+
+func initialScore(n *html.Node) float64 {
+	score := 0.0
+	switch n.DataAtom {
+	case atom.Div, atom.Article, atom.Main, atom.Section:
+		score = 5
+	case atom.Pre, atom.Td, atom.Blockquote:
+		score = 3
+	case atom.Address, atom.Ol, atom.Ul, atom.Dl, atom.Dd, atom.Dt, atom.Li, atom.Form:
+		score = -3
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6, atom.Th:
+		score = -5
+	}
+	return score + classWeight(n)
+}
+
+func classWeight(n *html.Node) float64 {
+	weight := 0.0
+	for _, value := range []string{attr(n, "class"), attr(n, "id")} {
+		if value == "" {
+			continue
+		}
+		if negativeClassPattern.MatchString(value) {
+			weight -= 25
+		}
+		if positiveClassPattern.MatchString(value) {
+			weight += 25
+		}
+	}
+	return weight
+}
+
+// isParagraphLike reports whether n is scored as a paragraph: p, pre, td, or
+// a div without block-level children.
+func isParagraphLike(n *html.Node) bool {
+	switch n.DataAtom {
+	case atom.P, atom.Pre, atom.Td:
+		return true
+	case atom.Div:
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type == html.ElementNode && readabilityBlockTags[child.DataAtom] {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+func isHidden(n *html.Node) bool {
+	if _, ok := attrLookup(n, "hidden"); ok {
+		return true
+	}
+	if strings.EqualFold(attr(n, "aria-hidden"), "true") {
+		return true
+	}
+	style := strings.ReplaceAll(strings.ToLower(attr(n, "style")), " ", "")
+	return strings.Contains(style, "display:none") || strings.Contains(style, "visibility:hidden")
+}
+
+func linkDensity(n *html.Node) float64 {
+	textLen := len([]rune(nodeText(n)))
+	if textLen == 0 {
+		return 0
+	}
+	linkLen := 0
+	walkElements(n, func(child *html.Node) {
+		if child.DataAtom == atom.A {
+			linkLen += len([]rune(nodeText(child)))
+		}
+	})
+	return math.Min(1, float64(linkLen)/float64(textLen))
+}
+
+// blockText mirrors the heuristic extractor's text: block elements joined by
+// blank lines, falling back to the whole text.
+func blockText(root *html.Node) string {
+	var blocks []string
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.DataAtom {
+			case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6,
+				atom.P, atom.Li, atom.Blockquote, atom.Pre:
+				if text := nodeText(n); text != "" {
+					blocks = append(blocks, text)
+				}
+				return
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(root)
+	if len(blocks) == 0 {
+		return nodeText(root)
+	}
+	return strings.Join(blocks, "\n\n")
+}
+
+// nodeText returns whitespace-normalized text content.
+func nodeText(n *html.Node) string {
+	var builder strings.Builder
+	var walk func(*html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.TextNode {
+			builder.WriteString(node.Data)
+			builder.WriteByte(' ')
+			return
+		}
+		if node.Type == html.ElementNode && readabilityRemovedTags[node.DataAtom] {
+			return
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(n)
+	return strings.Join(strings.Fields(builder.String()), " ")
+}
+
+// typePosition is an element's 1-based index among its siblings of the same
+// tag and the number of those siblings.
+type typePosition struct {
+	index, total int
+}
+
+// typePositions records the typePosition of every element under root.
+func typePositions(root *html.Node) map[*html.Node]typePosition {
+	positions := map[*html.Node]typePosition{}
+	var walk func(*html.Node)
+	walk = func(parent *html.Node) {
+		totals := map[string]int{}
+		for child := parent.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode {
+				continue
+			}
+			totals[child.Data]++
+			positions[child] = typePosition{index: totals[child.Data]}
+			walk(child)
+		}
+		for child := parent.FirstChild; child != nil; child = child.NextSibling {
+			if position, ok := positions[child]; ok {
+				position.total = totals[child.Data]
+				positions[child] = position
+			}
+		}
+	}
+	walk(root)
+	return positions
+}
+
+// cssPath builds a selector for n such as "body > div#content > article:nth-of-type(2)"
+// from the sibling positions recorded by typePositions. An element with an id
+// anchors the path.
+func cssPath(n *html.Node, positions map[*html.Node]typePosition) string {
+	var segments []string
+	for node := n; node != nil && node.Type == html.ElementNode; node = node.Parent {
+		segment := node.Data
+		if id := strings.TrimSpace(attr(node, "id")); id != "" && !strings.ContainsAny(id, " \t\n") {
+			segments = append(segments, segment+"#"+id)
+			break
+		}
+		if position := positions[node]; position.total > 1 {
+			segment += ":nth-of-type(" + strconv.Itoa(position.index) + ")"
+		}
+		segments = append(segments, segment)
+	}
+	for i, j := 0, len(segments)-1; i < j; i, j = i+1, j-1 {
+		segments[i], segments[j] = segments[j], segments[i]
+	}
+	return strings.Join(segments, " > ")
+}
+
+func renderNode(n *html.Node) string {
+	var builder strings.Builder
+	if err := html.Render(&builder, n); err != nil {
+		return ""
+	}
+	return builder.String()
+}
+
+func renderChildren(n *html.Node) string {
+	var builder strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if err := html.Render(&builder, child); err != nil {
+			return ""
+		}
+	}
+	return builder.String()
+}
+
+func isAncestor(ancestor, n *html.Node) bool {
+	for node := n.Parent; node != nil; node = node.Parent {
+		if node == ancestor {
+			return true
+		}
+	}
+	return false
+}
+
+func findFirst(n *html.Node, tag atom.Atom) *html.Node {
+	if n.Type == html.ElementNode && n.DataAtom == tag {
+		return n
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if found := findFirst(child, tag); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// walkElements calls fn for every element in document order, including root.
+func walkElements(root *html.Node, fn func(*html.Node)) {
+	if root.Type == html.ElementNode {
+		fn(root)
+	}
+	for child := root.FirstChild; child != nil; child = child.NextSibling {
+		walkElements(child, fn)
+	}
+}
+
+func attr(n *html.Node, key string) string {
+	value, _ := attrLookup(n, key)
+	return value
+}
+
+func attrLookup(n *html.Node, key string) (string, bool) {
+	for _, a := range n.Attr {
+		if strings.EqualFold(a.Key, key) {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
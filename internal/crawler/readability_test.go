@@ -0,0 +1,99 @@
+package crawler
+
+import (
+	"strings"
+	"testing"
+)
+
+const readabilityFixture = `<!doctype html>
+<html><head><title>Post</title><script>var tracking = "do not keep";</script></head>
+<body>
+  <nav><a href="/">Home</a> <a href="/docs">Docs</a> <a href="/blog">Blog</a></nav>
+  <div class="cookie-banner">We use cookies to improve your experience, please accept them all.</div>
+  <div class="layout">
+    <div class="sidebar">
+      <ul>
+        <li><a href="/a">Related article number one with a long title</a></li>
+        <li><a href="/b">Related article number two with a long title</a></li>
+      </ul>
+    </div>
+    <div id="post-body" class="post">
+      <h1>Understanding crawlers</h1>
+      <p>Crawlers fetch pages, extract links, and decide what to visit next, which makes scheduling the core of any crawler.</p>
+      <p>Politeness matters too, because hammering a server with requests is rude, expensive, and often gets a crawler blocked.</p>
+      <p>Finally, content extraction turns noisy markup into text that downstream tools, such as search indexes, can use.</p>
+    </div>
+  </div>
+  <footer>Copyright, legal notice, imprint, and other footer text that should be ignored.</footer>
+</body></html>`
+
+func TestExtractReadable(t *testing.T) {
+	result, ok := extractReadable(readabilityFixture)
+	if !ok {
+		t.Fatalf("expected readable content")
+	}
+	if result.RootPath != "div#post-body" {
+		t.Fatalf("expected post body root, got %q", result.RootPath)
+	}
+	if !strings.HasPrefix(result.Text, "Understanding crawlers\n\nCrawlers fetch pages") {
+		t.Fatalf("unexpected text: %q", result.Text)
+	}
+	for _, noise := range []string{"cookies", "Related article", "Copyright", "tracking", "Home"} {
+		if strings.Contains(result.Text, noise) || strings.Contains(result.HTML, noise) {
+			t.Fatalf("expected %q to be removed, got text %q html %q", noise, result.Text, result.HTML)
+		}
+	}
+	if result.Confidence <= 0.3 || result.Confidence > 1 {
+		t.Fatalf("expected a meaningful confidence, got %v", result.Confidence)
+	}
+}
+
+func TestExtractReadableFallsBackToBody(t *testing.T) {
+	result, ok := extractReadable(`<html><body><span>Short</span></body></html>`)
+	if !ok {
+		t.Fatalf("expected fallback result")
+	}
+	if result.RootPath != "html > body" || result.Text != "Short" || result.Confidence != 0 {
+		t.Fatalf("unexpected fallback result: %+v", result)
+	}
+}
+
+func TestCSSPathUsesNthOfType(t *testing.T) {
+	result, ok := extractReadable(`<html><body>
+		<article><p>Short teaser text only.</p></article>
+		<article><p>This article has enough text, commas, and sentences, so that it wins the scoring by far.</p>
+		<p>Another paragraph keeps adding words, commas, and weight to this second article element.</p></article>
+	</body></html>`)
+	if !ok {
+		t.Fatalf("expected readable content")
+	}
+	if result.RootPath != "html > body > article:nth-of-type(2)" {
+		t.Fatalf("unexpected root path %q", result.RootPath)
+	}
+}
+
+func TestCSSPathCountsPrunedSiblings(t *testing.T) {
+	result, ok := extractReadable(`<html><body>
+		<div class="sidebar"><a href="/a">Related</a> <a href="/b">Popular</a></div>
+		<div><p>This article has enough text, commas, and sentences, so that it wins the scoring by far.</p>
+		<p>Another paragraph keeps adding words, commas, and weight to the article container.</p></div>
+	</body></html>`)
+	if !ok {
+		t.Fatalf("expected readable content")
+	}
+	if result.RootPath != "html > body > div:nth-of-type(2)" {
+		t.Fatalf("unexpected root path %q", result.RootPath)
+	}
+}
+
+func TestParseExtractor(t *testing.T) {
+	if got, err := ParseExtractor(" Readability "); err != nil || got != ExtractorReadability {
+		t.Fatalf("expected readability, got %q (%v)", got, err)
+	}
+	if got, err := ParseExtractor("heuristic"); err != nil || got != ExtractorHeuristic {
+		t.Fatalf("expected heuristic, got %q (%v)", got, err)
+	}
+	if _, err := ParseExtractor("magic"); err == nil {
+		t.Fatalf("expected error for invalid extractor")
+	}
+}
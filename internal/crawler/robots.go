@@ -12,8 +12,9 @@ import (
 )
 
 type robotsEntry struct {
-	loaded bool
-	group  *robotstxt.Group
+	loaded   bool
+	group    *robotstxt.Group
+	sitemaps []string
 }
 
 type robotsCache struct {
@@ -78,7 +79,7 @@ func (rc *robotsCache) getOrLoad(host string) (*robotsEntry, error) {
 	}
 	rc.mu.Unlock()
 
-	group, loadErr := rc.loadGroup(normalizedHost)
+	group, sitemaps, loadErr := rc.loadGroup(normalizedHost)
 	if loadErr != nil {
 		rc.logger.Warn("robots.txt fetch failed; allowing crawl for host", "host", normalizedHost, "error", loadErr)
 	}
@@ -86,14 +87,24 @@ func (rc *robotsCache) getOrLoad(host string) (*robotsEntry, error) {
 	rc.mu.Lock()
 	defer rc.mu.Unlock()
 	updated := &robotsEntry{
-		loaded: true,
-		group:  group,
+		loaded:   true,
+		group:    group,
+		sitemaps: sitemaps,
 	}
 	rc.entries[normalizedHost] = updated
 	return updated, loadErr
 }
 
-func (rc *robotsCache) loadGroup(host string) (*robotstxt.Group, error) {
+// Sitemaps returns the Sitemap URLs listed in the host's robots.txt.
+func (rc *robotsCache) Sitemaps(host string) ([]string, error) {
+	entry, err := rc.getOrLoad(host)
+	if entry == nil {
+		return nil, err
+	}
+	return entry.sitemaps, err
+}
+
+func (rc *robotsCache) loadGroup(host string) (*robotstxt.Group, []string, error) {
 	var lastErr error
 	for _, scheme := range []string{"https", "http"} {
 		robotsURL := scheme + "://" + host + "/robots.txt"
@@ -114,10 +125,10 @@ func (rc *robotsCache) loadGroup(host string) (*robotstxt.Group, error) {
 			lastErr = err
 			continue
 		}
-		return data.FindGroup(rc.userAgent), nil
+		return data.FindGroup(rc.userAgent), data.Sitemaps, nil
 	}
 	if lastErr == nil {
 		lastErr = io.EOF
 	}
-	return nil, lastErr
+	return nil, nil, lastErr
 }
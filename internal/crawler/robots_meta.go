@@ -0,0 +1,94 @@
+package crawler
+
+import (
+	"sort"
+	"strings"
+)
+
+const (
+	// RobotsNoIndex asks crawlers not to index (write) a page.
+	RobotsNoIndex = "noindex"
+	// RobotsNoFollow asks crawlers not to follow links from a page or anchor.
+	RobotsNoFollow = "nofollow"
+)
+
+// robotsValueDirectives are directives that carry a value after a colon and
+// must not be mistaken for a user-agent prefix in X-Robots-Tag headers.
+var robotsValueDirectives = map[string]struct{}{
+	"unavailable_after": {},
+	"max-snippet":       {},
+	"max-image-preview": {},
+	"max-video-preview": {},
+}
+
+// parseRobotsDirectives merges meta robots contents and X-Robots-Tag header
+// values into a sorted, de-duplicated list of lowercase directives.
+//
+// Values scoped to a named crawler ("googlebot: noindex") are ignored because
+// they do not address generic crawlers. "none" expands to noindex + nofollow.
+func parseRobotsDirectives(values []string) []string {
+	set := map[string]struct{}{}
+	for _, value := range values {
+		value = strings.ToLower(strings.TrimSpace(value))
+		if value == "" {
+			continue
+		}
+		if name, rest, found := strings.Cut(value, ":"); found && !strings.Contains(name, ",") {
+			name = strings.TrimSpace(name)
+			if _, isDirective := robotsValueDirectives[name]; !isDirective {
+				if name != "*" && name != "robots" {
+					continue
+				}
+				value = rest
+			}
+		}
+		for _, token := range strings.Split(value, ",") {
+			token = strings.TrimSpace(token)
+			if token == "" {
+				continue
+			}
+			if name, _, found := strings.Cut(token, ":"); found {
+				// A user-agent scope inside a list ("noindex, googlebot: nofollow")
+				// applies to that crawler only.
+				token = strings.TrimSpace(name)
+				if _, isDirective := robotsValueDirectives[token]; !isDirective {
+					continue
+				}
+			}
+			if token == "none" {
+				set[RobotsNoIndex] = struct{}{}
+				set[RobotsNoFollow] = struct{}{}
+			}
+			set[token] = struct{}{}
+		}
+	}
+	if len(set) == 0 {
+		return nil
+	}
+	directives := make([]string, 0, len(set))
+	for directive := range set {
+		directives = append(directives, directive)
+	}
+	sort.Strings(directives)
+	return directives
+}
+
+// hasRobotsDirective reports whether directive is present in directives.
+func hasRobotsDirective(directives []string, directive string) bool {
+	for _, candidate := range directives {
+		if candidate == directive {
+			return true
+		}
+	}
+	return false
+}
+
+// isNofollowRel reports whether an anchor rel attribute contains nofollow.
+func isNofollowRel(rel string) bool {
+	for _, token := range strings.Fields(strings.ToLower(rel)) {
+		if token == RobotsNoFollow {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,39 @@
+package crawler
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseRobotsDirectives(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []string
+		want   []string
+	}{
+		{"empty", nil, nil},
+		{"meta", []string{"NoIndex, Follow"}, []string{"follow", "noindex"}},
+		{"none expands", []string{"none"}, []string{"nofollow", "noindex", "none"}},
+		{"merged", []string{"noarchive", "nofollow"}, []string{"noarchive", "nofollow"}},
+		{"named crawler ignored", []string{"googlebot: noindex"}, nil},
+		{"value directive kept", []string{"unavailable_after: 25 Jun 2010 15:00:00 PST"}, []string{"unavailable_after"}},
+		{"trailing value directive", []string{"noindex, max-snippet:50"}, []string{"max-snippet", "noindex"}},
+		{"named crawler in list dropped", []string{"noindex, googlebot: nofollow"}, []string{"noindex"}},
+	}
+
+	for _, tt := range tests {
+		got := parseRobotsDirectives(tt.values)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestIsNofollowRel(t *testing.T) {
+	if !isNofollowRel("noopener NoFollow") {
+		t.Fatalf("expected nofollow rel to be detected")
+	}
+	if isNofollowRel("noopener noreferrer") {
+		t.Fatalf("expected rel without nofollow to be ignored")
+	}
+}
@@ -0,0 +1,13 @@
+package crawler
+
+// PageSink receives crawl output while the crawl runs, so pages reach disk as
+// soon as they are finished instead of after Crawl returns.
+//
+// Begin is called once with the crawl metadata before the first page.
+// WritePage is called for every page, in crawl order, with Totals already
+// counting that page. Scores and translation sets are only known once Crawl
+// returns. A sink error stops the crawl and is returned by Crawl.
+type PageSink interface {
+	Begin(result *CrawlResult) error
+	WritePage(page *Page) error
+}
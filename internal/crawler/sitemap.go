@@ -0,0 +1,299 @@
+package crawler
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// SitemapAuto discovers sitemaps from the start host's robots.txt, falling
+// back to /sitemap.xml.
+const SitemapAuto = "auto"
+
+// maxSitemapFiles bounds how many sitemap files, including those reached
+// through sitemap indexes, are fetched per crawl.
+const maxSitemapFiles = 100
+
+// sitemapBodyLimit is the protocol's maximum uncompressed sitemap size.
+const sitemapBodyLimit = 50 << 20
+
+// ParseSitemaps validates a comma-separated sitemap flag value: "auto" or
+// absolute http(s) sitemap URLs. An empty value disables sitemap coverage.
+func ParseSitemaps(raw string) ([]string, error) {
+	var sources []string
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if strings.EqualFold(part, SitemapAuto) {
+			sources = append(sources, SitemapAuto)
+			continue
+		}
+		parsed, err := url.Parse(part)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return nil, fmt.Errorf("invalid sitemap %q (allowed: auto or an http(s) URL)", part)
+		}
+		sources = append(sources, part)
+	}
+	return sources, nil
+}
+
+// SitemapError is a sitemap file that could not be fetched or parsed.
+type SitemapError struct {
+	URL   string
+	Error string
+}
+
+// SitemapIssue is a sitemap URL whose crawl redirected or did not succeed.
+type SitemapIssue struct {
+	URL      string
+	FinalURL string
+	Status   string
+	Error    string
+}
+
+// SitemapCoverage compares the URLs listed in sitemaps with what link
+// discovery reached during the crawl.
+type SitemapCoverage struct {
+	// Sitemaps lists the sitemap files read, including those found through
+	// sitemap indexes.
+	Sitemaps []string
+	Errors   []SitemapError
+	// URLs counts the distinct in-scope sitemap URLs; OutOfScope counts the
+	// listed URLs on other hosts, which are ignored.
+	URLs       int
+	OutOfScope int
+	Crawled    int
+	// Orphans are sitemap URLs no crawled page links to.
+	Orphans []string
+	// Uncrawled are sitemap URLs the crawl did not reach.
+	Uncrawled []string
+	// NotInSitemap are successfully crawled pages missing from the sitemaps.
+	NotInSitemap []string
+	Redirects    []SitemapIssue
+	Failures     []SitemapIssue
+	// Depths counts the crawled sitemap URLs per crawl depth.
+	Depths map[int]int
+}
+
+// sitemapLoader fetches sitemaps and follows sitemap indexes.
+type sitemapLoader struct {
+	client    *http.Client
+	userAgent string
+	logger    *slog.Logger
+}
+
+type sitemapDocument struct {
+	XMLName  xml.Name
+	URLs     []sitemapLoc `xml:"url"`
+	Sitemaps []sitemapLoc `xml:"sitemap"`
+}
+
+type sitemapLoc struct {
+	Loc string `xml:"loc"`
+}
+
+// load reads every sitemap reachable from sources and returns the listed
+// page URLs in document order, with the sitemap files read and those that
+// failed.
+func (sl *sitemapLoader) load(ctx context.Context, sources []string) (urls, read []string, failed []SitemapError) {
+	queue := append([]string(nil), sources...)
+	seen := map[string]struct{}{}
+	for len(queue) > 0 && ctx.Err() == nil {
+		source := queue[0]
+		queue = queue[1:]
+		if _, ok := seen[source]; ok {
+			continue
+		}
+		seen[source] = struct{}{}
+		if len(read)+len(failed) >= maxSitemapFiles {
+			sl.logger.Warn("sitemap limit reached", "limit", maxSitemapFiles, "skipped", source)
+			break
+		}
+
+		doc, err := sl.fetch(ctx, source)
+		if err != nil {
+			sl.logger.Warn("sitemap fetch failed", "url", source, "error", err)
+			failed = append(failed, SitemapError{URL: source, Error: err.Error()})
+			continue
+		}
+		read = append(read, source)
+		for _, child := range doc.Sitemaps {
+			if loc := strings.TrimSpace(child.Loc); loc != "" {
+				queue = append(queue, loc)
+			}
+		}
+		for _, entry := range doc.URLs {
+			if loc := strings.TrimSpace(entry.Loc); loc != "" {
+				urls = append(urls, loc)
+			}
+		}
+	}
+	return urls, read, failed
+}
+
+// fetch downloads one sitemap, decompressing gzip bodies, and parses it as a
+// urlset or sitemapindex document.
+func (sl *sitemapLoader) fetch(ctx context.Context, source string) (*sitemapDocument, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", sl.userAgent)
+	resp, err := sl.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	body := bufio.NewReader(resp.Body)
+	var reader io.Reader = body
+	if magic, _ := body.Peek(2); bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		reader = gz
+	}
+	var doc sitemapDocument
+	if err := xml.NewDecoder(io.LimitReader(reader, sitemapBodyLimit)).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("parse sitemap: %w", err)
+	}
+	if doc.XMLName.Local != "urlset" && doc.XMLName.Local != "sitemapindex" {
+		return nil, fmt.Errorf("parse sitemap: unexpected root element <%s>", doc.XMLName.Local)
+	}
+	return &doc, nil
+}
+
+// sitemapSources resolves SitemapAuto against the start URL's host.
+func sitemapSources(sources []string, startURL string, robots *robotsCache, logger *slog.Logger) []string {
+	var resolved []string
+	for _, source := range sources {
+		if source != SitemapAuto {
+			resolved = append(resolved, source)
+			continue
+		}
+		start, err := url.Parse(startURL)
+		if err != nil {
+			continue
+		}
+		listed, err := robots.Sitemaps(start.Hostname())
+		if err != nil {
+			logger.Debug("robots.txt sitemaps unavailable", "host", start.Hostname(), "error", err)
+		}
+		if len(listed) == 0 {
+			listed = []string{start.Scheme + "://" + start.Host + "/sitemap.xml"}
+		}
+		resolved = append(resolved, listed...)
+	}
+	return resolved
+}
+
+// scopeSitemapURLs normalizes sitemap URLs like discovered links and keeps
+// those inside the crawl scope.
+func scopeSitemapURLs(urls []string, scope Scope, clean bool) (inScope []string, outOfScope int) {
+	for _, raw := range urls {
+		normalized, err := NormalizeURL(raw, clean)
+		if err != nil || !scope.IsAllowedURL(normalized) {
+			outOfScope++
+			continue
+		}
+		inScope = append(inScope, normalized)
+	}
+	return inScope, outOfScope
+}
+
+// buildSitemapCoverage matches normalized, in-scope sitemap URLs against the
+// crawled pages and the internal link graph. A sitemap URL is crawled when a
+// page was requested at it or redirected to it.
+func buildSitemapCoverage(sitemapURLs []string, pages []*Page, graph *LinkGraph) *SitemapCoverage {
+	coverage := &SitemapCoverage{
+		Orphans:      []string{},
+		Uncrawled:    []string{},
+		NotInSitemap: []string{},
+		Redirects:    []SitemapIssue{},
+		Failures:     []SitemapIssue{},
+		Depths:       map[int]int{},
+	}
+
+	listed := map[string]struct{}{}
+	for _, u := range sitemapURLs {
+		listed[u] = struct{}{}
+	}
+	coverage.URLs = len(listed)
+
+	linked := map[string]struct{}{}
+	for _, edge := range graph.Edges() {
+		if edge.From != edge.To {
+			linked[edge.To] = struct{}{}
+		}
+	}
+
+	byURL := map[string]*Page{}
+	byFinal := map[string]*Page{}
+	for _, page := range pages {
+		if _, ok := byURL[page.URL]; !ok {
+			byURL[page.URL] = page
+		}
+		if _, ok := byFinal[page.FinalURL]; !ok && page.FinalURL != "" {
+			byFinal[page.FinalURL] = page
+		}
+		if page.Status != StatusOK {
+			continue
+		}
+		_, urlListed := listed[page.URL]
+		_, finalListed := listed[page.FinalURL]
+		if !urlListed && !finalListed {
+			coverage.NotInSitemap = append(coverage.NotInSitemap, page.FinalURL)
+		}
+	}
+
+	for u := range listed {
+		if _, ok := linked[u]; !ok {
+			coverage.Orphans = append(coverage.Orphans, u)
+		}
+		page, ok := byURL[u]
+		if !ok {
+			page, ok = byFinal[u]
+		}
+		if !ok {
+			coverage.Uncrawled = append(coverage.Uncrawled, u)
+			continue
+		}
+		coverage.Crawled++
+		coverage.Depths[page.Depth]++
+		issue := SitemapIssue{URL: u, FinalURL: page.FinalURL, Status: page.Status, Error: page.Error}
+		if page.URL == u && page.FinalURL != "" && page.FinalURL != u {
+			coverage.Redirects = append(coverage.Redirects, issue)
+		}
+		if page.Status != StatusOK {
+			coverage.Failures = append(coverage.Failures, issue)
+		}
+	}
+
+	sort.Strings(coverage.Orphans)
+	sort.Strings(coverage.Uncrawled)
+	sort.Strings(coverage.NotInSitemap)
+	coverage.NotInSitemap = slices.Compact(coverage.NotInSitemap)
+	sortIssues := func(issues []SitemapIssue) {
+		sort.Slice(issues, func(i, j int) bool { return issues[i].URL < issues[j].URL })
+	}
+	sortIssues(coverage.Redirects)
+	sortIssues(coverage.Failures)
+	return coverage
+}
@@ -0,0 +1,134 @@
+package crawler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseSitemaps(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    []string
+		wantErr bool
+	}{
+		{raw: "", want: nil},
+		{raw: " AUTO ", want: []string{SitemapAuto}},
+		{raw: "auto, https://example.com/news.xml", want: []string{SitemapAuto, "https://example.com/news.xml"}},
+		{raw: "/sitemap.xml", wantErr: true},
+		{raw: "ftp://example.com/sitemap.xml", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseSitemaps(tt.raw)
+		if (err != nil) != tt.wantErr || !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("ParseSitemaps(%q) = %v, %v", tt.raw, got, err)
+		}
+	}
+}
+
+func TestSitemapLoaderFollowsIndexes(t *testing.T) {
+	var gzipped bytes.Buffer
+	gz := gzip.NewWriter(&gzipped)
+	_, _ = gz.Write([]byte(`<?xml version="1.0"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>https://example.com/b</loc></url>
+</urlset>`))
+	_ = gz.Close()
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/sitemap.xml":
+			_, _ = w.Write([]byte(`<?xml version="1.0"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>` + server.URL + `/pages.xml</loc></sitemap>
+  <sitemap><loc>` + server.URL + `/more.xml.gz</loc></sitemap>
+  <sitemap><loc>` + server.URL + `/missing.xml</loc></sitemap>
+  <sitemap><loc>` + server.URL + `/sitemap.xml</loc></sitemap>
+</sitemapindex>`))
+		case "/pages.xml":
+			_, _ = w.Write([]byte(`<urlset><url><loc> https://example.com/a </loc></url><url><loc></loc></url></urlset>`))
+		case "/more.xml.gz":
+			_, _ = w.Write(gzipped.Bytes())
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	loader := &sitemapLoader{client: &http.Client{Timeout: 5 * time.Second}, userAgent: "sitecrawl-test", logger: slog.Default()}
+	urls, read, failed := loader.load(context.Background(), []string{server.URL + "/sitemap.xml"})
+	if !reflect.DeepEqual(urls, []string{"https://example.com/a", "https://example.com/b"}) {
+		t.Fatalf("unexpected urls: %v", urls)
+	}
+	if !reflect.DeepEqual(read, []string{server.URL + "/sitemap.xml", server.URL + "/pages.xml", server.URL + "/more.xml.gz"}) {
+		t.Fatalf("unexpected sitemaps read: %v", read)
+	}
+	if len(failed) != 1 || failed[0].URL != server.URL+"/missing.xml" || failed[0].Error == "" {
+		t.Fatalf("unexpected failures: %+v", failed)
+	}
+}
+
+func TestScopeSitemapURLs(t *testing.T) {
+	scope, err := NewScope("example.com")
+	if err != nil {
+		t.Fatalf("NewScope: %v", err)
+	}
+	inScope, outOfScope := scopeSitemapURLs([]string{"https://EXAMPLE.com/a#top", "https://other.org/", "https://blog.example.com/"}, scope, true)
+	if !reflect.DeepEqual(inScope, []string{"https://example.com/a"}) || outOfScope != 2 {
+		t.Fatalf("unexpected scoping: %v, %d", inScope, outOfScope)
+	}
+}
+
+func TestBuildSitemapCoverage(t *testing.T) {
+	graph := NewLinkGraph()
+	graph.AddEdge("https://example.com/", "https://example.com/a")
+	graph.AddEdge("https://example.com/", "https://example.com/old")
+	graph.AddEdge("https://example.com/a", "https://example.com/broken")
+	graph.AddEdge("https://example.com/a", "https://example.com/a")
+	graph.AddEdge("https://example.com/a", "https://example.com/extra")
+	pages := []*Page{
+		{URL: "https://example.com/", FinalURL: "https://example.com/", Depth: 0, Status: StatusOK},
+		{URL: "https://example.com/a", FinalURL: "https://example.com/a", Depth: 1, Status: StatusOK},
+		{URL: "https://example.com/old", FinalURL: "https://example.com/new", Depth: 1, Status: StatusOK},
+		{URL: "https://example.com/broken", FinalURL: "https://example.com/broken", Depth: 2, Status: StatusError, Error: "net::ERR_FAILED"},
+		{URL: "https://example.com/extra", FinalURL: "https://example.com/extra", Depth: 2, Status: StatusOK},
+	}
+	listed := []string{
+		"https://example.com/",
+		"https://example.com/a",
+		"https://example.com/a",
+		"https://example.com/old",
+		"https://example.com/broken",
+		"https://example.com/lonely",
+	}
+
+	got := buildSitemapCoverage(listed, pages, graph)
+	if got.URLs != 5 || got.Crawled != 4 {
+		t.Fatalf("unexpected counts: %+v", got)
+	}
+	if !reflect.DeepEqual(got.Orphans, []string{"https://example.com/", "https://example.com/lonely"}) {
+		t.Fatalf("unexpected orphans: %v", got.Orphans)
+	}
+	if !reflect.DeepEqual(got.Uncrawled, []string{"https://example.com/lonely"}) {
+		t.Fatalf("unexpected uncrawled: %v", got.Uncrawled)
+	}
+	if !reflect.DeepEqual(got.NotInSitemap, []string{"https://example.com/extra"}) {
+		t.Fatalf("unexpected not in sitemap: %v", got.NotInSitemap)
+	}
+	if !reflect.DeepEqual(got.Redirects, []SitemapIssue{{URL: "https://example.com/old", FinalURL: "https://example.com/new", Status: StatusOK}}) {
+		t.Fatalf("unexpected redirects: %+v", got.Redirects)
+	}
+	if !reflect.DeepEqual(got.Failures, []SitemapIssue{{URL: "https://example.com/broken", FinalURL: "https://example.com/broken", Status: StatusError, Error: "net::ERR_FAILED"}}) {
+		t.Fatalf("unexpected failures: %+v", got.Failures)
+	}
+	if !reflect.DeepEqual(got.Depths, map[int]int{0: 1, 1: 2, 2: 1}) {
+		t.Fatalf("unexpected depths: %v", got.Depths)
+	}
+}
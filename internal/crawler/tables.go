@@ -0,0 +1,278 @@
+package crawler
+
+import (
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// maxTableSpan caps colspan/rowspan so malformed markup cannot blow up a grid.
+const maxTableSpan = 100
+
+// Table is one <table> in a page's main content, flattened to a grid.
+// Spanned cells repeat their value in every row and column they cover.
+type Table struct {
+	Caption string
+	Header  []string
+	Rows    [][]string
+}
+
+// Columns returns the grid width.
+func (t Table) Columns() int {
+	width := len(t.Header)
+	for _, row := range t.Rows {
+		width = max(width, len(row))
+	}
+	return width
+}
+
+// extractTables collects tables from mainHTML in document order. Header rows
+// come from <thead>, or from a leading row made only of <th> cells; several
+// header rows are merged per column. Nested tables are extracted on their own
+// and do not contribute cells to their parent. Empty body rows and tables
+// without any cells are dropped.
+func extractTables(mainHTML string) []Table {
+	if strings.TrimSpace(mainHTML) == "" {
+		return nil
+	}
+	doc, err := html.Parse(strings.NewReader(mainHTML))
+	if err != nil {
+		return nil
+	}
+
+	var tables []Table
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.DataAtom == atom.Table {
+			if table, ok := tableFromNode(n); ok {
+				tables = append(tables, table)
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(doc)
+	return tables
+}
+
+// TableLayout is a <table> element laid out on a grid. Cells holds the
+// source cell of every grid position: a spanned cell fills every row and
+// column it covers, and positions no cell reaches are nil. HeaderRows counts
+// the leading rows that form the header: the <thead> rows, or a leading row
+// made only of <th> cells.
+type TableLayout struct {
+	Caption    *html.Node
+	Cells      [][]*html.Node
+	HeaderRows int
+}
+
+// LayoutTable lays out the rows of table, honoring colspan and rowspan.
+// Nested tables do not contribute rows.
+func LayoutTable(table *html.Node) TableLayout {
+	var layout TableLayout
+	var rows [][]*html.Node
+	var header []bool
+	var collect func(*html.Node, bool)
+	collect = func(parent *html.Node, inHead bool) {
+		for child := parent.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode {
+				continue
+			}
+			switch child.DataAtom {
+			case atom.Caption:
+				if layout.Caption == nil {
+					layout.Caption = child
+				}
+			case atom.Thead:
+				collect(child, true)
+			case atom.Tbody, atom.Tfoot:
+				collect(child, false)
+			case atom.Tr:
+				var cells []*html.Node
+				for cell := child.FirstChild; cell != nil; cell = cell.NextSibling {
+					if cell.Type == html.ElementNode && (cell.DataAtom == atom.Td || cell.DataAtom == atom.Th) {
+						cells = append(cells, cell)
+					}
+				}
+				rows = append(rows, cells)
+				header = append(header, inHead)
+			}
+		}
+	}
+	collect(table, false)
+
+	layout.Cells = tableGrid(rows)
+	for layout.HeaderRows < len(rows) && header[layout.HeaderRows] {
+		layout.HeaderRows++
+	}
+	if layout.HeaderRows == 0 && len(rows) > 1 && allHeaderCells(rows[0]) {
+		layout.HeaderRows = 1
+	}
+	return layout
+}
+
+// Grid renders every cell of the layout with text, once per source cell.
+// Positions without a cell are empty.
+func (l TableLayout) Grid(text func(*html.Node) string) [][]string {
+	rendered := map[*html.Node]string{}
+	grid := make([][]string, len(l.Cells))
+	for r, row := range l.Cells {
+		grid[r] = make([]string, len(row))
+		for c, cell := range row {
+			if cell == nil {
+				continue
+			}
+			value, ok := rendered[cell]
+			if !ok {
+				value = text(cell)
+				rendered[cell] = value
+			}
+			grid[r][c] = value
+		}
+	}
+	return grid
+}
+
+func tableFromNode(n *html.Node) (Table, bool) {
+	layout := LayoutTable(n)
+	var table Table
+	if layout.Caption != nil {
+		table.Caption = tableCellText(layout.Caption)
+	}
+	grid := layout.Grid(tableCellText)
+	if layout.HeaderRows > 0 {
+		table.Header = MergeHeaderRows(grid[:layout.HeaderRows])
+	}
+	for _, row := range grid[layout.HeaderRows:] {
+		if strings.Join(row, "") != "" {
+			table.Rows = append(table.Rows, row)
+		}
+	}
+	if len(table.Header) == 0 && len(table.Rows) == 0 {
+		return Table{}, false
+	}
+	return table, true
+}
+
+// tableGrid places cells on a grid, honoring colspan and rowspan. Every row
+// is padded to the same width.
+func tableGrid(rows [][]*html.Node) [][]*html.Node {
+	grid := make([][]*html.Node, len(rows))
+	filled := make([][]bool, len(rows))
+	set := func(r, c int, cell *html.Node) {
+		for len(grid[r]) <= c {
+			grid[r] = append(grid[r], nil)
+			filled[r] = append(filled[r], false)
+		}
+		grid[r][c] = cell
+		filled[r][c] = true
+	}
+
+	for r, cells := range rows {
+		col := 0
+		for _, cell := range cells {
+			for col < len(filled[r]) && filled[r][col] {
+				col++
+			}
+			colspan := tableSpan(cell, "colspan")
+			rowspan := tableSpan(cell, "rowspan")
+			if rowspan == 0 || r+rowspan > len(rows) {
+				rowspan = len(rows) - r
+			}
+			for dr := 0; dr < rowspan; dr++ {
+				for dc := 0; dc < colspan; dc++ {
+					set(r+dr, col+dc, cell)
+				}
+			}
+			col += colspan
+		}
+	}
+
+	width := 0
+	for _, row := range grid {
+		width = max(width, len(row))
+	}
+	for r := range grid {
+		for len(grid[r]) < width {
+			grid[r] = append(grid[r], nil)
+		}
+	}
+	return grid
+}
+
+// tableSpan reads colspan/rowspan. Missing or invalid values count as 1;
+// rowspan="0" (span to the end of the section) is returned as 0.
+func tableSpan(cell *html.Node, key string) int {
+	span, err := strconv.Atoi(strings.TrimSpace(attr(cell, key)))
+	switch {
+	case err != nil || span < 0:
+		return 1
+	case span == 0 && key == "rowspan":
+		return 0
+	case span == 0:
+		return 1
+	}
+	return min(span, maxTableSpan)
+}
+
+// MergeHeaderRows merges header rows per column into "Group / Column",
+// skipping empty values and values repeated by a span.
+func MergeHeaderRows(rows [][]string) []string {
+	if len(rows) == 1 {
+		return rows[0]
+	}
+	merged := make([]string, len(rows[0]))
+	for c := range merged {
+		var parts []string
+		for _, row := range rows {
+			value := row[c]
+			if value != "" && (len(parts) == 0 || parts[len(parts)-1] != value) {
+				parts = append(parts, value)
+			}
+		}
+		merged[c] = strings.Join(parts, " / ")
+	}
+	return merged
+}
+
+func allHeaderCells(cells []*html.Node) bool {
+	if len(cells) == 0 {
+		return false
+	}
+	for _, cell := range cells {
+		if cell.DataAtom != atom.Th {
+			return false
+		}
+	}
+	return true
+}
+
+// tableCellText returns the whitespace-normalized text of a cell, skipping
+// nested tables, scripts, and styles.
+func tableCellText(n *html.Node) string {
+	var builder strings.Builder
+	var walk func(*html.Node)
+	walk = func(node *html.Node) {
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			switch {
+			case child.Type == html.TextNode:
+				builder.WriteString(child.Data)
+			case child.Type != html.ElementNode:
+			case child.DataAtom == atom.Table || child.DataAtom == atom.Script || child.DataAtom == atom.Style:
+			case child.DataAtom == atom.Br:
+				builder.WriteString(" ")
+			case readabilityBlockTags[child.DataAtom] || child.DataAtom == atom.Li:
+				builder.WriteString(" ")
+				walk(child)
+				builder.WriteString(" ")
+			default:
+				walk(child)
+			}
+		}
+	}
+	walk(n)
+	return strings.Join(strings.Fields(builder.String()), " ")
+}
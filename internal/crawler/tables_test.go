@@ -0,0 +1,61 @@
+package crawler
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractTables(t *testing.T) {
+	mainHTML := `
+		<table>
+			<caption> Plan   prices </caption>
+			<thead>
+				<tr><th rowspan="2">Plan</th><th colspan="2">Price</th></tr>
+				<tr><th>Monthly</th><th>Yearly</th></tr>
+			</thead>
+			<tbody>
+				<tr><td>Basic</td><td rowspan="2">$5</td><td>$50</td></tr>
+				<tr><td>Pro <b>plus</b></td><td>$90</td></tr>
+				<tr><td></td></tr>
+			</tbody>
+		</table>
+		<table>
+			<tr><th>Name</th><th>Type</th></tr>
+			<tr><td>id<table><tr><td>inner</td></tr></table></td><td>int</td></tr>
+		</table>
+		<table><tr></tr></table>
+	`
+
+	got := extractTables(mainHTML)
+	want := []Table{
+		{
+			Caption: "Plan prices",
+			Header:  []string{"Plan", "Price / Monthly", "Price / Yearly"},
+			Rows: [][]string{
+				{"Basic", "$5", "$50"},
+				{"Pro plus", "$5", "$90"},
+			},
+		},
+		{
+			Header: []string{"Name", "Type"},
+			Rows:   [][]string{{"id", "int"}},
+		},
+		{
+			Rows: [][]string{{"inner"}},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+	if got[0].Columns() != 3 {
+		t.Fatalf("expected 3 columns, got %d", got[0].Columns())
+	}
+}
+
+func TestExtractTablesWithoutHeader(t *testing.T) {
+	got := extractTables(`<table><tr><td>a</td><td colspan="2">b</td></tr><tr><td>c</td></tr></table>`)
+	want := []Table{{Rows: [][]string{{"a", "b", "b"}, {"c", "", ""}}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+}
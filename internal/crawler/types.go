@@ -10,7 +10,7 @@ import (
 const DefaultUserAgent = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36"
 
 // PageRankImplementation identifies the rank engine used in report metadata.
-const PageRankImplementation = "pkg/pagerank.NewPageRank + (*PageRank).CalcPageRank"
+const PageRankImplementation = "pkg/pagerank.NewPageRank + (*PageRank).Run"
 
 // Strategy controls URL selection behavior during crawling.
 type Strategy string
@@ -40,15 +40,29 @@ func ParseStrategy(raw string) (Strategy, error) {
 
 // Config contains all crawl runtime configuration.
 type Config struct {
-	Domain      string
-	Strategy    Strategy
-	MaxPages    int
-	MaxDepth    int
-	Clean       bool
-	Headful     bool
-	Delay       time.Duration
-	PageTimeout time.Duration
-	UserAgent   string
+	Domain            string
+	Strategy          Strategy
+	MaxPages          int
+	MaxDepth          int
+	Clean             bool
+	Extractor         Extractor
+	Headful           bool
+	Delay             time.Duration
+	PageTimeout       time.Duration
+	UserAgent         string
+	RespectMetaRobots bool
+	RespectAIOptOut   bool
+	Languages         []string
+	FieldRules        []FieldRule
+	CaptureHTTP       bool
+	// CheckLinks validates the links found on crawled pages once the crawl
+	// ends, checking up to LinkCheckConcurrency hosts at once.
+	CheckLinks           LinkCheckMode
+	LinkCheckConcurrency int
+	// Sitemaps lists sitemap URLs, or SitemapAuto, to compare with the
+	// crawled pages once the crawl ends.
+	Sitemaps []string
+	Sink     PageSink
 }
 
 // Totals tracks crawl counters for report generation.
@@ -57,24 +71,45 @@ type Totals struct {
 	Errors            int
 	SkippedExternal   int
 	SkippedOutOfScope int
+	SkippedNoIndex    int
+	SkippedNofollow   int
+	SkippedAIOptOut   int
+	SkippedLanguage   int
 }
 
 // Page stores extracted and output metadata for a crawled URL.
 type Page struct {
-	URL         string
-	FinalURL    string
-	Depth       int
-	Status      string
-	Title       string
-	Description string
-	Links       []string
-	MainText    string
-	MainHTML    string
-	BodyHTML    string
-	RawHTML     string
-	Error       string
-	OutPath     string
-	Score       *float64
+	URL               string
+	FinalURL          string
+	Depth             int
+	Status            string
+	Title             string
+	Description       string
+	Robots            []string
+	AISignals         []string
+	Language          string
+	DeclaredLanguage  string
+	ContentLanguage   string
+	DetectedLanguage  string
+	Alternates        []Alternate
+	H1                []string
+	Canonical         string
+	WordCount         int
+	Links             []string
+	ExternalLinks     []ExternalLink
+	Images            []Image
+	Tables            []Table
+	Fields            []FieldValue
+	ContentRoot       string
+	ContentConfidence *float64
+	MainText          string
+	MainHTML          string
+	BodyHTML          string
+	RawHTML           string
+	Capture           *HTTPCapture
+	Error             string
+	OutPath           string
+	Score             *float64
 }
 
 // CrawlResult is the complete crawl output before serialization.
@@ -86,11 +121,23 @@ type CrawlResult struct {
 	Strategy               Strategy
 	MaxPages               int
 	MaxDepth               int
+	FieldNames             []string
 	Clean                  bool
+	Extractor              Extractor
 	Headful                bool
 	PageRankImplementation string
-	Pages                  []*Page
-	Totals                 Totals
+	AIPolicies             []HostAIPolicy
+	TranslationSets        []TranslationSet
+	// LinkCheck is set when Config.CheckLinks is enabled and the crawl
+	// finished.
+	LinkCheck *LinkCheck
+	// SitemapCoverage is set when Config.Sitemaps is non-empty and the crawl
+	// finished.
+	SitemapCoverage *SitemapCoverage
+	// LinkGraph holds the internal links of every crawled page.
+	LinkGraph *LinkGraph
+	Pages     []*Page
+	Totals    Totals
 }
 
 const (
@@ -102,4 +149,10 @@ const (
 	StatusSkippedRobots = "skipped_robots"
 	// StatusSkippedOutOfHost indicates redirection or resolution escaped scope.
 	StatusSkippedOutOfHost = "skipped_out_of_scope"
+	// StatusSkippedNoIndex indicates meta robots or X-Robots-Tag asked for noindex.
+	StatusSkippedNoIndex = "skipped_noindex"
+	// StatusSkippedAIOptOut indicates the publisher reserved the page against AI/TDM use.
+	StatusSkippedAIOptOut = "skipped_ai_optout"
+	// StatusSkippedLanguage indicates the page language is excluded by the language filter.
+	StatusSkippedLanguage = "skipped_language"
 )
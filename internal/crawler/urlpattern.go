@@ -0,0 +1,43 @@
+package crawler
+
+import (
+	"regexp"
+	"strings"
+)
+
+// URLPattern is a URL glob. Patterns starting with "/" match the URL path
+// (plus query); other patterns match the full URL. "*" matches any run of
+// characters and patterns are anchored at both ends.
+type URLPattern struct {
+	pathOnly bool
+	re       *regexp.Regexp
+}
+
+// CompileURLPattern compiles a URL glob.
+func CompileURLPattern(pattern string) URLPattern {
+	parts := strings.Split(pattern, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	return URLPattern{
+		pathOnly: strings.HasPrefix(pattern, "/"),
+		re:       regexp.MustCompile("^" + strings.Join(parts, ".*") + "$"),
+	}
+}
+
+// Match reports whether rawURL matches the pattern.
+func (p URLPattern) Match(rawURL string) bool {
+	if !p.pathOnly {
+		return p.re.MatchString(rawURL)
+	}
+	pathAndQuery := rawURL
+	if idx := strings.Index(rawURL, "://"); idx >= 0 {
+		rest := rawURL[idx+3:]
+		if slash := strings.Index(rest, "/"); slash >= 0 {
+			pathAndQuery = rest[slash:]
+		} else {
+			pathAndQuery = "/"
+		}
+	}
+	return p.re.MatchString(pathAndQuery)
+}
@@ -0,0 +1,139 @@
+package output
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// fileSink receives output files by slash-separated name relative to the
+// output root.
+type fileSink interface {
+	WriteFile(name string, data []byte) error
+}
+
+// dirSink writes files below a directory, creating parent directories.
+type dirSink string
+
+func (d dirSink) WriteFile(name string, data []byte) error {
+	fullPath := filepath.Join(string(d), filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(fullPath, data, 0o644)
+}
+
+// ArchiveFormat selects the container for archive output.
+type ArchiveFormat string
+
+const (
+	// ArchiveTarZstd writes a zstd-compressed tar archive.
+	ArchiveTarZstd ArchiveFormat = "tar.zst"
+	// ArchiveTarGzip writes a gzip-compressed tar archive.
+	ArchiveTarGzip ArchiveFormat = "tar.gz"
+	// ArchiveZip writes a deflate-compressed zip archive.
+	ArchiveZip ArchiveFormat = "zip"
+)
+
+// ParseArchiveFormat validates and normalizes the archive flag.
+func ParseArchiveFormat(raw string) (ArchiveFormat, error) {
+	switch ArchiveFormat(strings.TrimPrefix(strings.ToLower(strings.TrimSpace(raw)), ".")) {
+	case ArchiveTarZstd:
+		return ArchiveTarZstd, nil
+	case ArchiveTarGzip, "tgz":
+		return ArchiveTarGzip, nil
+	case ArchiveZip:
+		return ArchiveZip, nil
+	default:
+		return "", fmt.Errorf("invalid archive format %q (allowed: tar.zst, tar.gz, zip)", raw)
+	}
+}
+
+// archiveModTime is stamped on every entry so that identical output gives
+// byte-identical archives. It is the earliest time a zip entry can carry.
+var archiveModTime = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// archiveSink streams files into a tar or zip archive as they are written.
+// Entries appear in write order, which is deterministic for a given crawl.
+type archiveSink struct {
+	tar        *tar.Writer
+	zip        *zip.Writer
+	compressor io.WriteCloser
+}
+
+func newArchiveSink(w io.Writer, format ArchiveFormat) (*archiveSink, error) {
+	switch format {
+	case ArchiveTarZstd:
+		encoder, err := zstd.NewWriter(w, zstd.WithEncoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		return &archiveSink{tar: tar.NewWriter(encoder), compressor: encoder}, nil
+	case ArchiveTarGzip:
+		compressor := gzip.NewWriter(w)
+		return &archiveSink{tar: tar.NewWriter(compressor), compressor: compressor}, nil
+	case ArchiveZip:
+		archive := zip.NewWriter(w)
+		archive.RegisterCompressor(zip.Deflate, func(out io.Writer) (io.WriteCloser, error) {
+			return flate.NewWriter(out, flate.DefaultCompression)
+		})
+		return &archiveSink{zip: archive}, nil
+	default:
+		return nil, fmt.Errorf("unsupported archive format: %s", format)
+	}
+}
+
+func (a *archiveSink) WriteFile(name string, data []byte) error {
+	return a.copyFile(name, bytes.NewReader(data), int64(len(data)))
+}
+
+// copyFile adds an entry of size bytes read from r, so large files need not
+// be held in memory.
+func (a *archiveSink) copyFile(name string, r io.Reader, size int64) error {
+	if a.zip != nil {
+		entry, err := a.zip.CreateHeader(&zip.FileHeader{
+			Name:     name,
+			Method:   zip.Deflate,
+			Modified: archiveModTime,
+		})
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(entry, r)
+		return err
+	}
+	if err := a.tar.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     0o644,
+		Size:     size,
+		ModTime:  archiveModTime,
+		Format:   tar.FormatPAX,
+	}); err != nil {
+		return err
+	}
+	_, err := io.CopyN(a.tar, r, size)
+	return err
+}
+
+// Close finishes the archive; the underlying writer stays open.
+func (a *archiveSink) Close() error {
+	if a.zip != nil {
+		return a.zip.Close()
+	}
+	if err := a.tar.Close(); err != nil {
+		a.compressor.Close()
+		return err
+	}
+	return a.compressor.Close()
+}
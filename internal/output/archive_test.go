@@ -0,0 +1,175 @@
+package output
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/sbstn/sitecrawl/internal/crawler"
+)
+
+func writeTestArchive(t *testing.T, path string, archive ArchiveFormat) []byte {
+	t.Helper()
+	result := &crawler.CrawlResult{
+		Domain:     "example.com",
+		StartedAt:  time.Date(2025, 3, 4, 5, 6, 7, 0, time.UTC),
+		FinishedAt: time.Date(2025, 3, 4, 5, 7, 0, 0, time.UTC),
+		Strategy:   crawler.StrategyLimit,
+		Clean:      true,
+		Pages: []*crawler.Page{
+			{URL: "https://example.com/", FinalURL: "https://example.com/", Status: crawler.StatusOK, Title: "Home", MainText: "home"},
+			{
+				URL:      "https://example.com/docs/setup",
+				FinalURL: "https://example.com/docs/setup",
+				Status:   crawler.StatusOK,
+				Title:    "Setup",
+				MainText: "setup",
+				Tables:   []crawler.Table{{Rows: [][]string{{"a", "b"}}}},
+			},
+			{URL: "https://example.com/broken", Status: crawler.StatusError, Error: "timeout"},
+		},
+	}
+	writer, err := NewArchiveWriter(path, archive, FormatMarkdown, Options{Layout: LayoutTree, LLMsTxt: true})
+	if err != nil {
+		t.Fatalf("unexpected archive writer error: %v", err)
+	}
+	defer writer.Close()
+	if err := writer.Begin(result); err != nil {
+		t.Fatalf("unexpected begin error: %v", err)
+	}
+	for _, page := range result.Pages {
+		if err := writer.WritePage(page); err != nil {
+			t.Fatalf("unexpected write page error: %v", err)
+		}
+	}
+	if err := writer.Finish(result); err != nil {
+		t.Fatalf("unexpected finish error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+	return data
+}
+
+func TestArchiveWriterIsDeterministic(t *testing.T) {
+	want := []string{
+		"index.md",
+		"docs/setup.md",
+		"docs/setup.table-1.csv",
+		PagesJSONLName,
+		LLMsTxtName,
+		LLMsFullTxtName,
+		ReportName,
+	}
+	for _, archive := range []ArchiveFormat{ArchiveTarZstd, ArchiveTarGzip, ArchiveZip} {
+		tmpDir := t.TempDir()
+		first := writeTestArchive(t, filepath.Join(tmpDir, "one."+string(archive)), archive)
+		second := writeTestArchive(t, filepath.Join(tmpDir, "two."+string(archive)), archive)
+		if !bytes.Equal(first, second) {
+			t.Fatalf("%s: expected byte-identical archives", archive)
+		}
+
+		entries := readArchive(t, archive, first)
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.name)
+			if !entry.modified.Equal(archiveModTime) {
+				t.Fatalf("%s: expected fixed mtime on %s, got %v", archive, entry.name, entry.modified)
+			}
+		}
+		if strings.Join(names, ",") != strings.Join(want, ",") {
+			t.Fatalf("%s: unexpected entries %v", archive, names)
+		}
+		if !strings.Contains(entries[len(entries)-1].content, `"out_path": "docs/setup.md"`) {
+			t.Fatalf("%s: expected final report as last entry, got %s", archive, entries[len(entries)-1].content)
+		}
+	}
+}
+
+func TestParseArchiveFormat(t *testing.T) {
+	cases := map[string]ArchiveFormat{
+		"tar.zst": ArchiveTarZstd,
+		".TAR.GZ": ArchiveTarGzip,
+		"tgz":     ArchiveTarGzip,
+		"zip":     ArchiveZip,
+	}
+	for input, expected := range cases {
+		if got, err := ParseArchiveFormat(input); err != nil || got != expected {
+			t.Fatalf("expected %s for %q, got %s (%v)", expected, input, got, err)
+		}
+	}
+	if _, err := ParseArchiveFormat("rar"); err == nil {
+		t.Fatalf("expected invalid archive format error")
+	}
+}
+
+type archiveEntry struct {
+	name     string
+	modified time.Time
+	content  string
+}
+
+func readArchive(t *testing.T, archive ArchiveFormat, data []byte) []archiveEntry {
+	t.Helper()
+	var entries []archiveEntry
+	if archive == ArchiveZip {
+		reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatalf("unexpected zip error: %v", err)
+		}
+		for _, file := range reader.File {
+			rc, err := file.Open()
+			if err != nil {
+				t.Fatalf("unexpected zip entry error: %v", err)
+			}
+			content, err := io.ReadAll(rc)
+			rc.Close()
+			if err != nil {
+				t.Fatalf("unexpected zip read error: %v", err)
+			}
+			entries = append(entries, archiveEntry{name: file.Name, modified: file.Modified.UTC(), content: string(content)})
+		}
+		return entries
+	}
+
+	var stream io.Reader
+	if archive == ArchiveTarZstd {
+		decoder, err := zstd.NewReader(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("unexpected zstd error: %v", err)
+		}
+		defer decoder.Close()
+		stream = decoder
+	} else {
+		decoder, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("unexpected gzip error: %v", err)
+		}
+		stream = decoder
+	}
+	reader := tar.NewReader(stream)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected tar error: %v", err)
+		}
+		content, err := io.ReadAll(reader)
+		if err != nil {
+			t.Fatalf("unexpected tar read error: %v", err)
+		}
+		entries = append(entries, archiveEntry{name: header.Name, modified: header.ModTime.UTC(), content: string(content)})
+	}
+	return entries
+}
@@ -0,0 +1,470 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/sbstn/sitecrawl/internal/crawler"
+)
+
+// AuditFormat selects how audit results are printed.
+type AuditFormat string
+
+const (
+	// AuditMarkdown prints a rule summary and findings grouped by rule.
+	AuditMarkdown AuditFormat = "markdown"
+	// AuditJSON prints the full audit as one JSON object.
+	AuditJSON AuditFormat = "json"
+)
+
+const (
+	// AuditJSONName and AuditMarkdownName are written by crawls with --audit.
+	AuditJSONName     = "audit.json"
+	AuditMarkdownName = "audit.md"
+)
+
+// ParseAuditFormat validates and normalizes the audit output flag.
+func ParseAuditFormat(raw string) (AuditFormat, error) {
+	switch AuditFormat(strings.ToLower(strings.TrimSpace(raw))) {
+	case AuditMarkdown, "md":
+		return AuditMarkdown, nil
+	case AuditJSON:
+		return AuditJSON, nil
+	default:
+		return "", fmt.Errorf("invalid output %q (allowed: markdown, json)", raw)
+	}
+}
+
+// AuditSeverity ranks audit findings.
+type AuditSeverity string
+
+// Severities from most to least urgent.
+const (
+	SeverityError   AuditSeverity = "error"
+	SeverityWarning AuditSeverity = "warning"
+	SeverityNotice  AuditSeverity = "notice"
+)
+
+var severities = []AuditSeverity{SeverityError, SeverityWarning, SeverityNotice}
+
+// AuditRule is one check run over the successfully crawled pages.
+type AuditRule struct {
+	ID          string        `json:"id"`
+	Severity    AuditSeverity `json:"severity"`
+	Description string        `json:"description"`
+}
+
+// auditRule pairs a rule with its check. check returns the findings without
+// rule and severity, or a reason when the report lacks the data it needs.
+type auditRule struct {
+	AuditRule
+	check func(pages []ReportPage, opts AuditOptions) ([]AuditFinding, string)
+}
+
+var auditRules = []auditRule{
+	{AuditRule{"title-missing", SeverityError, "Page has no title"}, checkTitleMissing},
+	{AuditRule{"title-too-long", SeverityWarning, "Title is longer than the maximum length"}, checkTitleTooLong},
+	{AuditRule{"title-duplicate", SeverityWarning, "Title is shared with other pages"}, checkTitleDuplicate},
+	{AuditRule{"description-missing", SeverityWarning, "Page has no meta description"}, checkDescriptionMissing},
+	{AuditRule{"description-too-long", SeverityNotice, "Meta description is longer than the maximum length"}, checkDescriptionTooLong},
+	{AuditRule{"description-duplicate", SeverityNotice, "Meta description is shared with other pages"}, checkDescriptionDuplicate},
+	{AuditRule{"h1-missing", SeverityWarning, "Page has no h1 heading"}, checkH1Missing},
+	{AuditRule{"h1-multiple", SeverityNotice, "Page has more than one h1 heading"}, checkH1Multiple},
+	{AuditRule{"thin-content", SeverityWarning, "Main content has fewer than the minimum number of words"}, checkThinContent},
+	{AuditRule{"deep-page", SeverityNotice, "Page is deeper than the audit's maximum depth"}, checkDeepPage},
+	{AuditRule{"non-canonical", SeverityNotice, "Page declares another URL as canonical"}, checkNonCanonical},
+	{AuditRule{"low-inbound-links", SeverityWarning, "Page receives fewer than the minimum number of internal links"}, checkLowInboundLinks},
+}
+
+// AuditRules lists every audit rule in report order.
+func AuditRules() []AuditRule {
+	rules := make([]AuditRule, 0, len(auditRules))
+	for _, rule := range auditRules {
+		rules = append(rules, rule.AuditRule)
+	}
+	return rules
+}
+
+// ParseAuditRules validates a comma-separated list of rule IDs.
+func ParseAuditRules(raw string) ([]string, error) {
+	var ids []string
+	for _, part := range strings.Split(raw, ",") {
+		id := strings.ToLower(strings.TrimSpace(part))
+		if id == "" {
+			continue
+		}
+		if !slices.ContainsFunc(auditRules, func(rule auditRule) bool { return rule.ID == id }) {
+			allowed := make([]string, 0, len(auditRules))
+			for _, rule := range auditRules {
+				allowed = append(allowed, rule.ID)
+			}
+			return nil, fmt.Errorf("invalid audit rule %q (allowed: %s)", part, strings.Join(allowed, ", "))
+		}
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// AuditOptions selects rules and sets their thresholds.
+type AuditOptions struct {
+	// Rules runs only these rule IDs when set; Disabled rules never run.
+	Rules    []string
+	Disabled []string
+
+	MaxTitleLength       int
+	MaxDescriptionLength int
+	MinWords             int
+	MaxDepth             int
+	MinInboundLinks      int
+}
+
+// DefaultAuditOptions runs every rule with common SEO thresholds.
+func DefaultAuditOptions() AuditOptions {
+	return AuditOptions{
+		MaxTitleLength:       60,
+		MaxDescriptionLength: 160,
+		MinWords:             200,
+		MaxDepth:             3,
+		MinInboundLinks:      1,
+	}
+}
+
+func (o AuditOptions) enabled(id string) bool {
+	if len(o.Rules) > 0 && !slices.Contains(o.Rules, id) {
+		return false
+	}
+	return !slices.Contains(o.Disabled, id)
+}
+
+// AuditFinding is one rule violation on one page.
+type AuditFinding struct {
+	Rule     string        `json:"rule"`
+	Severity AuditSeverity `json:"severity"`
+	URL      string        `json:"url"`
+	Message  string        `json:"message"`
+	// Related lists the other pages sharing a duplicated value.
+	Related []string `json:"related,omitempty"`
+}
+
+// AuditRuleResult is a rule that ran, with its number of findings. Skipped
+// explains why a rule could not be evaluated, e.g. on reports written before
+// the data it needs was recorded.
+type AuditRuleResult struct {
+	AuditRule
+	Findings int    `json:"findings"`
+	Skipped  string `json:"skipped,omitempty"`
+}
+
+// AuditResult is the outcome of auditing a crawl report.
+type AuditResult struct {
+	Domain   string            `json:"domain"`
+	Partial  bool              `json:"partial,omitempty"`
+	Pages    int               `json:"pages"`
+	Errors   int               `json:"errors"`
+	Warnings int               `json:"warnings"`
+	Notices  int               `json:"notices"`
+	Rules    []AuditRuleResult `json:"rules"`
+	Findings []AuditFinding    `json:"findings"`
+}
+
+// Audit runs the enabled rules over the report's successfully crawled pages.
+// Findings are ordered by severity, rule, and URL.
+func Audit(rep Report, opts AuditOptions) AuditResult {
+	var pages []ReportPage
+	for _, page := range rep.Pages {
+		if page.Status == crawler.StatusOK {
+			pages = append(pages, page)
+		}
+	}
+	result := AuditResult{
+		Domain:   rep.Domain,
+		Partial:  rep.Partial,
+		Pages:    len(pages),
+		Rules:    []AuditRuleResult{},
+		Findings: []AuditFinding{},
+	}
+	ruleIndex := map[string]int{}
+	for _, rule := range auditRules {
+		if !opts.enabled(rule.ID) {
+			continue
+		}
+		ruleIndex[rule.ID] = len(result.Rules)
+		findings, skipped := rule.check(pages, opts)
+		for i := range findings {
+			findings[i].Rule = rule.ID
+			findings[i].Severity = rule.Severity
+		}
+		result.Rules = append(result.Rules, AuditRuleResult{AuditRule: rule.AuditRule, Findings: len(findings), Skipped: skipped})
+		result.Findings = append(result.Findings, findings...)
+		switch rule.Severity {
+		case SeverityError:
+			result.Errors += len(findings)
+		case SeverityWarning:
+			result.Warnings += len(findings)
+		default:
+			result.Notices += len(findings)
+		}
+	}
+	sort.SliceStable(result.Findings, func(i, j int) bool {
+		a, b := result.Findings[i], result.Findings[j]
+		if a.Severity != b.Severity {
+			return slices.Index(severities, a.Severity) < slices.Index(severities, b.Severity)
+		}
+		if a.Rule != b.Rule {
+			return ruleIndex[a.Rule] < ruleIndex[b.Rule]
+		}
+		return a.URL < b.URL
+	})
+	return result
+}
+
+func checkTitleMissing(pages []ReportPage, _ AuditOptions) ([]AuditFinding, string) {
+	return eachPage(pages, func(page ReportPage) string {
+		if strings.TrimSpace(page.Title) == "" {
+			return "title is missing"
+		}
+		return ""
+	}), ""
+}
+
+func checkTitleTooLong(pages []ReportPage, opts AuditOptions) ([]AuditFinding, string) {
+	return eachPage(pages, func(page ReportPage) string {
+		return tooLong("title", page.Title, opts.MaxTitleLength)
+	}), ""
+}
+
+func checkTitleDuplicate(pages []ReportPage, _ AuditOptions) ([]AuditFinding, string) {
+	return duplicates(pages, "title", func(page ReportPage) string { return page.Title }), ""
+}
+
+func checkDescriptionMissing(pages []ReportPage, _ AuditOptions) ([]AuditFinding, string) {
+	return eachPage(pages, func(page ReportPage) string {
+		if strings.TrimSpace(page.Description) == "" {
+			return "meta description is missing"
+		}
+		return ""
+	}), ""
+}
+
+func checkDescriptionTooLong(pages []ReportPage, opts AuditOptions) ([]AuditFinding, string) {
+	return eachPage(pages, func(page ReportPage) string {
+		return tooLong("meta description", page.Description, opts.MaxDescriptionLength)
+	}), ""
+}
+
+func checkDescriptionDuplicate(pages []ReportPage, _ AuditOptions) ([]AuditFinding, string) {
+	return duplicates(pages, "meta description", func(page ReportPage) string { return page.Description }), ""
+}
+
+func checkH1Missing(pages []ReportPage, _ AuditOptions) ([]AuditFinding, string) {
+	if !slices.ContainsFunc(pages, func(page ReportPage) bool { return page.H1Count != nil }) {
+		return nil, "report has no h1_count"
+	}
+	return eachPage(pages, func(page ReportPage) string {
+		if page.H1Count != nil && *page.H1Count == 0 {
+			return "no h1 heading"
+		}
+		return ""
+	}), ""
+}
+
+func checkH1Multiple(pages []ReportPage, _ AuditOptions) ([]AuditFinding, string) {
+	if !slices.ContainsFunc(pages, func(page ReportPage) bool { return page.H1Count != nil }) {
+		return nil, "report has no h1_count"
+	}
+	return eachPage(pages, func(page ReportPage) string {
+		if page.H1Count != nil && *page.H1Count > 1 {
+			return fmt.Sprintf("%d h1 headings", *page.H1Count)
+		}
+		return ""
+	}), ""
+}
+
+func checkThinContent(pages []ReportPage, opts AuditOptions) ([]AuditFinding, string) {
+	if !slices.ContainsFunc(pages, func(page ReportPage) bool { return page.WordCount != nil }) {
+		return nil, "report has no word_count"
+	}
+	return eachPage(pages, func(page ReportPage) string {
+		if page.WordCount != nil && *page.WordCount < opts.MinWords {
+			return fmt.Sprintf("%d words (min %d)", *page.WordCount, opts.MinWords)
+		}
+		return ""
+	}), ""
+}
+
+func checkDeepPage(pages []ReportPage, opts AuditOptions) ([]AuditFinding, string) {
+	return eachPage(pages, func(page ReportPage) string {
+		if page.Depth > opts.MaxDepth {
+			return fmt.Sprintf("depth %d (max %d)", page.Depth, opts.MaxDepth)
+		}
+		return ""
+	}), ""
+}
+
+func checkNonCanonical(pages []ReportPage, _ AuditOptions) ([]AuditFinding, string) {
+	return eachPage(pages, func(page ReportPage) string {
+		if page.Canonical != "" && page.Canonical != reportPageKey(page) {
+			return "canonical URL is " + page.Canonical
+		}
+		return ""
+	}), ""
+}
+
+// checkLowInboundLinks skips the start page, which the crawl reaches without
+// a link.
+func checkLowInboundLinks(pages []ReportPage, opts AuditOptions) ([]AuditFinding, string) {
+	if !slices.ContainsFunc(pages, func(page ReportPage) bool { return page.InboundLinks != nil }) {
+		return nil, "report has no inbound_links"
+	}
+	return eachPage(pages, func(page ReportPage) string {
+		if page.Depth > 0 && page.InboundLinks != nil && *page.InboundLinks < opts.MinInboundLinks {
+			return fmt.Sprintf("%d inbound links (min %d)", *page.InboundLinks, opts.MinInboundLinks)
+		}
+		return ""
+	}), ""
+}
+
+// eachPage returns a finding for every page check returns a message for.
+func eachPage(pages []ReportPage, check func(ReportPage) string) []AuditFinding {
+	var findings []AuditFinding
+	for _, page := range pages {
+		if message := check(page); message != "" {
+			findings = append(findings, AuditFinding{URL: reportPageKey(page), Message: message})
+		}
+	}
+	return findings
+}
+
+func tooLong(name, value string, limit int) string {
+	if length := utf8.RuneCountInString(strings.TrimSpace(value)); length > limit {
+		return fmt.Sprintf("%s is %d characters (max %d)", name, length, limit)
+	}
+	return ""
+}
+
+// duplicates reports every page whose non-empty value, compared
+// case-insensitively with collapsed whitespace, also appears on other pages.
+func duplicates(pages []ReportPage, name string, value func(ReportPage) string) []AuditFinding {
+	groups := map[string][]string{}
+	for _, page := range pages {
+		key := strings.ToLower(strings.Join(strings.Fields(value(page)), " "))
+		if key != "" {
+			groups[key] = append(groups[key], reportPageKey(page))
+		}
+	}
+	var findings []AuditFinding
+	for _, page := range pages {
+		key := strings.ToLower(strings.Join(strings.Fields(value(page)), " "))
+		urls := groups[key]
+		if key == "" || len(urls) < 2 {
+			continue
+		}
+		url := reportPageKey(page)
+		related := slices.DeleteFunc(slices.Clone(urls), func(other string) bool { return other == url })
+		sort.Strings(related)
+		findings = append(findings, AuditFinding{
+			URL:     url,
+			Message: "duplicate " + name,
+			Related: related,
+		})
+	}
+	return findings
+}
+
+// ReportAudit lists the audit files and finding counts in report.json.
+type ReportAudit struct {
+	Files    []string `json:"files"`
+	Findings int      `json:"findings"`
+	Errors   int      `json:"errors"`
+	Warnings int      `json:"warnings"`
+	Notices  int      `json:"notices"`
+}
+
+// writeAudit audits rep and writes audit.json and audit.md.
+func writeAudit(rep Report, files fileSink, opts AuditOptions) (*ReportAudit, error) {
+	result := Audit(rep, opts)
+	for _, out := range []struct {
+		name   string
+		format AuditFormat
+	}{{AuditJSONName, AuditJSON}, {AuditMarkdownName, AuditMarkdown}} {
+		var buf bytes.Buffer
+		if err := WriteAudit(&buf, result, out.format); err != nil {
+			return nil, err
+		}
+		if err := files.WriteFile(out.name, buf.Bytes()); err != nil {
+			return nil, err
+		}
+	}
+	return &ReportAudit{
+		Files:    []string{AuditJSONName, AuditMarkdownName},
+		Findings: len(result.Findings),
+		Errors:   result.Errors,
+		Warnings: result.Warnings,
+		Notices:  result.Notices,
+	}, nil
+}
+
+// WriteAudit prints result to w in format.
+func WriteAudit(w io.Writer, result AuditResult, format AuditFormat) error {
+	switch format {
+	case AuditJSON:
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(append(data, '\n'))
+		return err
+	case AuditMarkdown:
+		_, err := io.WriteString(w, renderAuditMarkdown(result))
+		return err
+	default:
+		return fmt.Errorf("unsupported audit format: %s", format)
+	}
+}
+
+func renderAuditMarkdown(result AuditResult) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Audit: %s\n\n", result.Domain)
+	partial := ""
+	if result.Partial {
+		partial = " (partial crawl)"
+	}
+	fmt.Fprintf(&b, "Pages audited: %d%s. Errors: %d, warnings: %d, notices: %d.\n", result.Pages, partial, result.Errors, result.Warnings, result.Notices)
+
+	fmt.Fprintf(&b, "\n| Rule | Severity | Findings |\n| --- | --- | --- |\n")
+	for _, rule := range result.Rules {
+		findings := fmt.Sprint(rule.Findings)
+		if rule.Skipped != "" {
+			findings = "skipped: " + rule.Skipped
+		}
+		fmt.Fprintf(&b, "| `%s` | %s | %s |\n", rule.ID, rule.Severity, markdownCell(findings))
+	}
+
+	for _, rule := range slices.SortedStableFunc(slices.Values(result.Rules), func(a, b AuditRuleResult) int {
+		return slices.Index(severities, a.Severity) - slices.Index(severities, b.Severity)
+	}) {
+		if rule.Findings == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n## %s: %s\n\n%s.\n\n| URL | Finding |\n| --- | --- |\n", rule.Severity, rule.ID, rule.Description)
+		for _, finding := range result.Findings {
+			if finding.Rule != rule.ID {
+				continue
+			}
+			message := finding.Message
+			if len(finding.Related) > 0 {
+				message += ": " + strings.Join(finding.Related, ", ")
+			}
+			fmt.Fprintf(&b, "| %s | %s |\n", markdownCell(finding.URL), markdownCell(message))
+		}
+	}
+	return b.String()
+}
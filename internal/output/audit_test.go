@@ -0,0 +1,168 @@
+package output
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sbstn/sitecrawl/internal/crawler"
+)
+
+func TestParseAuditRules(t *testing.T) {
+	rules, err := ParseAuditRules(" Title-Missing,thin-content,,title-missing")
+	if err != nil || !reflect.DeepEqual(rules, []string{"title-missing", "thin-content"}) {
+		t.Fatalf("unexpected rules: %v, %v", rules, err)
+	}
+	if _, err := ParseAuditRules("title-missing,spelling"); err == nil || !strings.Contains(err.Error(), `"spelling"`) {
+		t.Fatalf("expected error for unknown rule, got %v", err)
+	}
+}
+
+func TestAudit(t *testing.T) {
+	count := func(v int) *int { return &v }
+	rep := Report{
+		Domain: "example.com",
+		Pages: []ReportPage{
+			{URL: "https://example.com/", FinalURL: "https://example.com/", Status: crawler.StatusOK, Title: "Home", Description: "Welcome", H1Count: count(1), WordCount: count(400), InboundLinks: count(0)},
+			{URL: "https://example.com/a", FinalURL: "https://example.com/a", Depth: 1, Status: crawler.StatusOK, Title: "Guide", Description: "Same", H1Count: count(0), WordCount: count(50), InboundLinks: count(2)},
+			{URL: "https://example.com/b", FinalURL: "https://example.com/b", Depth: 4, Status: crawler.StatusOK, Title: " guide ", Description: "same", H1Count: count(2), WordCount: count(300), InboundLinks: count(0), Canonical: "https://example.com/a"},
+			{URL: "https://example.com/c", FinalURL: "https://example.com/c", Depth: 1, Status: crawler.StatusOK, Title: strings.Repeat("x", 61), H1Count: count(1), WordCount: count(300), InboundLinks: count(1), Canonical: "https://example.com/c"},
+			{URL: "https://example.com/d", FinalURL: "https://example.com/d", Depth: 1, Status: crawler.StatusOK, Description: strings.Repeat("y", 161), H1Count: count(1), WordCount: count(300), InboundLinks: count(1)},
+			{URL: "https://example.com/broken", FinalURL: "https://example.com/broken", Depth: 9, Status: crawler.StatusError},
+		},
+	}
+
+	t.Run("findings", func(t *testing.T) {
+		result := Audit(rep, DefaultAuditOptions())
+		if result.Pages != 5 {
+			t.Fatalf("expected only ok pages to be audited, got %d", result.Pages)
+		}
+		var got []string
+		for _, finding := range result.Findings {
+			got = append(got, finding.Rule+" "+finding.URL)
+		}
+		want := []string{
+			"title-missing https://example.com/d",
+			"title-too-long https://example.com/c",
+			"title-duplicate https://example.com/a",
+			"title-duplicate https://example.com/b",
+			"description-missing https://example.com/c",
+			"h1-missing https://example.com/a",
+			"thin-content https://example.com/a",
+			"low-inbound-links https://example.com/b",
+			"description-too-long https://example.com/d",
+			"description-duplicate https://example.com/a",
+			"description-duplicate https://example.com/b",
+			"h1-multiple https://example.com/b",
+			"deep-page https://example.com/b",
+			"non-canonical https://example.com/b",
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("unexpected findings:\n got %q\nwant %q", got, want)
+		}
+		if result.Errors != 1 || result.Warnings != 7 || result.Notices != 6 {
+			t.Fatalf("unexpected severity counts: %d/%d/%d", result.Errors, result.Warnings, result.Notices)
+		}
+		if related := result.Findings[2].Related; !reflect.DeepEqual(related, []string{"https://example.com/b"}) {
+			t.Fatalf("unexpected duplicate related pages: %v", related)
+		}
+	})
+
+	t.Run("rule selection", func(t *testing.T) {
+		opts := DefaultAuditOptions()
+		opts.Rules = []string{"thin-content", "deep-page", "title-missing"}
+		opts.Disabled = []string{"title-missing"}
+		opts.MinWords = 350
+		result := Audit(rep, opts)
+		var ids []string
+		for _, rule := range result.Rules {
+			ids = append(ids, rule.ID)
+		}
+		if !reflect.DeepEqual(ids, []string{"thin-content", "deep-page"}) {
+			t.Fatalf("unexpected rules: %v", ids)
+		}
+		if result.Rules[0].Findings != 4 || len(result.Findings) != 5 {
+			t.Fatalf("unexpected findings with MinWords=350: %+v", result.Findings)
+		}
+	})
+
+	t.Run("markdown", func(t *testing.T) {
+		opts := DefaultAuditOptions()
+		opts.Rules = []string{"deep-page", "title-duplicate"}
+		var buf bytes.Buffer
+		if err := WriteAudit(&buf, Audit(rep, opts), AuditMarkdown); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := "# Audit: example.com\n\n" +
+			"Pages audited: 5. Errors: 0, warnings: 2, notices: 1.\n\n" +
+			"| Rule | Severity | Findings |\n| --- | --- | --- |\n" +
+			"| `title-duplicate` | warning | 2 |\n" +
+			"| `deep-page` | notice | 1 |\n\n" +
+			"## warning: title-duplicate\n\nTitle is shared with other pages.\n\n" +
+			"| URL | Finding |\n| --- | --- |\n" +
+			"| https://example.com/a | duplicate title: https://example.com/b |\n" +
+			"| https://example.com/b | duplicate title: https://example.com/a |\n\n" +
+			"## notice: deep-page\n\nPage is deeper than the audit's maximum depth.\n\n" +
+			"| URL | Finding |\n| --- | --- |\n" +
+			"| https://example.com/b | depth 4 (max 3) |\n"
+		if buf.String() != want {
+			t.Fatalf("unexpected markdown:\n%s", buf.String())
+		}
+	})
+}
+
+func TestAuditSkipsRulesWithoutData(t *testing.T) {
+	rep := Report{Domain: "example.com", Pages: []ReportPage{{URL: "https://example.com/", Status: crawler.StatusOK, Title: "Home"}}}
+	result := Audit(rep, DefaultAuditOptions())
+	skipped := map[string]string{}
+	for _, rule := range result.Rules {
+		if rule.Skipped != "" {
+			skipped[rule.ID] = rule.Skipped
+		}
+	}
+	want := map[string]string{
+		"h1-missing":        "report has no h1_count",
+		"h1-multiple":       "report has no h1_count",
+		"thin-content":      "report has no word_count",
+		"low-inbound-links": "report has no inbound_links",
+	}
+	if !reflect.DeepEqual(skipped, want) {
+		t.Fatalf("unexpected skipped rules: %v", skipped)
+	}
+}
+
+func TestFinishWritesAudit(t *testing.T) {
+	tmpDir := t.TempDir()
+	graph := crawler.NewLinkGraph()
+	graph.AddEdge("https://example.com/", "https://example.com/docs")
+	result := &crawler.CrawlResult{
+		Domain:    "example.com",
+		LinkGraph: graph,
+		Pages: []*crawler.Page{
+			{URL: "https://example.com/", FinalURL: "https://example.com/", Status: crawler.StatusOK, Title: "Home"},
+			{URL: "https://example.com/docs", FinalURL: "https://example.com/docs", Depth: 1, Status: crawler.StatusOK, Title: "Docs"},
+		},
+	}
+	opts := DefaultAuditOptions()
+	if err := WriteWithOptions(result, tmpDir, FormatMarkdown, Options{Audit: &opts}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rep := readReport(t, tmpDir)
+	if rep.Audit == nil || !reflect.DeepEqual(rep.Audit.Files, []string{AuditJSONName, AuditMarkdownName}) {
+		t.Fatalf("unexpected audit section: %+v", rep.Audit)
+	}
+	if got := rep.Pages[1].InboundLinks; got == nil || *got != 1 {
+		t.Fatalf("expected one inbound link for /docs, got %v", got)
+	}
+	for _, name := range rep.Audit.Files {
+		if _, err := os.Stat(filepath.Join(tmpDir, name)); err != nil {
+			t.Fatalf("expected %s: %v", name, err)
+		}
+	}
+	if rep.Audit.Findings != rep.Audit.Errors+rep.Audit.Warnings+rep.Audit.Notices || rep.Audit.Findings == 0 {
+		t.Fatalf("unexpected audit counts: %+v", rep.Audit)
+	}
+}
@@ -0,0 +1,308 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/sbstn/sitecrawl/internal/crawler"
+)
+
+// ChunksName is the JSON Lines file holding RAG-ready page chunks.
+const ChunksName = "chunks.jsonl"
+
+var markdownHeading = regexp.MustCompile(`^(#{1,6}) +(.+?)#*\s*$`)
+
+// ChunkOptions configures chunks.jsonl. OverlapTokens of the previous chunk
+// are repeated at the start of the next chunk within the same section.
+type ChunkOptions struct {
+	MaxTokens     int
+	OverlapTokens int
+	Tokenizer     Tokenizer
+}
+
+type pageChunk struct {
+	URL         string   `json:"url"`
+	Title       string   `json:"title,omitempty"`
+	OutPath     string   `json:"out_path,omitempty"`
+	HeadingPath []string `json:"heading_path"`
+	ChunkIndex  int      `json:"chunk_index"`
+	TokenCount  int      `json:"token_count"`
+	Score       *float64 `json:"score,omitempty"`
+	Text        string   `json:"text"`
+}
+
+// ReportChunks describes the chunks.jsonl file in report.json.
+type ReportChunks struct {
+	Path          string `json:"path"`
+	Total         int    `json:"total"`
+	Tokenizer     string `json:"tokenizer"`
+	MaxTokens     int    `json:"max_tokens"`
+	OverlapTokens int    `json:"overlap_tokens"`
+}
+
+type markdownSection struct {
+	headingPath []string
+	blocks      []string
+}
+
+// writeChunks writes chunks.jsonl for every written page and returns the
+// number of chunks.
+func writeChunks(result *crawler.CrawlResult, files fileSink, opts ChunkOptions) (int, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+
+	total := 0
+	for _, page := range result.Pages {
+		if page.Status != crawler.StatusOK {
+			continue
+		}
+		for _, chunk := range chunkPage(page, opts) {
+			if err := encoder.Encode(chunk); err != nil {
+				return 0, err
+			}
+			total++
+		}
+	}
+	return total, files.WriteFile(ChunksName, buf.Bytes())
+}
+
+// chunkPage splits a page's markdown on heading boundaries, then packs each
+// section's blocks into chunks of at most opts.MaxTokens. Blocks larger than
+// the budget are split on word boundaries.
+func chunkPage(page *crawler.Page, opts ChunkOptions) []pageChunk {
+	pageURL := page.FinalURL
+	if pageURL == "" {
+		pageURL = page.URL
+	}
+	var chunks []pageChunk
+	for _, section := range splitMarkdownSections(pageMarkdown(page)) {
+		for _, text := range packBlocks(section.blocks, opts) {
+			chunks = append(chunks, pageChunk{
+				URL:         pageURL,
+				Title:       page.Title,
+				OutPath:     page.OutPath,
+				HeadingPath: section.headingPath,
+				ChunkIndex:  len(chunks),
+				TokenCount:  opts.Tokenizer.Count(text),
+				Score:       page.Score,
+				Text:        text,
+			})
+		}
+	}
+	return chunks
+}
+
+// splitMarkdownSections starts a section at every heading outside fenced
+// code. Blocks are separated by blank lines; fenced code stays in one block.
+// Sections holding nothing but their heading are dropped.
+func splitMarkdownSections(markdown string) []markdownSection {
+	var sections []markdownSection
+	current := markdownSection{headingPath: []string{}}
+	var levels []int
+	var block []string
+	fence := ""
+	hasBody := false
+
+	flushBlock := func() {
+		if len(block) > 0 {
+			current.blocks = append(current.blocks, strings.Join(block, "\n"))
+			block = nil
+		}
+	}
+	flushSection := func() {
+		flushBlock()
+		if hasBody {
+			sections = append(sections, current)
+		}
+		hasBody = false
+	}
+
+	for _, line := range strings.Split(markdown, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if fence != "" {
+			block = append(block, line)
+			if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+				fence = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:len(trimmed)-len(strings.TrimLeft(trimmed, trimmed[:1]))]
+			block = append(block, line)
+			hasBody = true
+			continue
+		}
+		if match := markdownHeading.FindStringSubmatch(line); match != nil {
+			flushSection()
+			level := len(match[1])
+			for len(levels) > 0 && levels[len(levels)-1] >= level {
+				levels = levels[:len(levels)-1]
+			}
+			path := append([]string{}, current.headingPath[:len(levels)]...)
+			levels = append(levels, level)
+			current = markdownSection{headingPath: append(path, strings.TrimSpace(match[2]))}
+			block = []string{line}
+			flushBlock()
+			continue
+		}
+		if trimmed == "" {
+			flushBlock()
+			continue
+		}
+		block = append(block, line)
+		hasBody = true
+	}
+	flushSection()
+	return sections
+}
+
+// packBlocks greedily joins blocks into chunks within the token budget.
+func packBlocks(blocks []string, opts ChunkOptions) []string {
+	var chunks []string
+	var current []string
+	currentTokens := 0
+	flush := func() {
+		if len(current) > 0 {
+			chunks = append(chunks, strings.Join(current, "\n\n"))
+		}
+		current = nil
+		currentTokens = 0
+	}
+	for _, block := range blocks {
+		tokens := opts.Tokenizer.Count(block)
+		if tokens > opts.MaxTokens {
+			previous := ""
+			if len(current) > 0 {
+				previous = strings.Join(current, "\n\n")
+			}
+			flush()
+			if isCodeFence(block) {
+				chunks = append(chunks, splitCodeLines(previous, block, opts)...)
+			} else {
+				chunks = append(chunks, splitWords(previous, block, opts)...)
+			}
+			continue
+		}
+		if currentTokens+tokens > opts.MaxTokens && len(current) > 0 {
+			tail := overlapTail(strings.Join(current, "\n\n"), min(opts.OverlapTokens, opts.MaxTokens-tokens), opts.Tokenizer)
+			flush()
+			if tail != "" {
+				current = []string{tail}
+				currentTokens = opts.Tokenizer.Count(tail)
+			}
+		}
+		current = append(current, block)
+		currentTokens += tokens
+	}
+	flush()
+	return chunks
+}
+
+// splitWords cuts an oversized block on word boundaries. Each piece starts
+// with the overlap tail of the text before it.
+func splitWords(previous, block string, opts ChunkOptions) []string {
+	var pieces []string
+	var current []string
+	currentTokens := 0
+	if tail := overlapTail(previous, opts.OverlapTokens, opts.Tokenizer); tail != "" {
+		current = strings.Fields(tail)
+		currentTokens = opts.Tokenizer.Count(tail)
+	}
+	for _, word := range strings.Fields(block) {
+		tokens := opts.Tokenizer.Count(word)
+		if currentTokens+tokens > opts.MaxTokens && len(current) > 0 {
+			text := strings.Join(current, " ")
+			pieces = append(pieces, text)
+			current = nil
+			currentTokens = 0
+			if tail := overlapTail(text, min(opts.OverlapTokens, opts.MaxTokens-tokens), opts.Tokenizer); tail != "" {
+				current = strings.Fields(tail)
+				currentTokens = opts.Tokenizer.Count(tail)
+			}
+		}
+		current = append(current, word)
+		currentTokens += tokens
+	}
+	if len(current) > 0 {
+		pieces = append(pieces, strings.Join(current, " "))
+	}
+	return pieces
+}
+
+// splitCodeLines cuts an oversized fenced code block on line boundaries,
+// keeping each line's indentation and reopening the fence in every piece.
+// The first piece starts with the overlap tail of the text before it as a
+// paragraph; later pieces repeat trailing lines of the previous piece.
+func splitCodeLines(previous, block string, opts ChunkOptions) []string {
+	lines := strings.Split(block, "\n")
+	open, body := lines[0], lines[1:]
+	trimmed := strings.TrimSpace(open)
+	fence := trimmed[:len(trimmed)-len(strings.TrimLeft(trimmed, trimmed[:1]))]
+	closing := fence
+	if n := len(body); n > 0 && strings.HasPrefix(strings.TrimSpace(body[n-1]), fence) && strings.Trim(strings.TrimSpace(body[n-1]), fence[:1]) == "" {
+		closing, body = body[n-1], body[:n-1]
+	}
+	budget := opts.MaxTokens - opts.Tokenizer.Count(open+"\n"+closing)
+
+	var pieces []string
+	lead := overlapTail(previous, opts.OverlapTokens, opts.Tokenizer)
+	var current []string
+	currentTokens := 0
+	if lead != "" {
+		currentTokens = opts.Tokenizer.Count(lead)
+	}
+	flush := func() {
+		piece := open + "\n" + strings.Join(current, "\n") + "\n" + closing
+		if lead != "" {
+			piece = lead + "\n\n" + piece
+			lead = ""
+		}
+		pieces = append(pieces, piece)
+	}
+	for _, line := range body {
+		tokens := opts.Tokenizer.Count(line)
+		if currentTokens+tokens > budget && len(current) > 0 {
+			flush()
+			var tail []string
+			tailTokens := 0
+			for i := len(current) - 1; i >= 0; i-- {
+				lineTokens := opts.Tokenizer.Count(current[i])
+				if tailTokens+lineTokens > min(opts.OverlapTokens, budget-tokens) {
+					break
+				}
+				tail = append([]string{current[i]}, tail...)
+				tailTokens += lineTokens
+			}
+			current, currentTokens = tail, tailTokens
+		}
+		current = append(current, line)
+		currentTokens += tokens
+	}
+	if len(current) > 0 {
+		flush()
+	}
+	return pieces
+}
+
+// overlapTail returns the longest run of trailing words of text that fits in
+// budget tokens.
+func overlapTail(text string, budget int, tokenizer Tokenizer) string {
+	if budget <= 0 || text == "" {
+		return ""
+	}
+	words := strings.Fields(text)
+	start := len(words)
+	used := 0
+	for start > 0 {
+		tokens := tokenizer.Count(words[start-1])
+		if used+tokens > budget {
+			break
+		}
+		used += tokens
+		start--
+	}
+	return strings.Join(words[start:], " ")
+}
@@ -0,0 +1,129 @@
+package output
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sbstn/sitecrawl/internal/crawler"
+)
+
+func TestSplitMarkdownSections(t *testing.T) {
+	markdown := "Intro text.\n\n# Guide\n\n## Install\n\nRun it.\n\n```sh\n# not a heading\n\ngo install\n```\n\n## Usage\n\nUse it.\n\n# Appendix\n\nMore.\n"
+
+	sections := splitMarkdownSections(markdown)
+	var paths [][]string
+	for _, section := range sections {
+		paths = append(paths, section.headingPath)
+	}
+	wantPaths := [][]string{{}, {"Guide", "Install"}, {"Guide", "Usage"}, {"Appendix"}}
+	if !reflect.DeepEqual(paths, wantPaths) {
+		t.Fatalf("expected heading paths %v, got %v", wantPaths, paths)
+	}
+	wantInstall := []string{"## Install", "Run it.", "```sh\n# not a heading\n\ngo install\n```"}
+	if !reflect.DeepEqual(sections[1].blocks, wantInstall) {
+		t.Fatalf("expected blocks %q, got %q", wantInstall, sections[1].blocks)
+	}
+}
+
+func TestPackBlocksWithOverlap(t *testing.T) {
+	opts := ChunkOptions{MaxTokens: 5, OverlapTokens: 2, Tokenizer: TokenizerGPT}
+	blocks := []string{"one two three", "four five six", "seven"}
+
+	got := packBlocks(blocks, opts)
+	want := []string{"one two three", "two three\n\nfour five six", "five six\n\nseven"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestPackBlocksSplitsOversizedBlock(t *testing.T) {
+	opts := ChunkOptions{MaxTokens: 3, OverlapTokens: 1, Tokenizer: TokenizerGPT}
+
+	got := packBlocks([]string{"a b c d e"}, opts)
+	want := []string{"a b c", "c d e"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestPackBlocksSplitsOversizedCodeOnLines(t *testing.T) {
+	opts := ChunkOptions{MaxTokens: 12, OverlapTokens: 2, Tokenizer: TokenizerGPT}
+
+	got := packBlocks([]string{"```go\nfunc a() {\n\tb()\n\tc()\n}\n```"}, opts)
+	want := []string{"```go\nfunc a() {\n\tb()\n```", "```go\n\tb()\n\tc()\n}\n```"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestWriteChunks(t *testing.T) {
+	tmpDir := t.TempDir()
+	score := 0.5
+	result := &crawler.CrawlResult{
+		Domain:   "example.com",
+		Strategy: crawler.StrategyPageRank,
+		Clean:    true,
+		Pages: []*crawler.Page{
+			{
+				URL:      "https://example.com/docs",
+				FinalURL: "https://example.com/docs",
+				Status:   crawler.StatusOK,
+				Title:    "Docs",
+				MainHTML: "<h1>Docs</h1><p>Welcome.</p><h2>Setup</h2><p>Install the tool.</p>",
+				Score:    &score,
+			},
+			{URL: "https://example.com/skipped", Status: crawler.StatusError},
+		},
+	}
+	opts := Options{Chunks: &ChunkOptions{MaxTokens: 100, OverlapTokens: 10, Tokenizer: TokenizerGPT}}
+
+	if err := WriteWithOptions(result, tmpDir, FormatMarkdown, opts); err != nil {
+		t.Fatalf("unexpected write error: %v", err)
+	}
+
+	file, err := os.Open(filepath.Join(tmpDir, ChunksName))
+	if err != nil {
+		t.Fatalf("unexpected open error: %v", err)
+	}
+	defer file.Close()
+	var chunks []pageChunk
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var chunk pageChunk
+		if err := json.Unmarshal(scanner.Bytes(), &chunk); err != nil {
+			t.Fatalf("unexpected json error: %v", err)
+		}
+		chunks = append(chunks, chunk)
+	}
+	if len(chunks) != 2 {
+		t.Fatalf("expected 2 chunks, got %+v", chunks)
+	}
+	second := chunks[1]
+	if second.ChunkIndex != 1 || !reflect.DeepEqual(second.HeadingPath, []string{"Docs", "Setup"}) ||
+		second.OutPath != "docs.md" || second.Score == nil || *second.Score != 0.5 ||
+		!strings.HasPrefix(second.Text, "## Setup") || second.TokenCount == 0 {
+		t.Fatalf("unexpected chunk: %+v", second)
+	}
+
+	reportBytes, err := os.ReadFile(filepath.Join(tmpDir, "report.json"))
+	if err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+	var parsed struct {
+		Chunks struct {
+			Total     int    `json:"total"`
+			Tokenizer string `json:"tokenizer"`
+		} `json:"chunks"`
+	}
+	if err := json.Unmarshal(reportBytes, &parsed); err != nil {
+		t.Fatalf("unexpected json error: %v", err)
+	}
+	if parsed.Chunks.Total != 2 || parsed.Chunks.Tokenizer != "gpt" {
+		t.Fatalf("unexpected report chunks: %+v", parsed.Chunks)
+	}
+}
@@ -0,0 +1,387 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sbstn/sitecrawl/internal/crawler"
+)
+
+// DiffFormat selects how a crawl diff is printed.
+type DiffFormat string
+
+const (
+	// DiffMarkdown prints a human-readable summary with fenced content diffs.
+	DiffMarkdown DiffFormat = "markdown"
+	// DiffJSON prints the full diff as one JSON object.
+	DiffJSON DiffFormat = "json"
+)
+
+// ParseDiffFormat validates and normalizes the diff output flag.
+func ParseDiffFormat(raw string) (DiffFormat, error) {
+	switch DiffFormat(strings.ToLower(strings.TrimSpace(raw))) {
+	case DiffMarkdown, "md":
+		return DiffMarkdown, nil
+	case DiffJSON:
+		return DiffJSON, nil
+	default:
+		return "", fmt.Errorf("invalid output %q (allowed: markdown, json)", raw)
+	}
+}
+
+// DiffOptions configures DiffCrawls.
+type DiffOptions struct {
+	// Content adds unified diffs of the page files.
+	Content bool
+	// ContextLines surround each change in content diffs.
+	ContextLines int
+	// MinScoreDelta is the smallest score change reported as a movement.
+	MinScoreDelta float64
+}
+
+// CrawlDiff is the difference between two crawl output directories.
+type CrawlDiff struct {
+	Old       DiffSide     `json:"old"`
+	New       DiffSide     `json:"new"`
+	Added     []DiffPage   `json:"added"`
+	Removed   []DiffPage   `json:"removed"`
+	Changed   []PageChange `json:"changed"`
+	Unchanged int          `json:"unchanged"`
+}
+
+// DiffSide describes one of the compared crawls.
+type DiffSide struct {
+	Path       string    `json:"path"`
+	Domain     string    `json:"domain"`
+	FinishedAt time.Time `json:"finished_at"`
+	Partial    bool      `json:"partial,omitempty"`
+	Pages      int       `json:"pages"`
+}
+
+// DiffPage is a page present in only one crawl.
+type DiffPage struct {
+	URL    string   `json:"url"`
+	Status string   `json:"status"`
+	Title  string   `json:"title,omitempty"`
+	Score  *float64 `json:"score,omitempty"`
+}
+
+// PageChange lists what changed on a page present in both crawls. Unchanged
+// aspects are omitted.
+type PageChange struct {
+	URL         string        `json:"url"`
+	Status      *StringChange `json:"status,omitempty"`
+	Title       *StringChange `json:"title,omitempty"`
+	Description *StringChange `json:"description,omitempty"`
+	Score       *ScoreChange  `json:"score,omitempty"`
+	// ContentDiff is a unified diff of the page files.
+	ContentDiff string `json:"content_diff,omitempty"`
+}
+
+// StringChange is an old and a new value.
+type StringChange struct {
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+// ScoreChange is a PageRank score movement. Delta is zero when the page was
+// scored in only one crawl.
+type ScoreChange struct {
+	Old   *float64 `json:"old"`
+	New   *float64 `json:"new"`
+	Delta float64  `json:"delta"`
+}
+
+// DiffCrawls compares the reports and page files of two output directories.
+// Pages are matched by normalized URL, ignoring the scheme and a leading
+// "www."; added and removed pages follow the new and old report order.
+func DiffCrawls(oldDir, newDir string, opts DiffOptions) (CrawlDiff, error) {
+	oldReport, err := ReadReport(oldDir)
+	if err != nil {
+		return CrawlDiff{}, err
+	}
+	newReport, err := ReadReport(newDir)
+	if err != nil {
+		return CrawlDiff{}, err
+	}
+
+	diff := CrawlDiff{
+		Old:     diffSide(oldDir, oldReport),
+		New:     diffSide(newDir, newReport),
+		Added:   []DiffPage{},
+		Removed: []DiffPage{},
+		Changed: []PageChange{},
+	}
+	oldPages := make(map[string]ReportPage, len(oldReport.Pages))
+	for _, page := range oldReport.Pages {
+		oldPages[diffKey(page.URL)] = page
+	}
+	matched := map[string]bool{}
+	for _, page := range newReport.Pages {
+		key := diffKey(page.URL)
+		oldPage, ok := oldPages[key]
+		if !ok {
+			diff.Added = append(diff.Added, toDiffPage(page))
+			continue
+		}
+		matched[key] = true
+		change, err := comparePages(oldDir, newDir, oldPage, page, opts)
+		if err != nil {
+			return CrawlDiff{}, err
+		}
+		if change == nil {
+			diff.Unchanged++
+			continue
+		}
+		diff.Changed = append(diff.Changed, *change)
+	}
+	for _, page := range oldReport.Pages {
+		if !matched[diffKey(page.URL)] {
+			diff.Removed = append(diff.Removed, toDiffPage(page))
+		}
+	}
+	return diff, nil
+}
+
+func diffSide(dir string, rep Report) DiffSide {
+	return DiffSide{
+		Path:       dir,
+		Domain:     rep.Domain,
+		FinishedAt: rep.FinishedAt,
+		Partial:    rep.Partial,
+		Pages:      len(rep.Pages),
+	}
+}
+
+func toDiffPage(page ReportPage) DiffPage {
+	return DiffPage{URL: page.URL, Status: page.Status, Title: page.Title, Score: page.Score}
+}
+
+// diffKey matches a page across crawls that may have started on http or on
+// the www host.
+func diffKey(raw string) string {
+	normalized, err := crawler.NormalizeURL(raw, true)
+	if err != nil {
+		return raw
+	}
+	parsed, err := url.Parse(normalized)
+	if err != nil {
+		return normalized
+	}
+	parsed.Scheme = ""
+	parsed.Host = strings.TrimPrefix(parsed.Host, "www.")
+	return parsed.String()
+}
+
+// comparePages returns nil when nothing reportable changed.
+func comparePages(oldDir, newDir string, oldPage, newPage ReportPage, opts DiffOptions) (*PageChange, error) {
+	change := PageChange{URL: newPage.URL}
+	changed := false
+	if oldPage.Status != newPage.Status {
+		change.Status = &StringChange{Old: oldPage.Status, New: newPage.Status}
+		changed = true
+	}
+	if oldPage.Title != newPage.Title {
+		change.Title = &StringChange{Old: oldPage.Title, New: newPage.Title}
+		changed = true
+	}
+	if oldPage.Description != newPage.Description {
+		change.Description = &StringChange{Old: oldPage.Description, New: newPage.Description}
+		changed = true
+	}
+	if score := scoreChange(oldPage.Score, newPage.Score, opts.MinScoreDelta); score != nil {
+		change.Score = score
+		changed = true
+	}
+	if opts.Content && (oldPage.OutPath != "" || newPage.OutPath != "") {
+		oldText, err := readPageFile(oldDir, oldPage.OutPath)
+		if err != nil {
+			return nil, err
+		}
+		newText, err := readPageFile(newDir, newPage.OutPath)
+		if err != nil {
+			return nil, err
+		}
+		oldName, newName := "a/"+oldPage.OutPath, "b/"+newPage.OutPath
+		if oldPage.OutPath == "" {
+			oldName = "/dev/null"
+		}
+		if newPage.OutPath == "" {
+			newName = "/dev/null"
+		}
+		if text := unifiedDiff(oldName, newName, oldText, newText, opts.ContextLines); text != "" {
+			change.ContentDiff = text
+			changed = true
+		}
+	}
+	if !changed {
+		return nil, nil
+	}
+	return &change, nil
+}
+
+func scoreChange(oldScore, newScore *float64, minDelta float64) *ScoreChange {
+	switch {
+	case oldScore == nil && newScore == nil:
+		return nil
+	case oldScore == nil || newScore == nil:
+		return &ScoreChange{Old: oldScore, New: newScore}
+	}
+	delta := *newScore - *oldScore
+	if delta == 0 || math.Abs(delta) < minDelta {
+		return nil
+	}
+	return &ScoreChange{Old: oldScore, New: newScore, Delta: delta}
+}
+
+// readPageFile reads a page file of an output directory; a page without
+// one reads as empty.
+func readPageFile(dir, outPath string) (string, error) {
+	if outPath == "" {
+		return "", nil
+	}
+	if info, err := os.Stat(dir); err == nil && !info.IsDir() {
+		dir = filepath.Dir(dir)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(outPath)))
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// WriteDiff prints diff to w in format.
+func WriteDiff(w io.Writer, diff CrawlDiff, format DiffFormat) error {
+	switch format {
+	case DiffJSON:
+		data, err := json.MarshalIndent(diff, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(append(data, '\n'))
+		return err
+	case DiffMarkdown:
+		_, err := io.WriteString(w, renderDiffMarkdown(diff))
+		return err
+	default:
+		return fmt.Errorf("unsupported diff format: %s", format)
+	}
+}
+
+func renderDiffMarkdown(diff CrawlDiff) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Crawl diff: %s\n\n", diff.New.Domain)
+	for _, side := range []struct {
+		name string
+		side DiffSide
+	}{{"Old", diff.Old}, {"New", diff.New}} {
+		partial := ""
+		if side.side.Partial {
+			partial = ", partial"
+		}
+		fmt.Fprintf(&b, "- %s: `%s` (%d pages, finished %s%s)\n", side.name, side.side.Path, side.side.Pages, side.side.FinishedAt.UTC().Format(time.RFC3339), partial)
+	}
+
+	var statuses, titles, descriptions, scores, contents []PageChange
+	for _, change := range diff.Changed {
+		if change.Status != nil {
+			statuses = append(statuses, change)
+		}
+		if change.Title != nil {
+			titles = append(titles, change)
+		}
+		if change.Description != nil {
+			descriptions = append(descriptions, change)
+		}
+		if change.Score != nil {
+			scores = append(scores, change)
+		}
+		if change.ContentDiff != "" {
+			contents = append(contents, change)
+		}
+	}
+	sort.SliceStable(scores, func(i, j int) bool {
+		return math.Abs(scores[i].Score.Delta) > math.Abs(scores[j].Score.Delta)
+	})
+
+	fmt.Fprintf(&b, "\n| Change | Pages |\n| --- | --- |\n")
+	fmt.Fprintf(&b, "| Added | %d |\n| Removed | %d |\n| Status | %d |\n| Title | %d |\n| Description | %d |\n| Score | %d |\n| Content | %d |\n| Unchanged | %d |\n",
+		len(diff.Added), len(diff.Removed), len(statuses), len(titles), len(descriptions), len(scores), len(contents), diff.Unchanged)
+
+	if len(diff.Added) > 0 {
+		fmt.Fprintf(&b, "\n## Added pages\n\n")
+		for _, page := range diff.Added {
+			fmt.Fprintf(&b, "- %s (%s)%s\n", page.URL, page.Status, titleSuffix(page.Title))
+		}
+	}
+	if len(diff.Removed) > 0 {
+		fmt.Fprintf(&b, "\n## Removed pages\n\n")
+		for _, page := range diff.Removed {
+			fmt.Fprintf(&b, "- %s (%s)%s\n", page.URL, page.Status, titleSuffix(page.Title))
+		}
+	}
+	writeChangeTable(&b, "Status changes", statuses, func(c PageChange) *StringChange { return c.Status })
+	writeChangeTable(&b, "Title changes", titles, func(c PageChange) *StringChange { return c.Title })
+	writeChangeTable(&b, "Description changes", descriptions, func(c PageChange) *StringChange { return c.Description })
+	if len(scores) > 0 {
+		fmt.Fprintf(&b, "\n## Score movements\n\n| URL | Old | New | Delta |\n| --- | --- | --- | --- |\n")
+		for _, change := range scores {
+			delta := ""
+			if change.Score.Old != nil && change.Score.New != nil {
+				delta = fmt.Sprintf("%+.6f", change.Score.Delta)
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", markdownCell(change.URL), optionalScore(change.Score.Old), optionalScore(change.Score.New), delta)
+		}
+	}
+	if len(contents) > 0 {
+		fmt.Fprintf(&b, "\n## Content changes\n")
+		for _, change := range contents {
+			// Page content may hold fences of its own.
+			fence := "```"
+			for strings.Contains(change.ContentDiff, fence) {
+				fence += "`"
+			}
+			fmt.Fprintf(&b, "\n### %s\n\n%sdiff\n%s%s\n", change.URL, fence, change.ContentDiff, fence)
+		}
+	}
+	return b.String()
+}
+
+func writeChangeTable(b *strings.Builder, heading string, changes []PageChange, field func(PageChange) *StringChange) {
+	if len(changes) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n## %s\n\n| URL | Old | New |\n| --- | --- | --- |\n", heading)
+	for _, change := range changes {
+		value := field(change)
+		fmt.Fprintf(b, "| %s | %s | %s |\n", markdownCell(change.URL), markdownCell(value.Old), markdownCell(value.New))
+	}
+}
+
+func titleSuffix(title string) string {
+	if title == "" {
+		return ""
+	}
+	return ": " + title
+}
+
+func optionalScore(score *float64) string {
+	if score == nil {
+		return "-"
+	}
+	return formatScore(*score)
+}
+
+// markdownCell keeps a value on one table row.
+func markdownCell(value string) string {
+	value = strings.Join(strings.Fields(value), " ")
+	return strings.ReplaceAll(value, "|", `\|`)
+}
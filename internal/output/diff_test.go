@@ -0,0 +1,131 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sbstn/sitecrawl/internal/crawler"
+)
+
+func writeDiffFixture(t *testing.T, pages []ReportPage, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := writeReport(Report{Domain: "example.com", Strategy: "pagerank", Pages: pages}, dirSink(dir)); err != nil {
+		t.Fatalf("write report: %v", err)
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+func TestDiffCrawls(t *testing.T) {
+	score := func(v float64) *float64 { return &v }
+	oldDir := writeDiffFixture(t, []ReportPage{
+		{URL: "https://example.com/", Status: crawler.StatusOK, Title: "Home", OutPath: "index.md", Score: score(0.5)},
+		{URL: "https://example.com/docs", Status: crawler.StatusOK, Title: "Docs", Description: "Old docs", OutPath: "docs.md", Score: score(0.3)},
+		{URL: "https://example.com/old", Status: crawler.StatusOK, Title: "Old", OutPath: "old.md"},
+		{URL: "https://example.com/flaky", Status: crawler.StatusOK, Title: "Flaky", OutPath: "flaky.md"},
+	}, map[string]string{
+		"index.md": "# Home\n",
+		"docs.md":  "# Docs\n\nStep one.\nStep two.\n",
+		"old.md":   "# Old\n",
+		"flaky.md": "# Flaky\n",
+	})
+	newDir := writeDiffFixture(t, []ReportPage{
+		// Matched despite the www host and http scheme.
+		{URL: "http://www.example.com/", Status: crawler.StatusOK, Title: "Home", OutPath: "index.md", Score: score(0.50001)},
+		{URL: "https://example.com/docs", Status: crawler.StatusOK, Title: "Docs | Example", Description: "New docs", OutPath: "docs.md", Score: score(0.2)},
+		{URL: "https://example.com/flaky", Status: crawler.StatusError, Error: "timeout"},
+		{URL: "https://example.com/new", Status: crawler.StatusOK, Title: "New", OutPath: "new.md"},
+	}, map[string]string{
+		"index.md": "# Home\n",
+		"docs.md":  "# Docs\n\nStep one.\nStep 2.\n",
+		"new.md":   "# New\n",
+	})
+
+	diff, err := DiffCrawls(oldDir, newDir, DiffOptions{Content: true, ContextLines: 3, MinScoreDelta: 0.001})
+	if err != nil {
+		t.Fatalf("diff: %v", err)
+	}
+	if len(diff.Added) != 1 || diff.Added[0].URL != "https://example.com/new" {
+		t.Fatalf("unexpected added pages: %+v", diff.Added)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0].URL != "https://example.com/old" {
+		t.Fatalf("unexpected removed pages: %+v", diff.Removed)
+	}
+	if diff.Unchanged != 1 {
+		t.Fatalf("expected home page unchanged below the score threshold, got %d", diff.Unchanged)
+	}
+	if len(diff.Changed) != 2 {
+		t.Fatalf("expected 2 changed pages, got %+v", diff.Changed)
+	}
+
+	docs := diff.Changed[0]
+	if docs.Title == nil || docs.Title.New != "Docs | Example" || docs.Description == nil || docs.Status != nil {
+		t.Fatalf("unexpected docs change: %+v", docs)
+	}
+	if docs.Score == nil || docs.Score.Delta > -0.09 {
+		t.Fatalf("expected docs score drop, got %+v", docs.Score)
+	}
+	if !strings.Contains(docs.ContentDiff, "-Step two.\n+Step 2.\n") {
+		t.Fatalf("unexpected content diff:\n%s", docs.ContentDiff)
+	}
+
+	flaky := diff.Changed[1]
+	if flaky.Status == nil || flaky.Status.Old != "ok" || flaky.Status.New != "error" {
+		t.Fatalf("unexpected flaky change: %+v", flaky)
+	}
+	if !strings.Contains(flaky.ContentDiff, "+++ /dev/null") {
+		t.Fatalf("expected content removal diff, got:\n%s", flaky.ContentDiff)
+	}
+
+	var markdown bytes.Buffer
+	if err := WriteDiff(&markdown, diff, DiffMarkdown); err != nil {
+		t.Fatalf("write markdown: %v", err)
+	}
+	for _, want := range []string{
+		"# Crawl diff: example.com",
+		"| Added | 1 |",
+		"## Removed pages\n\n- https://example.com/old (ok): Old",
+		"| https://example.com/docs | Docs | Docs \\| Example |",
+		"| https://example.com/docs | 0.300000 | 0.200000 | -0.100000 |",
+		"### https://example.com/docs\n\n```diff\n--- a/docs.md",
+	} {
+		if !strings.Contains(markdown.String(), want) {
+			t.Fatalf("expected markdown to contain %q, got:\n%s", want, markdown.String())
+		}
+	}
+
+	var jsonOut bytes.Buffer
+	if err := WriteDiff(&jsonOut, diff, DiffJSON); err != nil {
+		t.Fatalf("write json: %v", err)
+	}
+	var decoded CrawlDiff
+	if err := json.Unmarshal(jsonOut.Bytes(), &decoded); err != nil {
+		t.Fatalf("decode json: %v", err)
+	}
+	if len(decoded.Changed) != 2 || decoded.Changed[0].ContentDiff != docs.ContentDiff {
+		t.Fatalf("json round trip mismatch: %+v", decoded)
+	}
+}
+
+func TestDiffCrawlsWithoutContent(t *testing.T) {
+	pages := []ReportPage{{URL: "https://example.com/", Status: crawler.StatusOK, OutPath: "index.md"}}
+	oldDir := writeDiffFixture(t, pages, map[string]string{"index.md": "old\n"})
+	newDir := writeDiffFixture(t, pages, map[string]string{"index.md": "new\n"})
+
+	diff, err := DiffCrawls(oldDir, newDir, DiffOptions{})
+	if err != nil {
+		t.Fatalf("diff: %v", err)
+	}
+	if diff.Unchanged != 1 || len(diff.Changed) != 0 {
+		t.Fatalf("expected content to be ignored, got %+v", diff)
+	}
+}
@@ -1,4 +1,16 @@
 // Package output writes crawl artifacts:
-//   - one deterministic file per page in md/html/json format
-//   - a report.json summary for downstream agent workflows
+//   - one deterministic file per page in md/html/json format, with clean
+//     markdown converted from the page's main-content HTML
+//   - optional WARC 1.1 archives with a CDXJ index
+//   - optional SQLite databases that accumulate crawls with an FTS5 index
+//   - broken_links.csv when links were checked, and external_links.csv
+//     listing outbound links
+//   - optional link graph exports as CSV, GraphML, GEXF, or DOT, which
+//     RankCrawl reads back to recompute scores
+//   - a report.json summary for downstream agent workflows, which
+//     Summarize condenses for the report subcommand and Audit lints for the
+//     audit subcommand
+//
+// Output goes to a directory, or streams into a tar.zst, tar.gz, or zip
+// archive with deterministic entries.
 package output
@@ -0,0 +1,135 @@
+package output
+
+import (
+	"bytes"
+	"encoding/csv"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/sbstn/sitecrawl/internal/crawler"
+)
+
+// ExternalLinksCSVName lists every external link of the written pages.
+const ExternalLinksCSVName = "external_links.csv"
+
+// ReportExternalLink is an external link of a page as listed in pages.jsonl.
+type ReportExternalLink struct {
+	URL  string   `json:"url"`
+	Text string   `json:"text,omitempty"`
+	Rel  []string `json:"rel,omitempty"`
+}
+
+// ReportExternalDomain aggregates the external links pointing to one domain.
+type ReportExternalDomain struct {
+	Domain string `json:"domain"`
+	Links  int    `json:"links"`
+	Pages  int    `json:"pages"`
+	// Rel counts links per rel token, e.g. how many are sponsored.
+	Rel          map[string]int `json:"rel,omitempty"`
+	LinkingPages []string       `json:"linking_pages"`
+}
+
+// ReportExternalLinks holds the external domain inventory of report.json.
+type ReportExternalLinks struct {
+	Total   int                    `json:"total"`
+	Path    string                 `json:"path,omitempty"`
+	Domains []ReportExternalDomain `json:"domains"`
+}
+
+func toReportExternalLinks(links []crawler.ExternalLink) []ReportExternalLink {
+	converted := make([]ReportExternalLink, 0, len(links))
+	for _, link := range links {
+		converted = append(converted, ReportExternalLink{URL: link.URL, Text: link.Text, Rel: link.Rel})
+	}
+	return converted
+}
+
+// externalDomain is the link's host without a leading "www.".
+func externalDomain(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	return strings.TrimPrefix(parsed.Hostname(), "www.")
+}
+
+// buildExternalLinkIndex groups the external links of written pages by
+// domain, most linked first. It returns nil when no page links out.
+func buildExternalLinkIndex(pages []*crawler.Page) *ReportExternalLinks {
+	index := &ReportExternalLinks{Domains: []ReportExternalDomain{}}
+	byDomain := map[string]*ReportExternalDomain{}
+	for _, page := range pages {
+		if page.Status != crawler.StatusOK || len(page.ExternalLinks) == 0 {
+			continue
+		}
+		pageURL := page.FinalURL
+		if pageURL == "" {
+			pageURL = page.URL
+		}
+		for _, link := range page.ExternalLinks {
+			index.Total++
+			name := externalDomain(link.URL)
+			domain, ok := byDomain[name]
+			if !ok {
+				domain = &ReportExternalDomain{Domain: name, LinkingPages: []string{}}
+				byDomain[name] = domain
+			}
+			domain.Links++
+			domain.LinkingPages = append(domain.LinkingPages, pageURL)
+			for _, rel := range link.Rel {
+				if domain.Rel == nil {
+					domain.Rel = map[string]int{}
+				}
+				domain.Rel[rel]++
+			}
+		}
+	}
+	if index.Total == 0 {
+		return nil
+	}
+
+	for _, domain := range byDomain {
+		sort.Strings(domain.LinkingPages)
+		domain.LinkingPages = slices.Compact(domain.LinkingPages)
+		domain.Pages = len(domain.LinkingPages)
+		index.Domains = append(index.Domains, *domain)
+	}
+	sort.Slice(index.Domains, func(i, j int) bool {
+		if index.Domains[i].Links != index.Domains[j].Links {
+			return index.Domains[i].Links > index.Domains[j].Links
+		}
+		return index.Domains[i].Domain < index.Domains[j].Domain
+	})
+	return index
+}
+
+// writeExternalLinksCSV writes one row per page and external link.
+func writeExternalLinksCSV(result *crawler.CrawlResult, files fileSink) error {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.Write([]string{"source_url", "target_url", "domain", "anchor_text", "rel"}); err != nil {
+		return err
+	}
+	for _, page := range result.Pages {
+		if page.Status != crawler.StatusOK {
+			continue
+		}
+		pageURL := page.FinalURL
+		if pageURL == "" {
+			pageURL = page.URL
+		}
+		for _, link := range page.ExternalLinks {
+			row := []string{pageURL, link.URL, externalDomain(link.URL), link.Text, strings.Join(link.Rel, " ")}
+			if err := writer.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+	return files.WriteFile(ExternalLinksCSVName, buf.Bytes())
+}
@@ -0,0 +1,128 @@
+package output
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/sbstn/sitecrawl/internal/crawler"
+)
+
+func TestBuildExternalLinkIndexGroupsByDomain(t *testing.T) {
+	pages := []*crawler.Page{
+		{
+			URL: "https://example.com/", FinalURL: "https://example.com/", Status: crawler.StatusOK,
+			ExternalLinks: []crawler.ExternalLink{
+				{URL: "https://partner.org/deal", Rel: []string{"nofollow", "sponsored"}},
+				{URL: "https://www.partner.org/"},
+				{URL: "https://docs.vendor.io/"},
+			},
+		},
+		{
+			URL: "https://example.com/about", FinalURL: "https://example.com/about", Status: crawler.StatusOK,
+			ExternalLinks: []crawler.ExternalLink{{URL: "https://partner.org/", Rel: []string{"sponsored"}}},
+		},
+		{URL: "https://example.com/broken", Status: crawler.StatusError, Error: "timeout"},
+	}
+	index := buildExternalLinkIndex(pages)
+	want := &ReportExternalLinks{
+		Total: 4,
+		Domains: []ReportExternalDomain{
+			{
+				Domain:       "partner.org",
+				Links:        3,
+				Pages:        2,
+				Rel:          map[string]int{"nofollow": 1, "sponsored": 2},
+				LinkingPages: []string{"https://example.com/", "https://example.com/about"},
+			},
+			{Domain: "docs.vendor.io", Links: 1, Pages: 1, LinkingPages: []string{"https://example.com/"}},
+		},
+	}
+	if !reflect.DeepEqual(index, want) {
+		t.Fatalf("unexpected index: %+v", index)
+	}
+	if index := buildExternalLinkIndex(pages[2:]); index != nil {
+		t.Fatalf("expected nil index without external links, got %+v", index)
+	}
+}
+
+func TestWriteExternalLinks(t *testing.T) {
+	tmpDir := t.TempDir()
+	result := &crawler.CrawlResult{
+		Domain: "example.com",
+		Pages: []*crawler.Page{
+			{
+				URL: "https://example.com/", FinalURL: "https://example.com/", Status: crawler.StatusOK, Title: "Home",
+				ExternalLinks: []crawler.ExternalLink{
+					{URL: "https://partner.org/deal", Text: "Deal", Rel: []string{"nofollow", "sponsored"}},
+					{URL: "https://docs.vendor.io/", Text: "Vendor, \"docs\""},
+				},
+			},
+			{URL: "https://example.com/broken", Status: crawler.StatusError, Error: "timeout"},
+		},
+	}
+	if err := WriteWithOptions(result, tmpDir, FormatMarkdown, Options{ExternalLinksCSV: true}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rep := readReport(t, tmpDir)
+	if rep.ExternalLinks == nil || rep.ExternalLinks.Path != ExternalLinksCSVName || rep.ExternalLinks.Total != 2 {
+		t.Fatalf("unexpected external_links section: %+v", rep.ExternalLinks)
+	}
+
+	data, err := os.ReadFile(filepath.Join(tmpDir, ExternalLinksCSVName))
+	if err != nil {
+		t.Fatalf("read %s: %v", ExternalLinksCSVName, err)
+	}
+	wantCSV := "source_url,target_url,domain,anchor_text,rel\n" +
+		"https://example.com/,https://partner.org/deal,partner.org,Deal,nofollow sponsored\n" +
+		"https://example.com/,https://docs.vendor.io/,docs.vendor.io,\"Vendor, \"\"docs\"\"\",\n"
+	if string(data) != wantCSV {
+		t.Fatalf("unexpected %s:\n%s", ExternalLinksCSVName, data)
+	}
+}
+
+func TestPageJSONListsExternalLinks(t *testing.T) {
+	tmpDir := t.TempDir()
+	links := []crawler.ExternalLink{{URL: "https://partner.org/", Text: "Partner", Rel: []string{"sponsored"}}}
+	result := &crawler.CrawlResult{
+		Domain: "example.com",
+		Pages:  []*crawler.Page{{URL: "https://example.com/", FinalURL: "https://example.com/", Status: crawler.StatusOK, ExternalLinks: links}},
+	}
+	if err := WriteWithOptions(result, tmpDir, FormatJSON, Options{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rep := readReport(t, tmpDir)
+	if rep.Pages[0].ExternalLinksCount != 1 {
+		t.Fatalf("unexpected per-page count: %+v", rep.Pages[0])
+	}
+	pageData, err := os.ReadFile(filepath.Join(tmpDir, rep.Pages[0].OutPath))
+	if err != nil {
+		t.Fatalf("read page: %v", err)
+	}
+	var page struct {
+		ExternalLinks []ReportExternalLink `json:"external_links"`
+	}
+	if err := json.Unmarshal(pageData, &page); err != nil {
+		t.Fatalf("parse page: %v", err)
+	}
+	if !reflect.DeepEqual(page.ExternalLinks, []ReportExternalLink{{URL: "https://partner.org/", Text: "Partner", Rel: []string{"sponsored"}}}) {
+		t.Fatalf("unexpected page external_links: %+v", page.ExternalLinks)
+	}
+}
+
+func TestWriteExternalLinksCSVWithoutLinks(t *testing.T) {
+	tmpDir := t.TempDir()
+	result := &crawler.CrawlResult{
+		Domain: "example.com",
+		Pages:  []*crawler.Page{{URL: "https://example.com/", FinalURL: "https://example.com/", Status: crawler.StatusOK, Title: "Home"}},
+	}
+	if err := WriteWithOptions(result, tmpDir, FormatMarkdown, Options{ExternalLinksCSV: true}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rep := readReport(t, tmpDir)
+	if rep.ExternalLinks == nil || rep.ExternalLinks.Total != 0 || rep.ExternalLinks.Path != ExternalLinksCSVName {
+		t.Fatalf("expected an empty external_links section, got %+v", rep.ExternalLinks)
+	}
+}
@@ -0,0 +1,90 @@
+package output
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+
+	"github.com/sbstn/sitecrawl/internal/crawler"
+)
+
+// FieldsCSVName is the CSV export of extracted fields, one row per page.
+const FieldsCSVName = "fields.csv"
+
+// fieldsObject renders extracted fields for per-page JSON: single-cardinality
+// fields become a string (null when nothing matched), multiple-cardinality
+// fields an array.
+func fieldsObject(fields []crawler.FieldValue) map[string]any {
+	object := make(map[string]any, len(fields))
+	for _, field := range fields {
+		if field.Multiple {
+			values := field.Values
+			if values == nil {
+				values = []string{}
+			}
+			object[field.Name] = values
+			continue
+		}
+		if len(field.Values) == 0 {
+			object[field.Name] = nil
+			continue
+		}
+		object[field.Name] = field.Values[0]
+	}
+	return object
+}
+
+// writeFieldsCSV writes fields.csv with url, out_path, and one column per
+// field rule. Multiple-cardinality cells hold a JSON array; cells of rules
+// not scoped to a page stay empty.
+func writeFieldsCSV(result *crawler.CrawlResult, files fileSink) error {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	header := append([]string{"url", "out_path"}, result.FieldNames...)
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for _, page := range result.Pages {
+		if page.Status != crawler.StatusOK {
+			continue
+		}
+		pageURL := page.FinalURL
+		if pageURL == "" {
+			pageURL = page.URL
+		}
+		byName := make(map[string]crawler.FieldValue, len(page.Fields))
+		for _, field := range page.Fields {
+			byName[field.Name] = field
+		}
+		row := []string{pageURL, page.OutPath}
+		for _, name := range result.FieldNames {
+			field, ok := byName[name]
+			switch {
+			case !ok:
+				row = append(row, "")
+			case field.Multiple:
+				values := field.Values
+				if values == nil {
+					values = []string{}
+				}
+				encoded, err := json.Marshal(values)
+				if err != nil {
+					return err
+				}
+				row = append(row, string(encoded))
+			case len(field.Values) > 0:
+				row = append(row, field.Values[0])
+			default:
+				row = append(row, "")
+			}
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+	return files.WriteFile(FieldsCSVName, buf.Bytes())
+}
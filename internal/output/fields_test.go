@@ -0,0 +1,73 @@
+package output
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sbstn/sitecrawl/internal/crawler"
+)
+
+func TestWriteFieldsToJSONAndCSV(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	result := &crawler.CrawlResult{
+		Domain:     "example.com",
+		Strategy:   crawler.StrategyLimit,
+		Clean:      true,
+		FieldNames: []string{"price", "tags", "version"},
+		Pages: []*crawler.Page{
+			{
+				URL:      "https://example.com/p",
+				FinalURL: "https://example.com/p",
+				Status:   crawler.StatusOK,
+				Fields: []crawler.FieldValue{
+					{Name: "price", Values: []string{"9.99"}},
+					{Name: "tags", Values: []string{"a", "b,c"}, Multiple: true},
+					{Name: "version"},
+				},
+			},
+			{
+				URL:      "https://example.com/q",
+				FinalURL: "https://example.com/q",
+				Status:   crawler.StatusOK,
+			},
+		},
+	}
+
+	if err := Write(result, tmpDir, FormatJSON); err != nil {
+		t.Fatalf("unexpected write error: %v", err)
+	}
+
+	pageBytes, err := os.ReadFile(filepath.Join(tmpDir, "p.json"))
+	if err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+	var page struct {
+		Fields map[string]any `json:"fields"`
+	}
+	if err := json.Unmarshal(pageBytes, &page); err != nil {
+		t.Fatalf("unexpected json error: %v", err)
+	}
+	if page.Fields["price"] != "9.99" {
+		t.Fatalf("expected single price value, got %v", page.Fields["price"])
+	}
+	if tags, ok := page.Fields["tags"].([]any); !ok || len(tags) != 2 {
+		t.Fatalf("expected tags array, got %v", page.Fields["tags"])
+	}
+	if value, ok := page.Fields["version"]; !ok || value != nil {
+		t.Fatalf("expected null version, got %v", value)
+	}
+
+	csvBytes, err := os.ReadFile(filepath.Join(tmpDir, FieldsCSVName))
+	if err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+	want := "url,out_path,price,tags,version\n" +
+		"https://example.com/p,p.json,9.99,\"[\"\"a\"\",\"\"b,c\"\"]\",\n" +
+		"https://example.com/q,q.json,,,\n"
+	if string(csvBytes) != want {
+		t.Fatalf("unexpected csv:\n%s\nwant:\n%s", csvBytes, want)
+	}
+}
@@ -9,15 +9,50 @@ import (
 	"unicode"
 )
 
+// Layout controls how page files are arranged in the output directory.
+type Layout string
+
+const (
+	// LayoutFlat writes every page file into the output directory,
+	// joining path segments with underscores.
+	LayoutFlat Layout = "flat"
+	// LayoutTree mirrors URL path segments as directories.
+	LayoutTree Layout = "tree"
+)
+
+// ParseLayout validates and normalizes the layout flag.
+func ParseLayout(raw string) (Layout, error) {
+	switch Layout(strings.ToLower(strings.TrimSpace(raw))) {
+	case LayoutFlat:
+		return LayoutFlat, nil
+	case LayoutTree:
+		return LayoutTree, nil
+	default:
+		return "", fmt.Errorf("invalid layout %q (allowed: flat, tree)", raw)
+	}
+}
+
 type FilenameMapper struct {
-	format Format
-	used   map[string]string
+	format      Format
+	layout      Layout
+	primaryHost string
+	used        map[string]string
 }
 
 // NewFilenameMapper creates a deterministic URL->filename mapper for one run.
 func NewFilenameMapper(format Format) *FilenameMapper {
+	return NewLayoutMapper(format, LayoutFlat)
+}
+
+// NewLayoutMapper is NewFilenameMapper with a directory layout. Names always
+// use forward slashes.
+func NewLayoutMapper(format Format, layout Layout) *FilenameMapper {
+	if layout == "" {
+		layout = LayoutFlat
+	}
 	return &FilenameMapper{
 		format: format,
+		layout: layout,
 		used:   map[string]string{},
 	}
 }
@@ -25,6 +60,9 @@ func NewFilenameMapper(format Format) *FilenameMapper {
 // FilenameForURL maps a normalized URL to a stable file name and resolves collisions.
 func (m *FilenameMapper) FilenameForURL(rawURL string) string {
 	name := sanitizeURLPathToName(rawURL)
+	if m.layout == LayoutTree {
+		name = m.treePath(rawURL)
+	}
 	ext := string(m.format)
 	base := name + "." + ext
 	if existing, ok := m.used[base]; !ok {
@@ -84,6 +122,40 @@ func sanitizeURLPathToName(rawURL string) string {
 	return strings.Join(sanitized, "_")
 }
 
+// treePath maps /docs/guides/setup to docs/guides/setup and directory-like
+// URLs (/ and /docs/) to index files. The host of the first mapped URL is the
+// output root; pages on any other host go below a directory named after that
+// host, which cannot clash with sanitized segments since those have no dots.
+func (m *FilenameMapper) treePath(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "index"
+	}
+	var parts []string
+	host := strings.ToLower(parsed.Hostname())
+	if m.primaryHost == "" {
+		m.primaryHost = host
+	}
+	if host != m.primaryHost && host != "" {
+		parts = append(parts, host)
+	}
+	hostParts := len(parts)
+	segments := strings.Split(strings.TrimSpace(parsed.Path), "/")
+	directory := len(segments) == 0 || segments[len(segments)-1] == ""
+	for _, part := range segments {
+		if unescaped, err := url.PathUnescape(part); err == nil {
+			part = unescaped
+		}
+		if safe := sanitizeSegment(part); safe != "" {
+			parts = append(parts, safe)
+		}
+	}
+	if directory || len(parts) == hostParts {
+		parts = append(parts, "index")
+	}
+	return strings.Join(parts, "/")
+}
+
 func sanitizeSegment(segment string) string {
 	var builder strings.Builder
 	lastUnderscore := false
@@ -35,3 +35,52 @@ func TestFilenameMappingCollisionUsesStableHash(t *testing.T) {
 		t.Fatalf("expected hashed suffix in %s", second)
 	}
 }
+
+func TestFilenameMappingTreeLayout(t *testing.T) {
+	mapper := NewLayoutMapper(FormatMarkdown, LayoutTree)
+
+	cases := []struct {
+		input    string
+		expected string
+	}{
+		{"https://example.com/", "index.md"},
+		{"https://example.com/docs", "docs.md"},
+		{"https://example.com/docs/", "docs/index.md"},
+		{"https://example.com/docs/guides/setup", "docs/guides/setup.md"},
+		{"https://example.com/Docs/API%20Reference/", "docs/api_reference/index.md"},
+		{"https://www.example.com/", "www.example.com/index.md"},
+		{"https://www.example.com/docs/guides/setup", "www.example.com/docs/guides/setup.md"},
+	}
+	for _, tc := range cases {
+		if got := mapper.FilenameForURL(tc.input); got != tc.expected {
+			t.Fatalf("expected %s for %s, got %s", tc.expected, tc.input, got)
+		}
+	}
+}
+
+func TestFilenameMappingTreeLayoutCollisionUsesStableHash(t *testing.T) {
+	mapper := NewLayoutMapper(FormatHTML, LayoutTree)
+
+	first := mapper.FilenameForURL("https://example.com/docs/")
+	second := mapper.FilenameForURL("https://example.com/docs/?page=2")
+	again := mapper.FilenameForURL("https://example.com/docs/?page=2")
+
+	if first != "docs/index.html" {
+		t.Fatalf("unexpected first filename: %s", first)
+	}
+	if second != "docs/index_"+shortHash("https://example.com/docs/?page=2")+".html" {
+		t.Fatalf("expected hashed suffix in %s", second)
+	}
+	if again != second {
+		t.Fatalf("expected stable filename for repeated URL, got %s and %s", second, again)
+	}
+}
+
+func TestParseLayout(t *testing.T) {
+	if layout, err := ParseLayout(" Tree "); err != nil || layout != LayoutTree {
+		t.Fatalf("expected tree layout, got %q (%v)", layout, err)
+	}
+	if _, err := ParseLayout("nested"); err == nil {
+		t.Fatalf("expected invalid layout error")
+	}
+}
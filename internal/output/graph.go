@@ -0,0 +1,318 @@
+package output
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/sbstn/sitecrawl/internal/crawler"
+)
+
+const (
+	// GraphNodesCSVName lists every URL of the link graph with page metadata.
+	GraphNodesCSVName = "nodes.csv"
+	// GraphEdgesCSVName lists every internal link as source,target.
+	GraphEdgesCSVName = "edges.csv"
+	// GraphMLName is the link graph in GraphML.
+	GraphMLName = "graph.graphml"
+	// GraphGEXFName is the link graph in GEXF 1.3, as read by Gephi.
+	GraphGEXFName = "graph.gexf"
+	// GraphDOTName is the link graph in Graphviz DOT.
+	GraphDOTName = "graph.dot"
+
+	// graphStatusUncrawled marks link targets the crawl never visited.
+	graphStatusUncrawled = "uncrawled"
+)
+
+// GraphFormat selects a link graph export.
+type GraphFormat string
+
+const (
+	// GraphCSV writes nodes.csv and edges.csv.
+	GraphCSV GraphFormat = "csv"
+	// GraphML writes graph.graphml.
+	GraphML GraphFormat = "graphml"
+	// GraphGEXF writes graph.gexf.
+	GraphGEXF GraphFormat = "gexf"
+	// GraphDOT writes graph.dot.
+	GraphDOT GraphFormat = "dot"
+)
+
+// ParseGraphFormats parses a comma-separated list of graph exports such as
+// "csv,gexf". Duplicates are dropped; an empty list disables the export.
+func ParseGraphFormats(raw string) ([]GraphFormat, error) {
+	var formats []GraphFormat
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		if part == "" {
+			continue
+		}
+		format := GraphFormat(part)
+		switch format {
+		case GraphCSV, GraphML, GraphGEXF, GraphDOT:
+		default:
+			return nil, fmt.Errorf("invalid graph format %q (allowed: csv, graphml, gexf, dot)", part)
+		}
+		if !slices.Contains(formats, format) {
+			formats = append(formats, format)
+		}
+	}
+	return formats, nil
+}
+
+// ReportGraph lists the link graph files in report.json.
+type ReportGraph struct {
+	Files []string `json:"files"`
+	Nodes int      `json:"nodes"`
+	Edges int      `json:"edges"`
+}
+
+// graphNode is a link graph URL joined with its crawled page, if any.
+type graphNode struct {
+	id        string
+	url       string
+	page      *crawler.Page
+	inDegree  int
+	outDegree int
+}
+
+func (n graphNode) status() string {
+	if n.page == nil {
+		return graphStatusUncrawled
+	}
+	return n.page.Status
+}
+
+type graphFile struct {
+	name string
+	data []byte
+}
+
+type graphData struct {
+	domain string
+	nodes  []graphNode
+	edges  []crawler.Edge
+	// index maps URLs to positions in nodes.
+	index map[string]int
+}
+
+func buildGraphData(result *crawler.CrawlResult) graphData {
+	pages := map[string]*crawler.Page{}
+	for _, page := range result.Pages {
+		key := page.FinalURL
+		if key == "" {
+			key = page.URL
+		}
+		pages[key] = page
+	}
+	data := graphData{domain: result.Domain, edges: result.LinkGraph.Edges(), index: map[string]int{}}
+	for i, node := range result.LinkGraph.Nodes() {
+		data.index[node] = i
+		data.nodes = append(data.nodes, graphNode{id: "n" + strconv.Itoa(i), url: node, page: pages[node]})
+	}
+	for _, edge := range data.edges {
+		data.nodes[data.index[edge.From]].outDegree++
+		data.nodes[data.index[edge.To]].inDegree++
+	}
+	return data
+}
+
+// writeGraph writes the requested link graph exports. Nodes and edges are
+// sorted by URL, so the files are stable for a given graph.
+func writeGraph(result *crawler.CrawlResult, files fileSink, formats []GraphFormat) (*ReportGraph, error) {
+	data := buildGraphData(result)
+	rep := &ReportGraph{Files: []string{}, Nodes: len(data.nodes), Edges: len(data.edges)}
+	for _, format := range formats {
+		var outputs []graphFile
+		switch format {
+		case GraphCSV:
+			nodes, err := data.nodesCSV()
+			if err != nil {
+				return nil, err
+			}
+			edges, err := data.edgesCSV()
+			if err != nil {
+				return nil, err
+			}
+			outputs = []graphFile{{GraphNodesCSVName, nodes}, {GraphEdgesCSVName, edges}}
+		case GraphML:
+			outputs = []graphFile{{GraphMLName, data.graphML()}}
+		case GraphGEXF:
+			outputs = []graphFile{{GraphGEXFName, data.gexf()}}
+		case GraphDOT:
+			outputs = []graphFile{{GraphDOTName, data.dot()}}
+		default:
+			return nil, fmt.Errorf("unsupported graph format: %s", format)
+		}
+		for _, file := range outputs {
+			if err := files.WriteFile(file.name, file.data); err != nil {
+				return nil, err
+			}
+			rep.Files = append(rep.Files, file.name)
+		}
+	}
+	return rep, nil
+}
+
+func (d graphData) nodesCSV() ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	rows := [][]string{{"url", "status", "depth", "score", "title", "in_degree", "out_degree"}}
+	for _, node := range d.nodes {
+		depth, score, title := "", "", ""
+		if node.page != nil {
+			depth = strconv.Itoa(node.page.Depth)
+			title = node.page.Title
+			if node.page.Score != nil {
+				score = strconv.FormatFloat(*node.page.Score, 'g', -1, 64)
+			}
+		}
+		rows = append(rows, []string{node.url, node.status(), depth, score, title, strconv.Itoa(node.inDegree), strconv.Itoa(node.outDegree)})
+	}
+	if err := w.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (d graphData) edgesCSV() ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	rows := [][]string{{"source", "target"}}
+	for _, edge := range d.edges {
+		rows = append(rows, []string{edge.From, edge.To})
+	}
+	if err := w.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (d graphData) graphML() []byte {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<graphml xmlns="http://graphml.graphdrawing.org/xmlns">` + "\n")
+	for _, key := range []struct{ name, kind string }{
+		{"url", "string"}, {"status", "string"}, {"depth", "int"}, {"score", "double"},
+		{"title", "string"}, {"in_degree", "int"}, {"out_degree", "int"},
+	} {
+		fmt.Fprintf(&b, `  <key id="%s" for="node" attr.name="%s" attr.type="%s"/>`+"\n", key.name, key.name, key.kind)
+	}
+	fmt.Fprintf(&b, `  <graph id="%s" edgedefault="directed">`+"\n", xmlEscape(d.domain))
+	for _, node := range d.nodes {
+		fmt.Fprintf(&b, `    <node id="%s">`+"\n", node.id)
+		for _, attr := range node.attributes() {
+			fmt.Fprintf(&b, `      <data key="%s">%s</data>`+"\n", attr.name, xmlEscape(attr.value))
+		}
+		b.WriteString("    </node>\n")
+	}
+	for i, edge := range d.edges {
+		fmt.Fprintf(&b, `    <edge id="e%d" source="%s" target="%s"/>`+"\n", i, d.nodes[d.index[edge.From]].id, d.nodes[d.index[edge.To]].id)
+	}
+	b.WriteString("  </graph>\n</graphml>\n")
+	return []byte(b.String())
+}
+
+// gexfAttributes declares the node attributes; attvalues refer to them by
+// index.
+var gexfAttributes = []struct{ name, kind string }{
+	{"url", "string"}, {"status", "string"}, {"depth", "integer"}, {"score", "double"},
+	{"title", "string"}, {"in_degree", "integer"}, {"out_degree", "integer"},
+}
+
+func (d graphData) gexf() []byte {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<gexf xmlns="http://gexf.net/1.3" version="1.3">` + "\n")
+	fmt.Fprintf(&b, "  <meta>\n    <creator>sitecrawl</creator>\n    <description>Internal link graph of %s</description>\n  </meta>\n", xmlEscape(d.domain))
+	b.WriteString(`  <graph mode="static" defaultedgetype="directed">` + "\n")
+	b.WriteString(`    <attributes class="node">` + "\n")
+	for i, attr := range gexfAttributes {
+		fmt.Fprintf(&b, `      <attribute id="%d" title="%s" type="%s"/>`+"\n", i, attr.name, attr.kind)
+	}
+	b.WriteString("    </attributes>\n    <nodes>\n")
+	positions := map[string]int{}
+	for i, attr := range gexfAttributes {
+		positions[attr.name] = i
+	}
+	for _, node := range d.nodes {
+		fmt.Fprintf(&b, `      <node id="%s" label="%s">`+"\n        <attvalues>\n", node.id, xmlEscape(node.label()))
+		for _, attr := range node.attributes() {
+			fmt.Fprintf(&b, `          <attvalue for="%d" value="%s"/>`+"\n", positions[attr.name], xmlEscape(attr.value))
+		}
+		b.WriteString("        </attvalues>\n      </node>\n")
+	}
+	b.WriteString("    </nodes>\n    <edges>\n")
+	for i, edge := range d.edges {
+		fmt.Fprintf(&b, `      <edge id="e%d" source="%s" target="%s"/>`+"\n", i, d.nodes[d.index[edge.From]].id, d.nodes[d.index[edge.To]].id)
+	}
+	b.WriteString("    </edges>\n  </graph>\n</gexf>\n")
+	return []byte(b.String())
+}
+
+func (d graphData) dot() []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %s {\n", dotQuote(d.domain))
+	for _, node := range d.nodes {
+		attrs := []string{"label=" + dotQuote(node.label())}
+		for _, attr := range node.attributes() {
+			if attr.name == "url" || attr.name == "title" {
+				continue
+			}
+			attrs = append(attrs, attr.name+"="+dotQuote(attr.value))
+		}
+		fmt.Fprintf(&b, "  %s [%s];\n", dotQuote(node.url), strings.Join(attrs, ", "))
+	}
+	for _, edge := range d.edges {
+		fmt.Fprintf(&b, "  %s -> %s;\n", dotQuote(edge.From), dotQuote(edge.To))
+	}
+	b.WriteString("}\n")
+	return []byte(b.String())
+}
+
+type graphAttribute struct {
+	name  string
+	value string
+}
+
+// attributes lists the node's known attributes; depth, score, and title are
+// omitted for uncrawled URLs.
+func (n graphNode) attributes() []graphAttribute {
+	attrs := []graphAttribute{{"url", n.url}, {"status", n.status()}}
+	if n.page != nil {
+		attrs = append(attrs, graphAttribute{"depth", strconv.Itoa(n.page.Depth)})
+		if n.page.Score != nil {
+			attrs = append(attrs, graphAttribute{"score", strconv.FormatFloat(*n.page.Score, 'g', -1, 64)})
+		}
+		if n.page.Title != "" {
+			attrs = append(attrs, graphAttribute{"title", n.page.Title})
+		}
+	}
+	return append(attrs,
+		graphAttribute{"in_degree", strconv.Itoa(n.inDegree)},
+		graphAttribute{"out_degree", strconv.Itoa(n.outDegree)},
+	)
+}
+
+// label is the page title, falling back to the URL.
+func (n graphNode) label() string {
+	if n.page != nil && n.page.Title != "" {
+		return n.page.Title
+	}
+	return n.url
+}
+
+func xmlEscape(value string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(value))
+	return b.String()
+}
+
+func dotQuote(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	return `"` + strings.ReplaceAll(value, `"`, `\"`) + `"`
+}
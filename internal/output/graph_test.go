@@ -0,0 +1,127 @@
+package output
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sbstn/sitecrawl/internal/crawler"
+)
+
+func graphTestResult() *crawler.CrawlResult {
+	score := func(v float64) *float64 { return &v }
+	graph := crawler.NewLinkGraph()
+	graph.AddEdge("https://example.com/", "https://example.com/docs")
+	graph.AddEdge("https://example.com/", "https://example.com/private")
+	graph.AddEdge("https://example.com/docs", "https://example.com/")
+	return &crawler.CrawlResult{
+		Domain:    "example.com",
+		Strategy:  crawler.StrategyPageRank,
+		LinkGraph: graph,
+		Pages: []*crawler.Page{
+			{URL: "https://example.com/", FinalURL: "https://example.com/", Status: crawler.StatusOK, Title: `Home & "Welcome"`, Score: score(0.6)},
+			{URL: "https://example.com/docs", FinalURL: "https://example.com/docs", Depth: 1, Status: crawler.StatusOK, Title: "Docs", Score: score(0.4)},
+		},
+	}
+}
+
+func TestParseGraphFormats(t *testing.T) {
+	formats, err := ParseGraphFormats(" GEXF, csv,gexf ,")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(formats, []GraphFormat{GraphGEXF, GraphCSV}) {
+		t.Fatalf("unexpected formats: %v", formats)
+	}
+	if formats, err := ParseGraphFormats(""); err != nil || formats != nil {
+		t.Fatalf("expected empty list to disable export, got %v, %v", formats, err)
+	}
+	if _, err := ParseGraphFormats("csv,json"); err == nil {
+		t.Fatalf("expected error for json")
+	}
+}
+
+func TestWriteGraphFormats(t *testing.T) {
+	tmpDir := t.TempDir()
+	rep, err := writeGraph(graphTestResult(), dirSink(tmpDir), []GraphFormat{GraphCSV, GraphML, GraphGEXF, GraphDOT})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := &ReportGraph{Files: []string{GraphNodesCSVName, GraphEdgesCSVName, GraphMLName, GraphGEXFName, GraphDOTName}, Nodes: 3, Edges: 3}
+	if !reflect.DeepEqual(rep, want) {
+		t.Fatalf("unexpected report: %+v", rep)
+	}
+	read := func(name string) string {
+		data, err := os.ReadFile(filepath.Join(tmpDir, name))
+		if err != nil {
+			t.Fatalf("read %s: %v", name, err)
+		}
+		return string(data)
+	}
+
+	nodes := read(GraphNodesCSVName)
+	wantNodes := "url,status,depth,score,title,in_degree,out_degree\n" +
+		"https://example.com/,ok,0,0.6,\"Home & \"\"Welcome\"\"\",1,2\n" +
+		"https://example.com/docs,ok,1,0.4,Docs,1,1\n" +
+		"https://example.com/private,uncrawled,,,,1,0\n"
+	if nodes != wantNodes {
+		t.Fatalf("unexpected nodes.csv:\n%s", nodes)
+	}
+	edges := read(GraphEdgesCSVName)
+	wantEdges := "source,target\n" +
+		"https://example.com/,https://example.com/docs\n" +
+		"https://example.com/,https://example.com/private\n" +
+		"https://example.com/docs,https://example.com/\n"
+	if edges != wantEdges {
+		t.Fatalf("unexpected edges.csv:\n%s", edges)
+	}
+
+	for _, name := range []string{GraphMLName, GraphGEXFName} {
+		content := read(name)
+		decoder := xml.NewDecoder(strings.NewReader(content))
+		for {
+			if _, err := decoder.Token(); err != nil {
+				if err.Error() != "EOF" {
+					t.Fatalf("%s is not well-formed XML: %v\n%s", name, err, content)
+				}
+				break
+			}
+		}
+		if !strings.Contains(content, `source="n2" target="n0"`) && !strings.Contains(content, `source="n1" target="n0"`) {
+			t.Fatalf("%s is missing the docs -> home edge:\n%s", name, content)
+		}
+	}
+	if gexf := read(GraphGEXFName); !strings.Contains(gexf, `<node id="n0" label="Home &amp; &#34;Welcome&#34;">`) {
+		t.Fatalf("unexpected gexf node label:\n%s", gexf)
+	}
+
+	dot := read(GraphDOTName)
+	for _, line := range []string{
+		`digraph "example.com" {`,
+		`  "https://example.com/" [label="Home & \"Welcome\"", status="ok", depth="0", score="0.6", in_degree="1", out_degree="2"];`,
+		`  "https://example.com/private" [label="https://example.com/private", status="uncrawled", in_degree="1", out_degree="0"];`,
+		`  "https://example.com/docs" -> "https://example.com/";`,
+	} {
+		if !strings.Contains(dot, line+"\n") {
+			t.Fatalf("expected dot line %q, got:\n%s", line, dot)
+		}
+	}
+}
+
+func TestFinishWritesGraphIntoReport(t *testing.T) {
+	tmpDir := t.TempDir()
+	result := graphTestResult()
+	if err := WriteWithOptions(result, tmpDir, FormatMarkdown, Options{Graph: []GraphFormat{GraphDOT}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rep := readReport(t, tmpDir)
+	if rep.Graph == nil || !reflect.DeepEqual(rep.Graph.Files, []string{GraphDOTName}) || rep.Graph.Edges != 3 {
+		t.Fatalf("unexpected graph report: %+v", rep.Graph)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, GraphDOTName)); err != nil {
+		t.Fatalf("expected graph.dot: %v", err)
+	}
+}
@@ -0,0 +1,127 @@
+package output
+
+import (
+	"sort"
+
+	"github.com/sbstn/sitecrawl/internal/crawler"
+)
+
+// maxImagePages bounds the "pages with the most undescribed images" listing.
+const maxImagePages = 20
+
+// ReportImage is an image of a page as listed in pages.jsonl.
+type ReportImage struct {
+	URL        string   `json:"url"`
+	Alt        string   `json:"alt"`
+	AltMissing bool     `json:"alt_missing,omitempty"`
+	Width      int      `json:"width,omitempty"`
+	Height     int      `json:"height,omitempty"`
+	Srcset     []string `json:"srcset,omitempty"`
+	Lazy       bool     `json:"lazy,omitempty"`
+}
+
+// ReportMissingAltImage is an image without alt text and the page showing it.
+type ReportMissingAltImage struct {
+	PageURL  string `json:"page_url"`
+	ImageURL string `json:"image_url"`
+}
+
+// ReportImagePage counts the images and missing alt texts of one page.
+type ReportImagePage struct {
+	URL        string `json:"url"`
+	OutPath    string `json:"out_path,omitempty"`
+	Images     int    `json:"images"`
+	MissingAlt int    `json:"missing_alt"`
+}
+
+// ReportImages holds the image inventory of report.json.
+type ReportImages struct {
+	Total              int                     `json:"total"`
+	MissingAlt         int                     `json:"missing_alt"`
+	Decorative         int                     `json:"decorative"`
+	MissingAltImages   []ReportMissingAltImage `json:"missing_alt_images"`
+	TopPagesMissingAlt []ReportImagePage       `json:"top_pages_missing_alt"`
+}
+
+func toReportImages(images []crawler.Image) []ReportImage {
+	converted := make([]ReportImage, 0, len(images))
+	for _, image := range images {
+		converted = append(converted, ReportImage{
+			URL:        image.URL,
+			Alt:        image.Alt,
+			AltMissing: image.AltMissing,
+			Width:      image.Width,
+			Height:     image.Height,
+			Srcset:     image.Srcset,
+			Lazy:       image.Lazy,
+		})
+	}
+	return converted
+}
+
+// buildImageAudit summarizes alt-text coverage over written pages. Images
+// without an alt attribute count as missing; alt="" marks decorative images.
+// It returns nil when no page has images.
+func buildImageAudit(pages []*crawler.Page) *ReportImages {
+	audit := &ReportImages{
+		MissingAltImages:   []ReportMissingAltImage{},
+		TopPagesMissingAlt: []ReportImagePage{},
+	}
+	for _, page := range pages {
+		if page.Status != crawler.StatusOK || len(page.Images) == 0 {
+			continue
+		}
+		pageURL := page.FinalURL
+		if pageURL == "" {
+			pageURL = page.URL
+		}
+		missing := 0
+		for _, image := range page.Images {
+			audit.Total++
+			switch {
+			case image.AltMissing:
+				missing++
+				audit.MissingAltImages = append(audit.MissingAltImages, ReportMissingAltImage{
+					PageURL:  pageURL,
+					ImageURL: image.URL,
+				})
+			case image.Alt == "":
+				audit.Decorative++
+			}
+		}
+		audit.MissingAlt += missing
+		if missing > 0 {
+			audit.TopPagesMissingAlt = append(audit.TopPagesMissingAlt, ReportImagePage{
+				URL:        pageURL,
+				OutPath:    page.OutPath,
+				Images:     len(page.Images),
+				MissingAlt: missing,
+			})
+		}
+	}
+	if audit.Total == 0 {
+		return nil
+	}
+
+	sort.SliceStable(audit.MissingAltImages, func(i, j int) bool {
+		if audit.MissingAltImages[i].PageURL != audit.MissingAltImages[j].PageURL {
+			return audit.MissingAltImages[i].PageURL < audit.MissingAltImages[j].PageURL
+		}
+		return audit.MissingAltImages[i].ImageURL < audit.MissingAltImages[j].ImageURL
+	})
+	sort.SliceStable(audit.TopPagesMissingAlt, func(i, j int) bool {
+		if audit.TopPagesMissingAlt[i].MissingAlt != audit.TopPagesMissingAlt[j].MissingAlt {
+			return audit.TopPagesMissingAlt[i].MissingAlt > audit.TopPagesMissingAlt[j].MissingAlt
+		}
+		return audit.TopPagesMissingAlt[i].URL < audit.TopPagesMissingAlt[j].URL
+	})
+	if len(audit.TopPagesMissingAlt) > maxImagePages {
+		audit.TopPagesMissingAlt = audit.TopPagesMissingAlt[:maxImagePages]
+	}
+	return audit
+}
+
+// markdownImage renders an inline markdown image.
+func markdownImage(alt, src string) string {
+	return "![" + escapeLinkText(alt) + "](" + markdownDestination(src) + ")"
+}
@@ -0,0 +1,63 @@
+package output
+
+import (
+	"testing"
+
+	"github.com/sbstn/sitecrawl/internal/crawler"
+)
+
+func TestBuildImageAudit(t *testing.T) {
+	pages := []*crawler.Page{
+		{
+			URL:    "https://example.com/a",
+			Status: crawler.StatusOK,
+			Images: []crawler.Image{
+				{URL: "https://example.com/1.png", AltMissing: true},
+				{URL: "https://example.com/2.png", Alt: ""},
+				{URL: "https://example.com/3.png", Alt: "Chart"},
+			},
+		},
+		{
+			URL:    "https://example.com/b",
+			Status: crawler.StatusOK,
+			Images: []crawler.Image{
+				{URL: "https://example.com/4.png", AltMissing: true},
+				{URL: "https://example.com/5.png", AltMissing: true},
+			},
+		},
+		{
+			URL:    "https://example.com/c",
+			Status: crawler.StatusError,
+			Images: []crawler.Image{{URL: "https://example.com/6.png", AltMissing: true}},
+		},
+	}
+
+	audit := buildImageAudit(pages)
+	if audit == nil {
+		t.Fatalf("expected image audit")
+	}
+	if audit.Total != 5 || audit.MissingAlt != 3 || audit.Decorative != 1 {
+		t.Fatalf("unexpected totals: %+v", audit)
+	}
+	if len(audit.TopPagesMissingAlt) != 2 || audit.TopPagesMissingAlt[0].URL != "https://example.com/b" {
+		t.Fatalf("expected page b first, got %+v", audit.TopPagesMissingAlt)
+	}
+	if len(audit.MissingAltImages) != 3 {
+		t.Fatalf("expected 3 images missing alt, got %d", len(audit.MissingAltImages))
+	}
+}
+
+func TestBuildImageAuditWithoutImages(t *testing.T) {
+	if audit := buildImageAudit([]*crawler.Page{{Status: crawler.StatusOK}}); audit != nil {
+		t.Fatalf("expected nil audit without images, got %+v", audit)
+	}
+}
+
+func TestMarkdownImage(t *testing.T) {
+	if got := markdownImage("A [big] chart", "https://example.com/a.png"); got != `![A \[big\] chart](https://example.com/a.png)` {
+		t.Fatalf("unexpected markdown: %s", got)
+	}
+	if got := markdownImage("", "https://example.com/a (1).png"); got != "![](<https://example.com/a (1).png>)" {
+		t.Fatalf("unexpected markdown: %s", got)
+	}
+}
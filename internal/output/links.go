@@ -0,0 +1,78 @@
+package output
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strconv"
+
+	"github.com/sbstn/sitecrawl/internal/crawler"
+)
+
+// BrokenLinksCSVName lists one row per page linking to a broken target.
+const BrokenLinksCSVName = "broken_links.csv"
+
+// ReportLinkSource is a page linking to a broken target and its anchor text.
+type ReportLinkSource struct {
+	URL  string `json:"url"`
+	Text string `json:"text,omitempty"`
+}
+
+// ReportBrokenLink is a broken link target with the pages linking to it.
+type ReportBrokenLink struct {
+	URL        string             `json:"url"`
+	StatusCode int                `json:"status_code,omitempty"`
+	Error      string             `json:"error,omitempty"`
+	Sources    []ReportLinkSource `json:"sources"`
+}
+
+// ReportBrokenLinks holds the link check summary of report.json.
+type ReportBrokenLinks struct {
+	Mode          string             `json:"mode"`
+	Path          string             `json:"path"`
+	Links         int                `json:"links"`
+	Checked       int                `json:"checked"`
+	SkippedRobots int                `json:"skipped_robots"`
+	Broken        int                `json:"broken"`
+	Targets       []ReportBrokenLink `json:"targets"`
+}
+
+// writeBrokenLinks writes broken_links.csv, which has only its header when
+// every checked link works.
+func writeBrokenLinks(check *crawler.LinkCheck, files fileSink) (*ReportBrokenLinks, error) {
+	rep := &ReportBrokenLinks{
+		Mode:          string(check.Mode),
+		Path:          BrokenLinksCSVName,
+		Links:         check.Links,
+		Checked:       check.Checked,
+		SkippedRobots: check.SkippedRobots,
+		Broken:        len(check.Broken),
+		Targets:       []ReportBrokenLink{},
+	}
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.Write([]string{"source_url", "target_url", "anchor_text", "status", "error"}); err != nil {
+		return nil, err
+	}
+	for _, broken := range check.Broken {
+		target := ReportBrokenLink{URL: broken.URL, StatusCode: broken.StatusCode, Error: broken.Error}
+		status := ""
+		if broken.StatusCode != 0 {
+			status = strconv.Itoa(broken.StatusCode)
+		}
+		for _, source := range broken.Sources {
+			target.Sources = append(target.Sources, ReportLinkSource{URL: source.URL, Text: source.Text})
+			if err := writer.Write([]string{source.URL, broken.URL, source.Text, status, broken.Error}); err != nil {
+				return nil, err
+			}
+		}
+		rep.Targets = append(rep.Targets, target)
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, err
+	}
+	if err := files.WriteFile(BrokenLinksCSVName, buf.Bytes()); err != nil {
+		return nil, err
+	}
+	return rep, nil
+}
@@ -0,0 +1,88 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/sbstn/sitecrawl/internal/crawler"
+)
+
+func TestFinishWritesBrokenLinks(t *testing.T) {
+	tmpDir := t.TempDir()
+	result := &crawler.CrawlResult{
+		Domain: "example.com",
+		Pages: []*crawler.Page{
+			{URL: "https://example.com/", FinalURL: "https://example.com/", Status: crawler.StatusOK, Title: "Home"},
+			{URL: "https://example.com/docs", FinalURL: "https://example.com/docs", Depth: 1, Status: crawler.StatusOK, Title: "Docs"},
+		},
+	}
+	result.LinkCheck = &crawler.LinkCheck{
+		Mode:    crawler.LinkCheckAll,
+		Links:   4,
+		Checked: 3,
+		Broken: []crawler.BrokenLink{
+			{
+				URL:        "https://example.com/private",
+				StatusCode: 404,
+				Sources: []crawler.LinkSource{
+					{URL: "https://example.com/", Text: `Private, "old"`},
+					{URL: "https://example.com/docs"},
+				},
+			},
+			{URL: "https://down.example.org/", Error: "dial tcp: connection refused", Sources: []crawler.LinkSource{{URL: "https://example.com/", Text: "Partner"}}},
+		},
+	}
+	if err := WriteWithOptions(result, tmpDir, FormatMarkdown, Options{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rep := readReport(t, tmpDir)
+	want := &ReportBrokenLinks{
+		Mode:    "all",
+		Path:    BrokenLinksCSVName,
+		Links:   4,
+		Checked: 3,
+		Broken:  2,
+		Targets: []ReportBrokenLink{
+			{URL: "https://example.com/private", StatusCode: 404, Sources: []ReportLinkSource{
+				{URL: "https://example.com/", Text: `Private, "old"`},
+				{URL: "https://example.com/docs"},
+			}},
+			{URL: "https://down.example.org/", Error: "dial tcp: connection refused", Sources: []ReportLinkSource{{URL: "https://example.com/", Text: "Partner"}}},
+		},
+	}
+	if !reflect.DeepEqual(rep.BrokenLinks, want) {
+		t.Fatalf("unexpected broken_links section: %+v", rep.BrokenLinks)
+	}
+
+	data, err := os.ReadFile(filepath.Join(tmpDir, BrokenLinksCSVName))
+	if err != nil {
+		t.Fatalf("read %s: %v", BrokenLinksCSVName, err)
+	}
+	wantCSV := "source_url,target_url,anchor_text,status,error\n" +
+		"https://example.com/,https://example.com/private,\"Private, \"\"old\"\"\",404,\n" +
+		"https://example.com/docs,https://example.com/private,,404,\n" +
+		"https://example.com/,https://down.example.org/,Partner,,dial tcp: connection refused\n"
+	if string(data) != wantCSV {
+		t.Fatalf("unexpected %s:\n%s", BrokenLinksCSVName, data)
+	}
+}
+
+func TestFinishWithoutLinkCheckOmitsBrokenLinks(t *testing.T) {
+	tmpDir := t.TempDir()
+	result := &crawler.CrawlResult{
+		Domain: "example.com",
+		Pages:  []*crawler.Page{{URL: "https://example.com/", FinalURL: "https://example.com/", Status: crawler.StatusOK, Title: "Home"}},
+	}
+	if err := WriteWithOptions(result, tmpDir, FormatMarkdown, Options{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rep := readReport(t, tmpDir); rep.BrokenLinks != nil {
+		t.Fatalf("expected no broken_links section, got %+v", rep.BrokenLinks)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, BrokenLinksCSVName)); !os.IsNotExist(err) {
+		t.Fatalf("expected no %s, got %v", BrokenLinksCSVName, err)
+	}
+}
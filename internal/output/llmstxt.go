@@ -0,0 +1,204 @@
+package output
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/sbstn/sitecrawl/internal/crawler"
+)
+
+const (
+	// LLMsTxtName is the curated page index following the llms.txt convention.
+	LLMsTxtName = "llms.txt"
+	// LLMsFullTxtName concatenates the cleaned markdown of every page.
+	LLMsFullTxtName = "llms-full.txt"
+)
+
+// llmsRootGroup collects pages that do not live under a URL section.
+const llmsRootGroup = "Pages"
+
+type llmsGroup struct {
+	name  string
+	pages []*crawler.Page
+}
+
+// writeLLMsTxt writes llms.txt and llms-full.txt for the written pages.
+func writeLLMsTxt(result *crawler.CrawlResult, files fileSink) error {
+	groups := groupLLMsPages(result)
+	if err := files.WriteFile(LLMsTxtName, []byte(renderLLMsTxt(result, groups))); err != nil {
+		return err
+	}
+	return files.WriteFile(LLMsFullTxtName, []byte(renderLLMsFullTxt(groups)))
+}
+
+// groupLLMsPages groups written pages by their first path segment. Segments
+// holding a single top-level page (e.g. /about) fold into the root group.
+// With PageRank, pages and groups are ordered by score; otherwise by crawl
+// order.
+func groupLLMsPages(result *crawler.CrawlResult) []llmsGroup {
+	var pages []*crawler.Page
+	for _, page := range result.Pages {
+		if page.Status == crawler.StatusOK {
+			pages = append(pages, page)
+		}
+	}
+	if result.Strategy == crawler.StrategyPageRank {
+		sort.SliceStable(pages, func(i, j int) bool {
+			return pageScore(pages[i]) > pageScore(pages[j])
+		})
+	}
+
+	nested := map[string]bool{}
+	for _, page := range pages {
+		if segments := pathSegments(pageLocation(page)); len(segments) > 1 {
+			nested[segments[0]] = true
+		}
+	}
+
+	var groups []llmsGroup
+	index := map[string]int{}
+	for _, page := range pages {
+		name := llmsRootGroup
+		if segments := pathSegments(pageLocation(page)); len(segments) > 0 && nested[segments[0]] {
+			name = sectionTitle(segments[0])
+		}
+		i, ok := index[name]
+		if !ok {
+			i = len(groups)
+			index[name] = i
+			groups = append(groups, llmsGroup{name: name})
+		}
+		groups[i].pages = append(groups[i].pages, page)
+	}
+	if i, ok := index[llmsRootGroup]; ok && i > 0 {
+		root := groups[i]
+		copy(groups[1:i+1], groups[:i])
+		groups[0] = root
+	}
+	return groups
+}
+
+func renderLLMsTxt(result *crawler.CrawlResult, groups []llmsGroup) string {
+	var builder strings.Builder
+	builder.WriteString("# ")
+	builder.WriteString(result.Domain)
+	builder.WriteString("\n")
+	if summary := siteSummary(groups); summary != "" {
+		builder.WriteString("\n> ")
+		builder.WriteString(summary)
+		builder.WriteString("\n")
+	}
+	for _, group := range groups {
+		builder.WriteString("\n## ")
+		builder.WriteString(group.name)
+		builder.WriteString("\n\n")
+		for _, page := range group.pages {
+			builder.WriteString("- [")
+			builder.WriteString(escapeLinkText(pageLabel(page)))
+			builder.WriteString("](")
+			builder.WriteString(markdownDestination(pageLocation(page)))
+			builder.WriteString(")")
+			if description := oneLine(page.Description); description != "" {
+				builder.WriteString(": ")
+				builder.WriteString(description)
+			}
+			builder.WriteString("\n")
+		}
+	}
+	return builder.String()
+}
+
+func renderLLMsFullTxt(groups []llmsGroup) string {
+	var parts []string
+	for _, group := range groups {
+		for _, page := range group.pages {
+			var builder strings.Builder
+			builder.WriteString("# ")
+			builder.WriteString(oneLine(pageLabel(page)))
+			builder.WriteString("\n\nSource: ")
+			builder.WriteString(pageLocation(page))
+			builder.WriteString("\n\n")
+			builder.WriteString(strings.TrimSpace(pageMarkdown(page)))
+			builder.WriteString("\n")
+			parts = append(parts, builder.String())
+		}
+	}
+	return strings.Join(parts, "\n---\n\n")
+}
+
+// siteSummary uses the root page description, if the root page was written.
+func siteSummary(groups []llmsGroup) string {
+	for _, group := range groups {
+		for _, page := range group.pages {
+			if len(pathSegments(pageLocation(page))) == 0 {
+				return oneLine(page.Description)
+			}
+		}
+	}
+	return ""
+}
+
+func pageLocation(page *crawler.Page) string {
+	if page.FinalURL != "" {
+		return page.FinalURL
+	}
+	return page.URL
+}
+
+func pageLabel(page *crawler.Page) string {
+	if title := oneLine(page.Title); title != "" {
+		return title
+	}
+	return pageLocation(page)
+}
+
+func pageScore(page *crawler.Page) float64 {
+	if page.Score == nil {
+		return 0
+	}
+	return *page.Score
+}
+
+func pathSegments(rawURL string) []string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil
+	}
+	var segments []string
+	for _, segment := range strings.Split(parsed.Path, "/") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return segments
+}
+
+// sectionTitle turns a path segment such as "api-reference" into
+// "Api Reference".
+func sectionTitle(segment string) string {
+	if unescaped, err := url.PathUnescape(segment); err == nil {
+		segment = unescaped
+	}
+	words := strings.FieldsFunc(segment, func(r rune) bool {
+		return r == '-' || r == '_' || r == '.' || r == ' '
+	})
+	for i, word := range words {
+		first, size := utf8.DecodeRuneInString(word)
+		words[i] = string(unicode.ToUpper(first)) + word[size:]
+	}
+	if len(words) == 0 {
+		return segment
+	}
+	return strings.Join(words, " ")
+}
+
+func escapeLinkText(text string) string {
+	return strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`).Replace(text)
+}
+
+func oneLine(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
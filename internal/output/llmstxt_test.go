@@ -0,0 +1,102 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sbstn/sitecrawl/internal/crawler"
+)
+
+func TestWriteLLMsTxt(t *testing.T) {
+	tmpDir := t.TempDir()
+	score := func(v float64) *float64 { return &v }
+	result := &crawler.CrawlResult{
+		Domain:   "example.com",
+		Strategy: crawler.StrategyPageRank,
+		Clean:    true,
+		Pages: []*crawler.Page{
+			{URL: "https://example.com/docs/install", Status: crawler.StatusOK, Title: "Install", MainText: "Install it.", Score: score(0.2)},
+			{URL: "https://example.com/", Status: crawler.StatusOK, Title: "Example", Description: "An example   site.", MainText: "Welcome.", Score: score(0.4)},
+			{URL: "https://example.com/about", Status: crawler.StatusOK, Title: "About [us]", MainText: "About.", Score: score(0.1)},
+			{URL: "https://example.com/docs/api-reference/", Status: crawler.StatusOK, Description: "All endpoints.", MainHTML: "<p>Endpoints.</p>", Score: score(0.3)},
+			{URL: "https://example.com/broken", Status: crawler.StatusError},
+		},
+	}
+
+	if err := WriteWithOptions(result, tmpDir, FormatMarkdown, Options{LLMsTxt: true}); err != nil {
+		t.Fatalf("unexpected write error: %v", err)
+	}
+
+	index, err := os.ReadFile(filepath.Join(tmpDir, LLMsTxtName))
+	if err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+	wantIndex := `# example.com
+
+> An example site.
+
+## Pages
+
+- [Example](https://example.com/): An example site.
+- [About \[us\]](https://example.com/about)
+
+## Docs
+
+- [https://example.com/docs/api-reference/](https://example.com/docs/api-reference/): All endpoints.
+- [Install](https://example.com/docs/install)
+`
+	if string(index) != wantIndex {
+		t.Fatalf("unexpected llms.txt:\n%s", index)
+	}
+
+	full, err := os.ReadFile(filepath.Join(tmpDir, LLMsFullTxtName))
+	if err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+	wantFull := `# Example
+
+Source: https://example.com/
+
+Welcome.
+
+---
+
+# About [us]
+
+Source: https://example.com/about
+
+About.
+
+---
+
+# https://example.com/docs/api-reference/
+
+Source: https://example.com/docs/api-reference/
+
+Endpoints.
+
+---
+
+# Install
+
+Source: https://example.com/docs/install
+
+Install it.
+`
+	if string(full) != wantFull {
+		t.Fatalf("unexpected llms-full.txt:\n%s", full)
+	}
+}
+
+func TestSectionTitle(t *testing.T) {
+	for segment, want := range map[string]string{
+		"api-reference_v2": "Api Reference V2",
+		"über-uns":         "Über Uns",
+		"%C3%A9quipe":      "Équipe",
+	} {
+		if got := sectionTitle(segment); got != want {
+			t.Fatalf("sectionTitle(%q) = %q, want %q", segment, got, want)
+		}
+	}
+}
@@ -0,0 +1,586 @@
+package output
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"github.com/sbstn/sitecrawl/internal/crawler"
+)
+
+// hardBreak marks a <br> while inline content is assembled so whitespace
+// collapsing does not eat the trailing spaces of the markdown line break.
+const hardBreak = "\x00"
+
+var (
+	spaceRun          = regexp.MustCompile(`[ \t\r\n\f]+`)
+	orderedListMarker = regexp.MustCompile(`^(\d+)([.)])( |$)`)
+	languageClass     = regexp.MustCompile(`^(?:language|lang)-([A-Za-z0-9_+#.-]+)$`)
+)
+
+// skippedElements never contribute markdown.
+var skippedElements = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Template: true,
+	atom.Head:     true,
+	atom.Svg:      true,
+	atom.Iframe:   true,
+	atom.Button:   true,
+	atom.Select:   true,
+	atom.Textarea: true,
+}
+
+// blockElements start a new markdown block.
+var blockElements = map[atom.Atom]bool{
+	atom.Address: true, atom.Article: true, atom.Aside: true, atom.Blockquote: true,
+	atom.Body: true, atom.Center: true, atom.Dd: true, atom.Details: true,
+	atom.Dialog: true, atom.Dir: true, atom.Div: true, atom.Dl: true,
+	atom.Dt: true, atom.Fieldset: true, atom.Figcaption: true, atom.Figure: true,
+	atom.Footer: true, atom.Form: true, atom.H1: true, atom.H2: true,
+	atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Header: true, atom.Hgroup: true, atom.Hr: true, atom.Li: true,
+	atom.Main: true, atom.Menu: true, atom.Nav: true, atom.Ol: true,
+	atom.P: true, atom.Pre: true, atom.Section: true, atom.Summary: true,
+	atom.Table: true, atom.Ul: true,
+}
+
+// htmlToMarkdown converts an HTML fragment to GitHub-flavored markdown.
+// Links and images are resolved against baseURL. The result ends with a
+// newline unless it is empty.
+func htmlToMarkdown(fragment, baseURL string) string {
+	doc, err := html.Parse(strings.NewReader(fragment))
+	if err != nil {
+		return ""
+	}
+	body := findElement(doc, atom.Body)
+	if body == nil {
+		return ""
+	}
+	converter := &markdownConverter{}
+	if base, err := url.Parse(baseURL); err == nil && base.IsAbs() {
+		converter.base = base
+	}
+	out := strings.Join(converter.blocks(body), "\n\n")
+	if out == "" {
+		return ""
+	}
+	return out + "\n"
+}
+
+// pageMarkdown converts a page's main content, falling back to its plain
+// text when no main HTML was captured.
+func pageMarkdown(page *crawler.Page) string {
+	baseURL := page.FinalURL
+	if baseURL == "" {
+		baseURL = page.URL
+	}
+	if converted := htmlToMarkdown(page.MainHTML, baseURL); converted != "" {
+		return converted
+	}
+	return page.MainText + "\n"
+}
+
+type markdownConverter struct {
+	base *url.URL
+}
+
+// blocks renders the children of n as a list of markdown blocks. Runs of
+// inline content between block elements become paragraphs.
+func (c *markdownConverter) blocks(n *html.Node) []string {
+	var blocks []string
+	var inline strings.Builder
+	flush := func() {
+		if paragraph := finishInline(inline.String()); paragraph != "" {
+			blocks = append(blocks, escapeBlockStart(paragraph))
+		}
+		inline.Reset()
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		switch {
+		case child.Type == html.TextNode:
+			inline.WriteString(c.inline(child))
+		case child.Type != html.ElementNode || skippedElements[child.DataAtom]:
+		case blockElements[child.DataAtom]:
+			flush()
+			blocks = append(blocks, c.block(child)...)
+		case child.DataAtom != atom.A && containsBlock(child):
+			flush()
+			blocks = append(blocks, c.blocks(child)...)
+		default:
+			inline.WriteString(c.inline(child))
+		}
+	}
+	flush()
+	return blocks
+}
+
+// block renders one block-level element.
+func (c *markdownConverter) block(n *html.Node) []string {
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		text := strings.ReplaceAll(finishInline(c.inlineChildren(n)), "  \n", " ")
+		if text == "" {
+			return nil
+		}
+		level := int(n.Data[1] - '0')
+		return []string{strings.Repeat("#", level) + " " + text}
+	case atom.P:
+		if paragraph := finishInline(c.inlineChildren(n)); paragraph != "" {
+			return []string{escapeBlockStart(paragraph)}
+		}
+		return nil
+	case atom.Hr:
+		return []string{"---"}
+	case atom.Pre:
+		return []string{c.codeBlock(n)}
+	case atom.Ul, atom.Ol, atom.Menu, atom.Dir:
+		if list := c.list(n); list != "" {
+			return []string{list}
+		}
+		return nil
+	case atom.Blockquote:
+		inner := c.blocks(n)
+		if len(inner) == 0 {
+			return nil
+		}
+		return []string{prefixLines(strings.Join(inner, "\n\n"), "> ", ">")}
+	case atom.Table:
+		return c.table(n)
+	case atom.Dt:
+		if term := finishInline(c.inlineChildren(n)); term != "" {
+			return []string{"**" + term + "**"}
+		}
+		return nil
+	default:
+		return c.blocks(n)
+	}
+}
+
+// inline renders n and its descendants as inline markdown.
+func (c *markdownConverter) inline(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return escapeMarkdownText(spaceRun.ReplaceAllString(n.Data, " "))
+	}
+	if n.Type != html.ElementNode || skippedElements[n.DataAtom] {
+		return ""
+	}
+	switch n.DataAtom {
+	case atom.Br:
+		return hardBreak
+	case atom.Img:
+		return c.image(n)
+	case atom.A:
+		return c.link(n)
+	case atom.Strong, atom.B:
+		return wrapInline(c.inlineChildren(n), "**")
+	case atom.Em, atom.I, atom.Cite:
+		return wrapInline(c.inlineChildren(n), "*")
+	case atom.Del, atom.S, atom.Strike:
+		return wrapInline(c.inlineChildren(n), "~~")
+	case atom.Code, atom.Kbd, atom.Samp, atom.Tt:
+		return codeSpan(spaceRun.ReplaceAllString(textContent(n), " "))
+	case atom.Input:
+		if strings.EqualFold(attrValue(n, "type"), "checkbox") {
+			if hasAttr(n, "checked") {
+				return "[x] "
+			}
+			return "[ ] "
+		}
+		return ""
+	}
+	text := c.inlineChildren(n)
+	if blockElements[n.DataAtom] {
+		return " " + text + " "
+	}
+	return text
+}
+
+func (c *markdownConverter) inlineChildren(n *html.Node) string {
+	var builder strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		builder.WriteString(c.inline(child))
+	}
+	return builder.String()
+}
+
+func (c *markdownConverter) link(n *html.Node) string {
+	text := strings.TrimSpace(strings.ReplaceAll(c.inlineChildren(n), hardBreak, " "))
+	href, ok := c.resolve(attrValue(n, "href"))
+	if !ok {
+		return text
+	}
+	if text == "" {
+		text = escapeMarkdownText(href)
+	}
+	destination := markdownDestination(href)
+	if title := strings.TrimSpace(attrValue(n, "title")); title != "" {
+		destination += ` "` + strings.ReplaceAll(title, `"`, `\"`) + `"`
+	}
+	return "[" + text + "](" + destination + ")"
+}
+
+func (c *markdownConverter) image(n *html.Node) string {
+	var src string
+	for _, key := range []string{"data-src", "data-lazy-src", "data-original", "src"} {
+		if value := strings.TrimSpace(attrValue(n, key)); value != "" && !strings.HasPrefix(value, "data:") {
+			src = value
+			break
+		}
+	}
+	if src == "" {
+		if fields := strings.Fields(attrValue(n, "srcset")); len(fields) > 0 {
+			src = fields[0]
+		}
+	}
+	resolved, ok := c.resolve(src)
+	if !ok {
+		return ""
+	}
+	return markdownImage(strings.Join(strings.Fields(attrValue(n, "alt")), " "), resolved)
+}
+
+// resolve absolutizes raw against the base URL. Empty and script URLs are
+// rejected.
+func (c *markdownConverter) resolve(raw string) (string, bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || strings.HasPrefix(strings.ToLower(raw), "javascript:") {
+		return "", false
+	}
+	ref, err := url.Parse(raw)
+	if err != nil {
+		return raw, true
+	}
+	if c.base != nil {
+		ref = c.base.ResolveReference(ref)
+	}
+	return ref.String(), true
+}
+
+// codeBlock renders <pre> as a fenced code block. The language comes from a
+// language-x or lang-x class (or data-lang) on the <pre> or its <code> child.
+func (c *markdownConverter) codeBlock(n *html.Node) string {
+	language := codeLanguage(n)
+	if language == "" {
+		if code := findElement(n, atom.Code); code != nil {
+			language = codeLanguage(code)
+		}
+	}
+	code := strings.TrimRight(strings.TrimPrefix(textContent(n), "\n"), " \t\r\n")
+	fence := strings.Repeat("`", max(3, longestRun(code, '`')+1))
+	return fence + language + "\n" + code + "\n" + fence
+}
+
+func codeLanguage(n *html.Node) string {
+	if lang := strings.TrimSpace(attrValue(n, "data-lang")); lang != "" {
+		return strings.ToLower(lang)
+	}
+	for _, class := range strings.Fields(attrValue(n, "class")) {
+		if match := languageClass.FindStringSubmatch(class); match != nil {
+			return strings.ToLower(match[1])
+		}
+	}
+	return ""
+}
+
+// list renders <ul>/<ol>. Items are indented to their marker width so nested
+// blocks stay inside the item; items holding paragraphs make the list loose.
+func (c *markdownConverter) list(n *html.Node) string {
+	ordered := n.DataAtom == atom.Ol
+	number := 1
+	if start, err := strconv.Atoi(strings.TrimSpace(attrValue(n, "start"))); ordered && err == nil {
+		number = start
+	}
+	var items []string
+	loose := false
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode || child.DataAtom != atom.Li {
+			continue
+		}
+		marker := "- "
+		if ordered {
+			marker = strconv.Itoa(number) + ". "
+			number++
+		}
+		if findElement(child, atom.P) != nil {
+			loose = true
+		}
+		blocks := c.blocks(child)
+		var body strings.Builder
+		for i, block := range blocks {
+			if i > 0 {
+				if isListBlock(block) && !loose {
+					body.WriteString("\n")
+				} else {
+					body.WriteString("\n\n")
+				}
+			}
+			body.WriteString(block)
+		}
+		if body.Len() == 0 {
+			items = append(items, strings.TrimSpace(marker))
+			continue
+		}
+		indent := strings.Repeat(" ", len(marker))
+		items = append(items, marker+strings.TrimPrefix(prefixLines(body.String(), indent, ""), indent))
+	}
+	if loose {
+		return strings.Join(items, "\n\n")
+	}
+	return strings.Join(items, "\n")
+}
+
+// table renders a GFM table laid out by crawler.LayoutTable, so rowspan and
+// colspan repeat a cell's content in every position it covers. The header is
+// the first row; further header rows are merged into it column by column as
+// "Group / Column". A <caption> becomes a paragraph above.
+func (c *markdownConverter) table(n *html.Node) []string {
+	layout := crawler.LayoutTable(n)
+	var blocks []string
+	if layout.Caption != nil {
+		if text := finishInline(c.inlineChildren(layout.Caption)); text != "" {
+			blocks = append(blocks, escapeBlockStart(text))
+		}
+	}
+	if len(layout.Cells) == 0 || len(layout.Cells[0]) == 0 {
+		return blocks
+	}
+
+	rows := layout.Grid(c.tableCell)
+	headerRows := max(layout.HeaderRows, 1)
+	rows = append([][]string{crawler.MergeHeaderRows(rows[:headerRows])}, rows[headerRows:]...)
+	separators := make([]string, len(rows[0]))
+	for i, cell := range layout.Cells[0] {
+		align := ""
+		if cell != nil {
+			align = cellAlign(cell)
+		}
+		switch align {
+		case "left":
+			separators[i] = ":---"
+		case "center":
+			separators[i] = ":---:"
+		case "right":
+			separators[i] = "---:"
+		default:
+			separators[i] = "---"
+		}
+	}
+
+	lines := []string{"| " + strings.Join(rows[0], " | ") + " |", "| " + strings.Join(separators, " | ") + " |"}
+	for _, row := range rows[1:] {
+		lines = append(lines, "| "+strings.Join(row, " | ")+" |")
+	}
+	return append(blocks, strings.Join(lines, "\n"))
+}
+
+// tableCell renders a cell on one line: block content is joined with <br>
+// and pipes are escaped.
+func (c *markdownConverter) tableCell(cell *html.Node) string {
+	var parts []string
+	for _, block := range c.blocks(cell) {
+		block = strings.ReplaceAll(block, "  \n", "<br>")
+		parts = append(parts, strings.ReplaceAll(block, "\n", " "))
+	}
+	return strings.ReplaceAll(strings.Join(parts, "<br>"), "|", `\|`)
+}
+
+func cellAlign(cell *html.Node) string {
+	if align := strings.ToLower(strings.TrimSpace(attrValue(cell, "align"))); align != "" {
+		return align
+	}
+	for _, declaration := range strings.Split(attrValue(cell, "style"), ";") {
+		key, value, ok := strings.Cut(declaration, ":")
+		if ok && strings.EqualFold(strings.TrimSpace(key), "text-align") {
+			return strings.ToLower(strings.TrimSpace(value))
+		}
+	}
+	return ""
+}
+
+// finishInline collapses whitespace in assembled inline content and turns
+// hard-break markers into markdown line breaks.
+func finishInline(text string) string {
+	text = strings.TrimSpace(spaceRun.ReplaceAllString(text, " "))
+	if !strings.Contains(text, hardBreak) {
+		return text
+	}
+	lines := strings.Split(text, hardBreak)
+	kept := lines[:0]
+	for _, line := range lines {
+		if line = strings.TrimSpace(line); line != "" {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "  \n")
+}
+
+// wrapInline wraps text in an emphasis delimiter, keeping surrounding
+// whitespace outside so the delimiters stay valid.
+func wrapInline(text, delimiter string) string {
+	trimmed := strings.Trim(text, " \n"+hardBreak)
+	if trimmed == "" {
+		return text
+	}
+	leading := text[:len(text)-len(strings.TrimLeft(text, " \n"+hardBreak))]
+	trailing := text[len(strings.TrimRight(text, " \n"+hardBreak)):]
+	return leading + delimiter + trimmed + delimiter + trailing
+}
+
+// codeSpan wraps text in a backtick run longer than any run inside it.
+func codeSpan(text string) string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return ""
+	}
+	fence := strings.Repeat("`", longestRun(text, '`')+1)
+	if strings.HasPrefix(text, "`") || strings.HasSuffix(text, "`") {
+		text = " " + text + " "
+	}
+	return fence + text + fence
+}
+
+// escapeMarkdownText escapes characters that would otherwise be read as
+// markdown syntax. Underscores inside words are left alone.
+func escapeMarkdownText(text string) string {
+	var builder strings.Builder
+	for i := 0; i < len(text); i++ {
+		ch := text[i]
+		switch ch {
+		case '\\', '*', '`', '[', ']', '<':
+			builder.WriteByte('\\')
+		case '_':
+			if i == 0 || i == len(text)-1 || !isWordByte(text[i-1]) || !isWordByte(text[i+1]) {
+				builder.WriteByte('\\')
+			}
+		}
+		builder.WriteByte(ch)
+	}
+	return builder.String()
+}
+
+// escapeBlockStart escapes a paragraph start that would be read as a
+// heading, list item, or thematic break.
+func escapeBlockStart(text string) string {
+	if match := orderedListMarker.FindStringSubmatchIndex(text); match != nil {
+		return text[:match[3]] + `\` + text[match[3]:]
+	}
+	switch {
+	case strings.HasPrefix(text, "#"),
+		strings.HasPrefix(text, ">"),
+		strings.HasPrefix(text, "+ "),
+		strings.HasPrefix(text, "- "),
+		strings.HasPrefix(text, "="),
+		text == "-" || text == "+" || strings.HasPrefix(text, "---"):
+		return `\` + text
+	}
+	return text
+}
+
+// markdownDestination renders a link destination, using the <...> form when
+// the URL contains characters that would end a plain destination.
+func markdownDestination(target string) string {
+	if strings.ContainsAny(target, " ()<>") {
+		return "<" + strings.NewReplacer("<", "%3C", ">", "%3E").Replace(target) + ">"
+	}
+	return target
+}
+
+func prefixLines(text, prefix, emptyPrefix string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = emptyPrefix
+		} else {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// isListBlock reports whether block is a list that may directly follow item
+// text. Ordered lists not starting at 1 cannot interrupt a paragraph, so they
+// need a blank line.
+func isListBlock(block string) bool {
+	return strings.HasPrefix(block, "- ") || block == "-" ||
+		strings.HasPrefix(block, "1. ") || block == "1."
+}
+
+func containsBlock(n *html.Node) bool {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && (blockElements[child.DataAtom] || containsBlock(child)) {
+			return true
+		}
+	}
+	return false
+}
+
+func findElement(n *html.Node, a atom.Atom) *html.Node {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode {
+			continue
+		}
+		if child.DataAtom == a {
+			return child
+		}
+		if found := findElement(child, a); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var builder strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && child.DataAtom == atom.Br {
+			builder.WriteString("\n")
+			continue
+		}
+		builder.WriteString(textContent(child))
+	}
+	return builder.String()
+}
+
+func attrValue(n *html.Node, key string) string {
+	for _, attr := range n.Attr {
+		if strings.EqualFold(attr.Key, key) {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+func hasAttr(n *html.Node, key string) bool {
+	for _, attr := range n.Attr {
+		if strings.EqualFold(attr.Key, key) {
+			return true
+		}
+	}
+	return false
+}
+
+func longestRun(text string, ch byte) int {
+	longest, current := 0, 0
+	for i := 0; i < len(text); i++ {
+		if text[i] == ch {
+			current++
+			longest = max(longest, current)
+		} else {
+			current = 0
+		}
+	}
+	return longest
+}
+
+func isWordByte(ch byte) bool {
+	return ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9' || ch >= 0x80
+}
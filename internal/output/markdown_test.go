@@ -0,0 +1,55 @@
+package output
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sbstn/sitecrawl/internal/crawler"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite golden files in testdata")
+
+const goldenBaseURL = "https://example.com/docs/page"
+
+func TestHTMLToMarkdownGolden(t *testing.T) {
+	inputs, err := filepath.Glob(filepath.Join("testdata", "markdown", "*.html"))
+	if err != nil {
+		t.Fatalf("unexpected glob error: %v", err)
+	}
+	if len(inputs) == 0 {
+		t.Fatalf("expected golden inputs in testdata/markdown")
+	}
+	for _, input := range inputs {
+		name := strings.TrimSuffix(filepath.Base(input), ".html")
+		t.Run(name, func(t *testing.T) {
+			source, err := os.ReadFile(input)
+			if err != nil {
+				t.Fatalf("unexpected read error: %v", err)
+			}
+			got := htmlToMarkdown(string(source), goldenBaseURL)
+			goldenPath := strings.TrimSuffix(input, ".html") + ".md"
+			if *updateGolden {
+				if err := os.WriteFile(goldenPath, []byte(got), 0o644); err != nil {
+					t.Fatalf("unexpected write error: %v", err)
+				}
+			}
+			want, err := os.ReadFile(goldenPath)
+			if err != nil {
+				t.Fatalf("unexpected read error: %v", err)
+			}
+			if got != string(want) {
+				t.Fatalf("markdown mismatch for %s\n--- want\n%s\n--- got\n%s", name, want, got)
+			}
+		})
+	}
+}
+
+func TestPageMarkdownFallsBackToMainText(t *testing.T) {
+	page := &crawler.Page{URL: "https://example.com/", MainText: "Plain body"}
+	if got := pageMarkdown(page); got != "Plain body\n" {
+		t.Fatalf("unexpected markdown: %q", got)
+	}
+}
@@ -0,0 +1,450 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/sbstn/sitecrawl/internal/crawler"
+)
+
+const (
+	// PackManifestName lists which pages made it into the context pack.
+	PackManifestName = "context-manifest.json"
+
+	packSeparator = "\n\n---\n\n"
+	// minTruncatedTokens is the smallest remaining budget worth filling with
+	// a truncated section.
+	minTruncatedTokens = 32
+)
+
+// PackOrder ranks pages for the context pack.
+type PackOrder string
+
+const (
+	// PackOrderScore ranks by PageRank score, falling back to crawl order.
+	PackOrderScore PackOrder = "score"
+	// PackOrderDepth ranks shallow pages first.
+	PackOrderDepth PackOrder = "depth"
+	// PackOrderCrawl keeps crawl order.
+	PackOrderCrawl PackOrder = "crawl"
+)
+
+const (
+	packIncluded  = "included"
+	packTruncated = "truncated"
+	packDropped   = "dropped"
+)
+
+// PackOptions configures the context pack. Format selects context.md or
+// context.json.
+type PackOptions struct {
+	MaxTokens int
+	Order     PackOrder
+	Tokenizer Tokenizer
+	Format    Format
+}
+
+// ParsePackOrder validates and normalizes the pack order flag.
+func ParsePackOrder(raw string) (PackOrder, error) {
+	switch PackOrder(strings.ToLower(strings.TrimSpace(raw))) {
+	case PackOrderScore:
+		return PackOrderScore, nil
+	case PackOrderDepth:
+		return PackOrderDepth, nil
+	case PackOrderCrawl:
+		return PackOrderCrawl, nil
+	default:
+		return "", fmt.Errorf("invalid pack order %q (allowed: score, depth, crawl)", raw)
+	}
+}
+
+type packManifestPage struct {
+	URL              string   `json:"url"`
+	Title            string   `json:"title,omitempty"`
+	Rank             int      `json:"rank"`
+	Score            *float64 `json:"score,omitempty"`
+	Status           string   `json:"status"`
+	Tokens           int      `json:"tokens"`
+	OriginalTokens   int      `json:"original_tokens"`
+	SectionsTotal    int      `json:"sections_total"`
+	SectionsIncluded int      `json:"sections_included"`
+}
+
+type packManifest struct {
+	Path      string             `json:"path"`
+	Format    string             `json:"format"`
+	Budget    int                `json:"budget"`
+	Tokens    int                `json:"tokens"`
+	Tokenizer string             `json:"tokenizer"`
+	Order     string             `json:"order"`
+	Pages     []packManifestPage `json:"pages"`
+}
+
+// ReportPack describes the context pack and its manifest in report.json.
+type ReportPack struct {
+	Path      string `json:"path"`
+	Manifest  string `json:"manifest"`
+	Budget    int    `json:"budget"`
+	Tokens    int    `json:"tokens"`
+	Included  int    `json:"included"`
+	Truncated int    `json:"truncated"`
+	Dropped   int    `json:"dropped"`
+}
+
+type packPageJSON struct {
+	URL     string   `json:"url"`
+	Title   string   `json:"title,omitempty"`
+	Score   *float64 `json:"score,omitempty"`
+	Status  string   `json:"status"`
+	Content string   `json:"content"`
+}
+
+type packDocumentJSON struct {
+	Domain string         `json:"domain"`
+	Budget int            `json:"budget"`
+	Pages  []packPageJSON `json:"pages"`
+}
+
+type packSection struct {
+	index  int
+	blocks []string
+	text   string
+	tokens int
+	weight float64
+}
+
+// writePack selects pages in rank order and writes the context file plus its
+// manifest. Each page is added whole when it fits; otherwise its most
+// informative sections are kept, and as a last resort its best section is cut
+// on a word boundary. Token accounting is per part and conservative, so the
+// written file never exceeds the budget.
+func writePack(result *crawler.CrawlResult, files fileSink, opts PackOptions) (*ReportPack, error) {
+	tokenizer := opts.Tokenizer
+	name := "context.md"
+	if opts.Format == FormatJSON {
+		name = "context.json"
+	}
+	manifest := packManifest{
+		Path:      name,
+		Format:    string(opts.Format),
+		Budget:    opts.MaxTokens,
+		Tokenizer: string(tokenizer),
+		Order:     string(opts.Order),
+		Pages:     []packManifestPage{},
+	}
+
+	header := "# Context pack: " + result.Domain + "\n\n"
+	envelope := packDocumentJSON{Domain: result.Domain, Budget: opts.MaxTokens, Pages: []packPageJSON{}}
+	if opts.Format == FormatJSON {
+		encoded, err := marshalPack(envelope)
+		if err != nil {
+			return nil, err
+		}
+		header = string(encoded)
+	}
+	remaining := opts.MaxTokens - tokenizer.Count(header)
+	if remaining < 0 {
+		return nil, fmt.Errorf("pack budget of %d tokens is smaller than the pack header", opts.MaxTokens)
+	}
+
+	var markdownParts []string
+	for rank, page := range rankPackPages(result.Pages, opts.Order) {
+		sections := packSections(page, tokenizer)
+		entry := packManifestPage{
+			URL:           pageLocation(page),
+			Title:         oneLine(page.Title),
+			Rank:          rank + 1,
+			Score:         page.Score,
+			SectionsTotal: len(sections),
+		}
+		entry.OriginalTokens = sumSectionTokens(sections)
+
+		render := func(kept []packSection, status string) (string, int, error) {
+			texts := make([]string, 0, len(kept))
+			for _, section := range kept {
+				texts = append(texts, section.text)
+			}
+			content := strings.Join(texts, "\n\n")
+			if opts.Format == FormatJSON {
+				encoded, err := marshalPack(packPageJSON{
+					URL:     entry.URL,
+					Title:   entry.Title,
+					Score:   page.Score,
+					Status:  status,
+					Content: content,
+				})
+				if err != nil {
+					return "", 0, err
+				}
+				return content, tokenizer.Count(string(encoded) + ","), nil
+			}
+			part := "## " + pageLabel(page) + "\n\nSource: " + entry.URL + "\n\n" + content + packSeparator
+			return content, tokenizer.Count(part), nil
+		}
+
+		kept, status := sections, packIncluded
+		content, cost, err := render(kept, status)
+		if err != nil {
+			return nil, err
+		}
+		if cost > remaining {
+			kept, status = nil, packTruncated
+			base, baseCost, err := render(nil, status)
+			if err != nil {
+				return nil, err
+			}
+			content, cost = base, baseCost
+			for _, section := range rankSections(sections) {
+				candidate := insertSection(kept, section)
+				candidateContent, candidateCost, err := render(candidate, status)
+				if err != nil {
+					return nil, err
+				}
+				if candidateCost <= remaining {
+					kept, content, cost = candidate, candidateContent, candidateCost
+				}
+			}
+			if len(kept) == 0 && len(sections) > 0 && remaining-baseCost >= minTruncatedTokens {
+				best := rankSections(sections)[0]
+				if best.text = truncateSection(best, remaining-baseCost, tokenizer); best.text != "" {
+					kept = []packSection{best}
+					content, cost, err = render(kept, status)
+					if err != nil {
+						return nil, err
+					}
+				}
+			}
+			if len(kept) == 0 || cost > remaining {
+				entry.Status = packDropped
+				manifest.Pages = append(manifest.Pages, entry)
+				continue
+			}
+		}
+
+		remaining -= cost
+		entry.Status = status
+		entry.Tokens = tokenizer.Count(content)
+		entry.SectionsIncluded = len(kept)
+		manifest.Pages = append(manifest.Pages, entry)
+		if opts.Format == FormatJSON {
+			envelope.Pages = append(envelope.Pages, packPageJSON{
+				URL:     entry.URL,
+				Title:   entry.Title,
+				Score:   page.Score,
+				Status:  status,
+				Content: content,
+			})
+		} else {
+			markdownParts = append(markdownParts, "## "+pageLabel(page)+"\n\nSource: "+entry.URL+"\n\n"+content)
+		}
+	}
+
+	document := header + strings.Join(markdownParts, packSeparator) + "\n"
+	if opts.Format == FormatJSON {
+		encoded, err := marshalPack(envelope)
+		if err != nil {
+			return nil, err
+		}
+		document = string(encoded) + "\n"
+	}
+	manifest.Tokens = tokenizer.Count(document)
+	if err := files.WriteFile(name, []byte(document)); err != nil {
+		return nil, err
+	}
+	manifestJSON, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := files.WriteFile(PackManifestName, manifestJSON); err != nil {
+		return nil, err
+	}
+
+	summary := &ReportPack{Path: name, Manifest: PackManifestName, Budget: opts.MaxTokens, Tokens: manifest.Tokens}
+	for _, entry := range manifest.Pages {
+		switch entry.Status {
+		case packIncluded:
+			summary.Included++
+		case packTruncated:
+			summary.Truncated++
+		default:
+			summary.Dropped++
+		}
+	}
+	return summary, nil
+}
+
+// rankPackPages orders written pages for the pack.
+func rankPackPages(pages []*crawler.Page, order PackOrder) []*crawler.Page {
+	var ranked []*crawler.Page
+	for _, page := range pages {
+		if page.Status == crawler.StatusOK {
+			ranked = append(ranked, page)
+		}
+	}
+	switch order {
+	case PackOrderScore:
+		sort.SliceStable(ranked, func(i, j int) bool {
+			return pageScore(ranked[i]) > pageScore(ranked[j])
+		})
+	case PackOrderDepth:
+		sort.SliceStable(ranked, func(i, j int) bool {
+			return ranked[i].Depth < ranked[j].Depth
+		})
+	}
+	return ranked
+}
+
+// packSections splits a page's markdown at headings, demoting headings by
+// two levels so they nest under the page's "##" title.
+func packSections(page *crawler.Page, tokenizer Tokenizer) []packSection {
+	var sections []packSection
+	for i, section := range splitMarkdownSections(pageMarkdown(page)) {
+		blocks := make([]string, len(section.blocks))
+		for j, block := range section.blocks {
+			if markdownHeading.MatchString(block) {
+				level := len(block) - len(strings.TrimLeft(block, "#"))
+				block = strings.Repeat("#", min(level+2, 6)) + block[level:]
+			}
+			blocks[j] = block
+		}
+		text := strings.Join(blocks, "\n\n")
+		tokens := tokenizer.Count(text)
+		sections = append(sections, packSection{
+			index:  i,
+			blocks: blocks,
+			text:   text,
+			tokens: tokens,
+			weight: sectionWeight(text, tokens, i),
+		})
+	}
+	return sections
+}
+
+// sectionWeight estimates how informative a section is per token: distinct
+// content words divided by tokens. The lead section gets a bonus since it
+// usually summarizes the page.
+func sectionWeight(text string, tokens, index int) float64 {
+	if tokens == 0 {
+		return 0
+	}
+	terms := map[string]struct{}{}
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}) {
+		if len([]rune(word)) >= 4 {
+			terms[word] = struct{}{}
+		}
+	}
+	weight := float64(len(terms)) / float64(tokens)
+	if index == 0 {
+		weight *= 1.5
+	}
+	return weight
+}
+
+// rankSections orders sections by weight, most informative first.
+func rankSections(sections []packSection) []packSection {
+	ranked := append([]packSection(nil), sections...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].weight > ranked[j].weight
+	})
+	return ranked
+}
+
+// insertSection adds section to kept, preserving document order.
+func insertSection(kept []packSection, section packSection) []packSection {
+	out := make([]packSection, 0, len(kept)+1)
+	inserted := false
+	for _, existing := range kept {
+		if !inserted && section.index < existing.index {
+			out = append(out, section)
+			inserted = true
+		}
+		out = append(out, existing)
+	}
+	if !inserted {
+		out = append(out, section)
+	}
+	return out
+}
+
+// truncateSection keeps the leading blocks of section that fit in budget
+// tokens, leaving room for an ellipsis marker. The first block that does not
+// fit is cut on a word boundary unless it is a code block or table, so the
+// kept text keeps its headings and line breaks.
+func truncateSection(section packSection, budget int, tokenizer Tokenizer) string {
+	budget -= tokenizer.Count("…")
+	var kept []string
+	for _, block := range section.blocks {
+		text := strings.Join(append(kept, block), "\n\n")
+		if tokenizer.Count(text) <= budget {
+			kept = append(kept, block)
+			continue
+		}
+		if !isCodeFence(block) && !strings.HasPrefix(block, "|") {
+			used := 0
+			if len(kept) > 0 {
+				used = tokenizer.Count(strings.Join(kept, "\n\n") + "\n\n")
+			}
+			if cut := truncateWords(block, budget-used, tokenizer); cut != "" {
+				return strings.Join(append(kept, cut), "\n\n") + " …"
+			}
+		}
+		break
+	}
+	if len(kept) == 0 {
+		return ""
+	}
+	return strings.Join(kept, "\n\n") + "\n\n…"
+}
+
+// truncateWords keeps the leading words of block that fit in budget tokens.
+// Lines that fit whole are kept unchanged.
+func truncateWords(block string, budget int, tokenizer Tokenizer) string {
+	var lines []string
+	used := 0
+	for _, line := range strings.Split(block, "\n") {
+		var words []string
+		for _, word := range strings.Fields(line) {
+			tokens := tokenizer.Count(word)
+			if used+tokens > budget {
+				if len(words) > 0 {
+					indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+					lines = append(lines, indent+strings.Join(words, " "))
+				}
+				return strings.Join(lines, "\n")
+			}
+			words = append(words, word)
+			used += tokens
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// isCodeFence reports whether a markdown block is a fenced code block.
+func isCodeFence(block string) bool {
+	trimmed := strings.TrimSpace(block)
+	return strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")
+}
+
+func sumSectionTokens(sections []packSection) int {
+	total := 0
+	for _, section := range sections {
+		total += section.tokens
+	}
+	return total
+}
+
+func marshalPack(value any) ([]byte, error) {
+	var builder strings.Builder
+	encoder := json.NewEncoder(&builder)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+	return []byte(strings.TrimSuffix(builder.String(), "\n")), nil
+}
@@ -0,0 +1,185 @@
+package output
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sbstn/sitecrawl/internal/crawler"
+)
+
+func TestWritePackMarkdown(t *testing.T) {
+	tmpDir := t.TempDir()
+	score := func(v float64) *float64 { return &v }
+	long := strings.Repeat("filler words repeated again and again ", 40)
+	result := &crawler.CrawlResult{
+		Domain:   "example.com",
+		Strategy: crawler.StrategyPageRank,
+		Clean:    true,
+		Pages: []*crawler.Page{
+			{
+				URL:      "https://example.com/guide",
+				Status:   crawler.StatusOK,
+				Title:    "Guide",
+				MainHTML: "<p>Guide intro explaining installation, configuration, deployment.</p><h2>Details</h2><p>" + long + "</p>",
+				Score:    score(0.3),
+			},
+			{
+				URL:      "https://example.com/",
+				Status:   crawler.StatusOK,
+				Title:    "Home",
+				MainHTML: "<p>Welcome home.</p>",
+				Score:    score(0.5),
+			},
+			{
+				URL:      "https://example.com/huge",
+				Status:   crawler.StatusOK,
+				Title:    "Huge",
+				MainHTML: "<p>" + long + long + "</p>",
+				Score:    score(0.1),
+			},
+		},
+	}
+	opts := PackOptions{MaxTokens: 60, Order: PackOrderScore, Tokenizer: TokenizerGPT, Format: FormatMarkdown}
+
+	summary, err := writePack(result, dirSink(tmpDir), opts)
+	if err != nil {
+		t.Fatalf("unexpected pack error: %v", err)
+	}
+
+	document, err := os.ReadFile(filepath.Join(tmpDir, "context.md"))
+	if err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+	if tokens := TokenizerGPT.Count(string(document)); tokens > opts.MaxTokens || tokens != summary.Tokens {
+		t.Fatalf("expected at most %d tokens (reported %d), got %d", opts.MaxTokens, summary.Tokens, tokens)
+	}
+	if !strings.HasPrefix(string(document), "# Context pack: example.com\n\n## Home\n\nSource: https://example.com/\n\nWelcome home.") {
+		t.Fatalf("expected home page first, got:\n%s", document)
+	}
+	if !strings.Contains(string(document), "Guide intro explaining") || strings.Contains(string(document), "#### Details") {
+		t.Fatalf("expected guide trimmed to its intro, got:\n%s", document)
+	}
+
+	manifestBytes, err := os.ReadFile(filepath.Join(tmpDir, PackManifestName))
+	if err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+	var manifest packManifest
+	if err := json.Unmarshal(manifestBytes, &manifest); err != nil {
+		t.Fatalf("unexpected json error: %v", err)
+	}
+	var statuses []string
+	for _, page := range manifest.Pages {
+		statuses = append(statuses, page.URL+"="+page.Status)
+	}
+	want := "https://example.com/=included https://example.com/guide=truncated https://example.com/huge=dropped"
+	if got := strings.Join(statuses, " "); got != want {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+	if guide := manifest.Pages[1]; guide.SectionsTotal != 2 || guide.SectionsIncluded != 1 || guide.Tokens >= guide.OriginalTokens {
+		t.Fatalf("unexpected guide entry: %+v", guide)
+	}
+	if summary.Included != 1 || summary.Truncated != 1 || summary.Dropped != 1 {
+		t.Fatalf("unexpected summary: %+v", summary)
+	}
+}
+
+func TestWritePackJSON(t *testing.T) {
+	tmpDir := t.TempDir()
+	long := strings.Repeat("filler words repeated again and again ", 40)
+	result := &crawler.CrawlResult{
+		Domain: "example.com",
+		Pages: []*crawler.Page{
+			{URL: "https://example.com/guide", Status: crawler.StatusOK, Title: "Guide", MainHTML: "<p>Guide intro.</p><h2>Details</h2><p>" + long + "</p>"},
+			{URL: "https://example.com/", Status: crawler.StatusOK, Title: "Home", MainHTML: "<p>Welcome home.</p>"},
+		},
+	}
+	opts := PackOptions{MaxTokens: 120, Order: PackOrderCrawl, Tokenizer: TokenizerClaude, Format: FormatJSON}
+
+	if _, err := writePack(result, dirSink(tmpDir), opts); err != nil {
+		t.Fatalf("unexpected pack error: %v", err)
+	}
+	document, err := os.ReadFile(filepath.Join(tmpDir, "context.json"))
+	if err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+	if tokens := TokenizerClaude.Count(string(document)); tokens > opts.MaxTokens {
+		t.Fatalf("expected at most %d tokens, got %d", opts.MaxTokens, tokens)
+	}
+	var parsed packDocumentJSON
+	if err := json.Unmarshal(document, &parsed); err != nil {
+		t.Fatalf("unexpected json error: %v", err)
+	}
+	if len(parsed.Pages) == 0 || parsed.Pages[0].URL != "https://example.com/guide" || parsed.Pages[0].Status != packTruncated {
+		t.Fatalf("expected truncated guide first, got %+v", parsed.Pages)
+	}
+}
+
+func TestWritePackCutsOversizedSection(t *testing.T) {
+	tmpDir := t.TempDir()
+	long := strings.Repeat("filler words repeated again and again ", 80)
+	result := &crawler.CrawlResult{
+		Domain: "example.com",
+		Pages:  []*crawler.Page{{URL: "https://example.com/huge", Status: crawler.StatusOK, Title: "Huge", MainHTML: "<p>" + long + "</p>"}},
+	}
+	opts := PackOptions{MaxTokens: 80, Order: PackOrderScore, Tokenizer: TokenizerGPT, Format: FormatMarkdown}
+
+	summary, err := writePack(result, dirSink(tmpDir), opts)
+	if err != nil {
+		t.Fatalf("unexpected pack error: %v", err)
+	}
+	document, err := os.ReadFile(filepath.Join(tmpDir, "context.md"))
+	if err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+	if tokens := TokenizerGPT.Count(string(document)); tokens > opts.MaxTokens {
+		t.Fatalf("expected at most %d tokens, got %d", opts.MaxTokens, tokens)
+	}
+	if summary.Truncated != 1 || !strings.HasSuffix(string(document), " …\n") {
+		t.Fatalf("expected a word-boundary cut, got %+v:\n%s", summary, document)
+	}
+}
+
+func TestWritePackTruncationKeepsHeadingLine(t *testing.T) {
+	tmpDir := t.TempDir()
+	long := strings.Repeat("filler words repeated again and again ", 80)
+	result := &crawler.CrawlResult{
+		Domain: "example.com",
+		Pages:  []*crawler.Page{{URL: "https://example.com/setup", Status: crawler.StatusOK, Title: "Setup", MainHTML: "<h2>Install steps</h2><p>" + long + "</p>"}},
+	}
+	opts := PackOptions{MaxTokens: 80, Order: PackOrderScore, Tokenizer: TokenizerGPT, Format: FormatMarkdown}
+
+	if _, err := writePack(result, dirSink(tmpDir), opts); err != nil {
+		t.Fatalf("unexpected pack error: %v", err)
+	}
+	document, err := os.ReadFile(filepath.Join(tmpDir, "context.md"))
+	if err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+	if !strings.Contains(string(document), "\n#### Install steps\n\nfiller words") {
+		t.Fatalf("expected the heading on its own line above the cut paragraph, got:\n%s", document)
+	}
+}
+
+func TestWritePackBudgetTooSmall(t *testing.T) {
+	opts := PackOptions{MaxTokens: 2, Order: PackOrderScore, Tokenizer: TokenizerGPT, Format: FormatMarkdown}
+	result := &crawler.CrawlResult{
+		Domain: "example.com",
+		Pages:  []*crawler.Page{{URL: "https://example.com/", Status: crawler.StatusOK, Title: "Home", MainHTML: "<p>Welcome home.</p>"}},
+	}
+	if _, err := writePack(result, dirSink(t.TempDir()), opts); err == nil {
+		t.Fatalf("expected error for a budget smaller than the header")
+	}
+}
+
+func TestParsePackOrder(t *testing.T) {
+	if got, err := ParsePackOrder(" Depth "); err != nil || got != PackOrderDepth {
+		t.Fatalf("expected depth, got %q (%v)", got, err)
+	}
+	if _, err := ParsePackOrder("random"); err == nil {
+		t.Fatalf("expected error for invalid order")
+	}
+}
@@ -0,0 +1,449 @@
+package output
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+
+	"github.com/sbstn/sitecrawl/internal/crawler"
+	"github.com/sbstn/sitecrawl/pkg/pagerank"
+)
+
+// RankOptions configures RankCrawl. PageRank.Personalization and
+// PageRank.Weights are read from the CSV files, when set.
+type RankOptions struct {
+	PageRank crawler.PageRankOptions
+	// PersonalizationPath is a url,weight CSV biasing the random jump.
+	PersonalizationPath string
+	// WeightsPath is a source,target,weight CSV of link weights.
+	WeightsPath string
+}
+
+// ReportRank records the parameters of the last rank run in report.json.
+type ReportRank struct {
+	Graph           string  `json:"graph"`
+	Alpha           float64 `json:"alpha"`
+	Tolerance       float64 `json:"tolerance"`
+	MaxIterations   uint    `json:"max_iterations"`
+	Personalization string  `json:"personalization,omitempty"`
+	Weights         string  `json:"weights,omitempty"`
+	Nodes           int     `json:"nodes"`
+	Edges           int     `json:"edges"`
+}
+
+// RankCrawl recomputes PageRank over the link graph saved in a crawl output
+// directory (edges.csv, graph.graphml, or graph.gexf) and rewrites the
+// scores and page order of its report.json. Page files and other artifacts
+// are left untouched. When PageRank does not converge, the scores of the last
+// iteration are written and pagerank.ErrNotConverged is returned along with
+// the ReportRank.
+func RankCrawl(dir string, opts RankOptions) (*ReportRank, error) {
+	if info, err := os.Stat(dir); err != nil {
+		return nil, err
+	} else if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a crawl output directory", dir)
+	}
+	rep, err := ReadReport(dir)
+	if err != nil {
+		return nil, err
+	}
+	if rep.Partial {
+		return nil, fmt.Errorf("%s is partial; rank needs a complete crawl", filepath.Join(dir, ReportName))
+	}
+	graph, graphName, err := readLinkGraph(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	prOpts := opts.PageRank
+	if opts.PersonalizationPath != "" {
+		prOpts.Personalization, err = readPersonalization(opts.PersonalizationPath, graph, rep.Clean)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if opts.WeightsPath != "" {
+		prOpts.Weights, err = readEdgeWeights(opts.WeightsPath, graph, rep.Clean)
+		if err != nil {
+			return nil, err
+		}
+	}
+	scores, order, rankErr := crawler.ComputePageRank(graph, prOpts)
+	if rankErr != nil && !errors.Is(rankErr, pagerank.ErrNotConverged) {
+		return nil, rankErr
+	}
+
+	rankReportPages(rep.Pages, scores, order)
+	rep.PageRankImplementation = crawler.PageRankImplementation
+	rep.Rank = &ReportRank{
+		Graph:           graphName,
+		Alpha:           prOpts.Alpha,
+		Tolerance:       prOpts.Tolerance,
+		MaxIterations:   prOpts.MaxIterations,
+		Personalization: opts.PersonalizationPath,
+		Weights:         opts.WeightsPath,
+		Nodes:           len(graph.Nodes()),
+		Edges:           len(graph.Edges()),
+	}
+	if err := writeReport(rep, dirSink(dir)); err != nil {
+		return nil, err
+	}
+	return rep.Rank, rankErr
+}
+
+// rankReportPages scores the ok pages and orders all pages like
+// crawler.ApplyPageRankScores: by descending score, then rank order, then URL.
+func rankReportPages(pages []ReportPage, scores map[string]float64, order []string) {
+	orderPos := map[string]int{}
+	for idx, url := range order {
+		orderPos[url] = idx
+	}
+	for i := range pages {
+		pages[i].Score = nil
+		if pages[i].Status != crawler.StatusOK {
+			continue
+		}
+		score := scores[reportPageKey(pages[i])]
+		pages[i].Score = &score
+	}
+	sort.SliceStable(pages, func(i, j int) bool {
+		iScore, jScore := -1.0, -1.0
+		if pages[i].Score != nil {
+			iScore = *pages[i].Score
+		}
+		if pages[j].Score != nil {
+			jScore = *pages[j].Score
+		}
+		if iScore != jScore {
+			return iScore > jScore
+		}
+		iURL, jURL := reportPageKey(pages[i]), reportPageKey(pages[j])
+		iPos, iOK := orderPos[iURL]
+		jPos, jOK := orderPos[jURL]
+		if iOK && jOK && iPos != jPos {
+			return iPos < jPos
+		}
+		return iURL < jURL
+	})
+}
+
+func reportPageKey(page ReportPage) string {
+	if page.FinalURL != "" {
+		return page.FinalURL
+	}
+	return page.URL
+}
+
+// readLinkGraph loads the first graph export found in dir. DOT is not read
+// back.
+func readLinkGraph(dir string) (*crawler.LinkGraph, string, error) {
+	for _, name := range []string{GraphEdgesCSVName, GraphMLName, GraphGEXFName} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		var graph *crawler.LinkGraph
+		switch name {
+		case GraphEdgesCSVName:
+			graph, err = readGraphCSV(dir, data)
+		case GraphMLName:
+			graph, err = readGraphML(data)
+		default:
+			graph, err = readGEXF(data)
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("%s: %w", name, err)
+		}
+		return graph, name, nil
+	}
+	return nil, "", fmt.Errorf("no link graph in %s; crawl with --graph-format csv, graphml, or gexf", dir)
+}
+
+// readGraphCSV reads edges.csv and, when present, nodes.csv for the URLs
+// without links.
+func readGraphCSV(dir string, edges []byte) (*crawler.LinkGraph, error) {
+	graph := crawler.NewLinkGraph()
+	err := readCSVRows(bytes.NewReader(edges), []string{"source", "target"}, func(line int, row []string) error {
+		if row[0] == "" || row[1] == "" {
+			return fmt.Errorf("line %d: empty URL", line)
+		}
+		graph.AddEdge(row[0], row[1])
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := os.ReadFile(filepath.Join(dir, GraphNodesCSVName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return graph, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = readCSVRows(bytes.NewReader(nodes), []string{"url"}, func(line int, row []string) error {
+		graph.AddNode(row[0])
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", GraphNodesCSVName, err)
+	}
+	return graph, nil
+}
+
+// readCSVRows calls fn for every row after a header that starts with
+// header. Rows may have more columns than the header.
+func readCSVRows(r io.Reader, header []string, fn func(line int, row []string) error) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	first := true
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		line, _ := reader.FieldPos(0)
+		if first {
+			first = false
+			if len(row) < len(header) || !slices.Equal(row[:len(header)], header) {
+				return fmt.Errorf("line %d: expected header starting with %v", line, header)
+			}
+			continue
+		}
+		if len(row) < len(header) {
+			return fmt.Errorf("line %d: expected %d columns, got %d", line, len(header), len(row))
+		}
+		if err := fn(line, row); err != nil {
+			return err
+		}
+	}
+	if first {
+		return fmt.Errorf("missing header %v", header)
+	}
+	return nil
+}
+
+type xmlGraphEdge struct {
+	Source string `xml:"source,attr"`
+	Target string `xml:"target,attr"`
+}
+
+type xmlGraphData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+type graphMLDocument struct {
+	Keys []struct {
+		ID   string `xml:"id,attr"`
+		Name string `xml:"attr.name,attr"`
+	} `xml:"key"`
+	Nodes []struct {
+		ID   string         `xml:"id,attr"`
+		Data []xmlGraphData `xml:"data"`
+	} `xml:"graph>node"`
+	Edges []xmlGraphEdge `xml:"graph>edge"`
+}
+
+// readGraphML reads node URLs from their "url" data, falling back to the
+// node ID.
+func readGraphML(data []byte) (*crawler.LinkGraph, error) {
+	var doc graphMLDocument
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	urlKey := ""
+	for _, key := range doc.Keys {
+		if key.Name == "url" {
+			urlKey = key.ID
+		}
+	}
+	urls := map[string]string{}
+	for _, node := range doc.Nodes {
+		urls[node.ID] = node.ID
+		for _, value := range node.Data {
+			if urlKey != "" && value.Key == urlKey {
+				urls[node.ID] = value.Value
+			}
+		}
+	}
+	return graphFromXML(urls, doc.Edges)
+}
+
+type gexfDocument struct {
+	Attributes []struct {
+		Class     string `xml:"class,attr"`
+		Attribute []struct {
+			ID    string `xml:"id,attr"`
+			Title string `xml:"title,attr"`
+		} `xml:"attribute"`
+	} `xml:"graph>attributes"`
+	Nodes []struct {
+		ID     string `xml:"id,attr"`
+		Values []struct {
+			For   string `xml:"for,attr"`
+			Value string `xml:"value,attr"`
+		} `xml:"attvalues>attvalue"`
+	} `xml:"graph>nodes>node"`
+	Edges []xmlGraphEdge `xml:"graph>edges>edge"`
+}
+
+// readGEXF reads node URLs from their "url" attribute, falling back to the
+// node ID.
+func readGEXF(data []byte) (*crawler.LinkGraph, error) {
+	var doc gexfDocument
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	urlAttr := ""
+	for _, attributes := range doc.Attributes {
+		if attributes.Class != "node" {
+			continue
+		}
+		for _, attr := range attributes.Attribute {
+			if attr.Title == "url" {
+				urlAttr = attr.ID
+			}
+		}
+	}
+	urls := map[string]string{}
+	for _, node := range doc.Nodes {
+		urls[node.ID] = node.ID
+		for _, value := range node.Values {
+			if urlAttr != "" && value.For == urlAttr {
+				urls[node.ID] = value.Value
+			}
+		}
+	}
+	return graphFromXML(urls, doc.Edges)
+}
+
+func graphFromXML(urls map[string]string, edges []xmlGraphEdge) (*crawler.LinkGraph, error) {
+	graph := crawler.NewLinkGraph()
+	for _, url := range urls {
+		graph.AddNode(url)
+	}
+	for _, edge := range edges {
+		from, okFrom := urls[edge.Source]
+		to, okTo := urls[edge.Target]
+		if !okFrom || !okTo {
+			return nil, fmt.Errorf("edge %s -> %s refers to an unknown node", edge.Source, edge.Target)
+		}
+		graph.AddEdge(from, to)
+	}
+	return graph, nil
+}
+
+// readPersonalization reads url,weight rows. URLs are normalized like the
+// crawl's and must be nodes of graph.
+func readPersonalization(path string, graph *crawler.LinkGraph, clean bool) (map[string]float64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	nodes := map[string]bool{}
+	for _, node := range graph.Nodes() {
+		nodes[node] = true
+	}
+	weights := map[string]float64{}
+	err = readCSVRows(file, []string{"url", "weight"}, func(line int, row []string) error {
+		url, err := normalizeGraphURL(row[0], nodes, clean)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		if _, dup := weights[url]; dup {
+			return fmt.Errorf("line %d: duplicate URL %s", line, url)
+		}
+		weights[url], err = parseRankWeight(row[1])
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return weights, nil
+}
+
+// readEdgeWeights reads source,target,weight rows. Both URLs are normalized
+// like the crawl's and the link must be an edge of graph.
+func readEdgeWeights(path string, graph *crawler.LinkGraph, clean bool) (map[crawler.Edge]float64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	nodes := map[string]bool{}
+	for _, node := range graph.Nodes() {
+		nodes[node] = true
+	}
+	edges := map[crawler.Edge]bool{}
+	for _, edge := range graph.Edges() {
+		edges[edge] = true
+	}
+	weights := map[crawler.Edge]float64{}
+	err = readCSVRows(file, []string{"source", "target", "weight"}, func(line int, row []string) error {
+		from, err := normalizeGraphURL(row[0], nodes, clean)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		to, err := normalizeGraphURL(row[1], nodes, clean)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		edge := crawler.Edge{From: from, To: to}
+		if !edges[edge] {
+			return fmt.Errorf("line %d: %s does not link to %s", line, from, to)
+		}
+		if _, dup := weights[edge]; dup {
+			return fmt.Errorf("line %d: duplicate link %s -> %s", line, from, to)
+		}
+		weights[edge], err = parseRankWeight(row[2])
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return weights, nil
+}
+
+func normalizeGraphURL(raw string, nodes map[string]bool, clean bool) (string, error) {
+	url, err := crawler.NormalizeURL(raw, clean)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL %q: %w", raw, err)
+	}
+	if !nodes[url] {
+		return "", fmt.Errorf("%s is not in the link graph", url)
+	}
+	return url, nil
+}
+
+func parseRankWeight(raw string) (float64, error) {
+	weight, err := strconv.ParseFloat(raw, 64)
+	if err != nil || math.IsNaN(weight) || math.IsInf(weight, 0) || weight < 0 {
+		return 0, fmt.Errorf("weight must be a non-negative number, got %q", raw)
+	}
+	return weight, nil
+}
@@ -0,0 +1,168 @@
+package output
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sbstn/sitecrawl/internal/crawler"
+	"github.com/sbstn/sitecrawl/pkg/pagerank"
+)
+
+func writeRankFixture(t *testing.T, formats []GraphFormat) string {
+	t.Helper()
+	dir := t.TempDir()
+	score := func(v float64) *float64 { return &v }
+	graph := crawler.NewLinkGraph()
+	graph.AddEdge("https://example.com/", "https://example.com/docs")
+	graph.AddEdge("https://example.com/", "https://example.com/private")
+	graph.AddEdge("https://example.com/docs", "https://example.com/")
+	result := &crawler.CrawlResult{
+		Domain:    "example.com",
+		Strategy:  crawler.StrategyPageRank,
+		LinkGraph: graph,
+		Pages: []*crawler.Page{
+			{URL: "https://example.com/", FinalURL: "https://example.com/", Status: crawler.StatusOK, Title: "Home", Score: score(0.6)},
+			{URL: "https://example.com/docs", FinalURL: "https://example.com/docs", Depth: 1, Status: crawler.StatusOK, Title: "Docs", Score: score(0.4)},
+			{URL: "https://example.com/broken", Status: crawler.StatusError, Error: "timeout"},
+		},
+	}
+	if err := WriteWithOptions(result, dir, FormatMarkdown, Options{Graph: formats}); err != nil {
+		t.Fatalf("write fixture: %v", err)
+	}
+	return dir
+}
+
+func rankedURLs(t *testing.T, dir string) []string {
+	t.Helper()
+	var urls []string
+	for _, page := range readReport(t, dir).Pages {
+		urls = append(urls, page.URL)
+	}
+	return urls
+}
+
+func TestRankCrawlReadsEveryGraphExport(t *testing.T) {
+	for _, format := range []GraphFormat{GraphCSV, GraphML, GraphGEXF} {
+		dir := writeRankFixture(t, []GraphFormat{format})
+		rank, err := RankCrawl(dir, RankOptions{PageRank: crawler.DefaultPageRankOptions()})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", format, err)
+		}
+		if rank.Nodes != 3 || rank.Edges != 3 {
+			t.Fatalf("%s: unexpected graph size: %+v", format, rank)
+		}
+		rep := readReport(t, dir)
+		if rep.Rank == nil || rep.Rank.Graph != rank.Graph || rep.Rank.Alpha != 0.85 {
+			t.Fatalf("%s: unexpected rank metadata: %+v", format, rep.Rank)
+		}
+		total := 0.0
+		for _, page := range rep.Pages {
+			if page.Status != crawler.StatusOK {
+				if page.Score != nil {
+					t.Fatalf("%s: expected no score for %s", format, page.URL)
+				}
+				continue
+			}
+			total += *page.Score
+		}
+		// The uncrawled /private node holds the rest of the rank.
+		if total <= 0 || total >= 1 {
+			t.Fatalf("%s: unexpected score total %v", format, total)
+		}
+		if urls := rankedURLs(t, dir); urls[0] != "https://example.com/" || urls[2] != "https://example.com/broken" {
+			t.Fatalf("%s: unexpected order: %v", format, urls)
+		}
+	}
+}
+
+func TestRankCrawlPersonalizationReorders(t *testing.T) {
+	dir := writeRankFixture(t, []GraphFormat{GraphCSV})
+	boost := filepath.Join(t.TempDir(), "boost.csv")
+	// URLs are normalized like the crawl's.
+	if err := os.WriteFile(boost, []byte("url,weight\nHTTPS://example.com/docs,1\n"), 0o644); err != nil {
+		t.Fatalf("write personalization: %v", err)
+	}
+	if _, err := RankCrawl(dir, RankOptions{PageRank: crawler.DefaultPageRankOptions(), PersonalizationPath: boost}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if urls := rankedURLs(t, dir); urls[0] != "https://example.com/docs" {
+		t.Fatalf("expected personalized page first, got %v", urls)
+	}
+	if rep := readReport(t, dir); rep.Rank.Personalization != boost {
+		t.Fatalf("unexpected rank metadata: %+v", rep.Rank)
+	}
+}
+
+func TestRankCrawlKeepsScoresWhenNotConverged(t *testing.T) {
+	dir := writeRankFixture(t, []GraphFormat{GraphCSV})
+	opts := crawler.DefaultPageRankOptions()
+	opts.MaxIterations = 0
+	rank, err := RankCrawl(dir, RankOptions{PageRank: opts})
+	if !errors.Is(err, pagerank.ErrNotConverged) || rank == nil {
+		t.Fatalf("expected ErrNotConverged with rank metadata, got %+v, %v", rank, err)
+	}
+	rep := readReport(t, dir)
+	if rep.Rank == nil || rep.Rank.MaxIterations != 0 || rep.Pages[0].Score == nil {
+		t.Fatalf("expected last-iteration scores in report.json, got %+v", rep)
+	}
+}
+
+func TestRankCrawlErrors(t *testing.T) {
+	dir := writeRankFixture(t, []GraphFormat{GraphCSV})
+	inputs := t.TempDir()
+	write := func(name, data string) string {
+		path := filepath.Join(inputs, name)
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+		return path
+	}
+	tests := []struct {
+		name    string
+		dir     string
+		opts    RankOptions
+		wantErr string
+	}{
+		{
+			name:    "no graph",
+			dir:     writeRankFixture(t, nil),
+			wantErr: "no link graph",
+		},
+		{
+			name:    "unknown personalization URL",
+			dir:     dir,
+			opts:    RankOptions{PersonalizationPath: write("unknown.csv", "url,weight\nhttps://example.com/nope,1\n")},
+			wantErr: "unknown.csv: line 2: https://example.com/nope is not in the link graph",
+		},
+		{
+			name:    "negative weight",
+			dir:     dir,
+			opts:    RankOptions{WeightsPath: write("negative.csv", "source,target,weight\nhttps://example.com/,https://example.com/docs,-1\n")},
+			wantErr: "negative.csv: line 2: weight must be a non-negative number",
+		},
+		{
+			name:    "missing link",
+			dir:     dir,
+			opts:    RankOptions{WeightsPath: write("missing.csv", "source,target,weight\nhttps://example.com/private,https://example.com/,2\n")},
+			wantErr: "does not link to",
+		},
+		{
+			name:    "bad header",
+			dir:     dir,
+			opts:    RankOptions{WeightsPath: write("header.csv", "from,to,weight\n")},
+			wantErr: "expected header",
+		},
+	}
+	for _, tt := range tests {
+		if tt.opts.PageRank.Tolerance == 0 {
+			tt.opts.PageRank = crawler.DefaultPageRankOptions()
+		}
+		_, err := RankCrawl(tt.dir, tt.opts)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Fatalf("%s: expected error containing %q, got %v", tt.name, tt.wantErr, err)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package output
+
+import (
+	"sort"
+
+	"github.com/sbstn/sitecrawl/internal/crawler"
+)
+
+// ReportSitemapError is a sitemap file that could not be fetched or parsed.
+type ReportSitemapError struct {
+	URL   string `json:"url"`
+	Error string `json:"error"`
+}
+
+// ReportSitemapIssue is a sitemap URL whose crawl redirected or failed.
+type ReportSitemapIssue struct {
+	URL      string `json:"url"`
+	FinalURL string `json:"final_url"`
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+}
+
+// ReportSitemapCoverage holds the sitemap coverage section of report.json.
+type ReportSitemapCoverage struct {
+	Sitemaps     []string             `json:"sitemaps"`
+	Errors       []ReportSitemapError `json:"errors,omitempty"`
+	URLs         int                  `json:"urls"`
+	OutOfScope   int                  `json:"out_of_scope"`
+	Crawled      int                  `json:"crawled"`
+	Orphans      []string             `json:"orphans"`
+	Uncrawled    []string             `json:"uncrawled"`
+	NotInSitemap []string             `json:"not_in_sitemap"`
+	Redirects    []ReportSitemapIssue `json:"redirects"`
+	Failures     []ReportSitemapIssue `json:"failures"`
+	Depths       []DepthCount         `json:"depths"`
+}
+
+func toReportSitemapCoverage(coverage *crawler.SitemapCoverage) *ReportSitemapCoverage {
+	if coverage == nil {
+		return nil
+	}
+	rep := &ReportSitemapCoverage{
+		Sitemaps:     append([]string{}, coverage.Sitemaps...),
+		URLs:         coverage.URLs,
+		OutOfScope:   coverage.OutOfScope,
+		Crawled:      coverage.Crawled,
+		Orphans:      coverage.Orphans,
+		Uncrawled:    coverage.Uncrawled,
+		NotInSitemap: coverage.NotInSitemap,
+		Redirects:    toReportSitemapIssues(coverage.Redirects),
+		Failures:     toReportSitemapIssues(coverage.Failures),
+		Depths:       []DepthCount{},
+	}
+	for _, failed := range coverage.Errors {
+		rep.Errors = append(rep.Errors, ReportSitemapError{URL: failed.URL, Error: failed.Error})
+	}
+	for depth, count := range coverage.Depths {
+		rep.Depths = append(rep.Depths, DepthCount{Depth: depth, Count: count})
+	}
+	sort.Slice(rep.Depths, func(i, j int) bool { return rep.Depths[i].Depth < rep.Depths[j].Depth })
+	return rep
+}
+
+func toReportSitemapIssues(issues []crawler.SitemapIssue) []ReportSitemapIssue {
+	converted := make([]ReportSitemapIssue, 0, len(issues))
+	for _, issue := range issues {
+		converted = append(converted, ReportSitemapIssue{
+			URL:      issue.URL,
+			FinalURL: issue.FinalURL,
+			Status:   issue.Status,
+			Error:    issue.Error,
+		})
+	}
+	return converted
+}
@@ -0,0 +1,58 @@
+package output
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sbstn/sitecrawl/internal/crawler"
+)
+
+func TestReportIncludesSitemapCoverage(t *testing.T) {
+	result := &crawler.CrawlResult{
+		Domain: "example.com",
+		Pages:  []*crawler.Page{{URL: "https://example.com/", FinalURL: "https://example.com/", Status: crawler.StatusOK, Title: "Home"}},
+	}
+	result.SitemapCoverage = &crawler.SitemapCoverage{
+		Sitemaps:     []string{"https://example.com/sitemap.xml"},
+		Errors:       []crawler.SitemapError{{URL: "https://example.com/news.xml", Error: "unexpected status 404 Not Found"}},
+		URLs:         3,
+		OutOfScope:   1,
+		Crawled:      2,
+		Orphans:      []string{"https://example.com/lonely"},
+		Uncrawled:    []string{"https://example.com/lonely"},
+		NotInSitemap: []string{},
+		Redirects:    []crawler.SitemapIssue{{URL: "https://example.com/old", FinalURL: "https://example.com/docs", Status: crawler.StatusOK}},
+		Failures:     []crawler.SitemapIssue{},
+		Depths:       map[int]int{1: 1, 0: 1},
+	}
+	tmpDir := t.TempDir()
+	if err := Write(result, tmpDir, FormatMarkdown); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := readReport(t, tmpDir).SitemapCoverage
+	want := &ReportSitemapCoverage{
+		Sitemaps:     []string{"https://example.com/sitemap.xml"},
+		Errors:       []ReportSitemapError{{URL: "https://example.com/news.xml", Error: "unexpected status 404 Not Found"}},
+		URLs:         3,
+		OutOfScope:   1,
+		Crawled:      2,
+		Orphans:      []string{"https://example.com/lonely"},
+		Uncrawled:    []string{"https://example.com/lonely"},
+		NotInSitemap: []string{},
+		Redirects:    []ReportSitemapIssue{{URL: "https://example.com/old", FinalURL: "https://example.com/docs", Status: crawler.StatusOK}},
+		Failures:     []ReportSitemapIssue{},
+		Depths:       []DepthCount{{Depth: 0, Count: 1}, {Depth: 1, Count: 1}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected sitemap_coverage:\n got %+v\nwant %+v", got, want)
+	}
+
+	result.SitemapCoverage = nil
+	if err := Write(result, tmpDir, FormatMarkdown); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rep := readReport(t, tmpDir); rep.SitemapCoverage != nil {
+		t.Fatalf("expected no sitemap_coverage without sitemaps, got %+v", rep.SitemapCoverage)
+	}
+}
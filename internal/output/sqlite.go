@@ -0,0 +1,368 @@
+package output
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/sbstn/sitecrawl/internal/crawler"
+	_ "modernc.org/sqlite"
+)
+
+// sqliteSchemaVersion is stored in PRAGMA user_version. Databases written by
+// a newer schema are rejected instead of being appended to.
+const sqliteSchemaVersion = 1
+
+// sqliteSchema creates the tables on first use. Every crawl appends one row
+// to crawls; all other rows reference it, so a single database file can hold
+// many crawls. pages_fts is an external-content FTS5 index over pages, kept
+// in sync by triggers.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS crawls (
+	id INTEGER PRIMARY KEY,
+	domain TEXT NOT NULL,
+	allowed_hosts TEXT NOT NULL,
+	started_at TEXT NOT NULL,
+	finished_at TEXT NOT NULL,
+	strategy TEXT NOT NULL,
+	max_pages INTEGER NOT NULL,
+	max_depth INTEGER NOT NULL,
+	fields TEXT,
+	clean INTEGER NOT NULL,
+	extractor TEXT,
+	headful INTEGER NOT NULL,
+	pagerank_implementation TEXT,
+	visited INTEGER NOT NULL,
+	errors INTEGER NOT NULL,
+	skipped_external INTEGER NOT NULL,
+	skipped_out_of_scope INTEGER NOT NULL,
+	skipped_noindex INTEGER NOT NULL,
+	skipped_nofollow INTEGER NOT NULL,
+	skipped_ai_optout INTEGER NOT NULL,
+	skipped_language INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS ai_policies (
+	crawl_id INTEGER NOT NULL REFERENCES crawls(id) ON DELETE CASCADE,
+	host TEXT NOT NULL,
+	ai_txt INTEGER NOT NULL,
+	tdmrep_rules TEXT,
+	PRIMARY KEY (crawl_id, host)
+);
+CREATE TABLE IF NOT EXISTS pages (
+	id INTEGER PRIMARY KEY,
+	crawl_id INTEGER NOT NULL REFERENCES crawls(id) ON DELETE CASCADE,
+	position INTEGER NOT NULL,
+	url TEXT NOT NULL,
+	final_url TEXT,
+	depth INTEGER NOT NULL,
+	status TEXT NOT NULL,
+	title TEXT,
+	description TEXT,
+	language TEXT,
+	declared_language TEXT,
+	content_language TEXT,
+	detected_language TEXT,
+	content_root TEXT,
+	content_confidence REAL,
+	main_text TEXT,
+	main_html TEXT,
+	body_html TEXT,
+	raw_html TEXT,
+	error TEXT,
+	out_path TEXT,
+	score REAL
+);
+CREATE INDEX IF NOT EXISTS pages_crawl_url ON pages (crawl_id, url);
+CREATE INDEX IF NOT EXISTS pages_crawl_final_url ON pages (crawl_id, final_url);
+CREATE TABLE IF NOT EXISTS page_robots (
+	page_id INTEGER NOT NULL REFERENCES pages(id) ON DELETE CASCADE,
+	directive TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS page_ai_signals (
+	page_id INTEGER NOT NULL REFERENCES pages(id) ON DELETE CASCADE,
+	signal TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS alternates (
+	page_id INTEGER NOT NULL REFERENCES pages(id) ON DELETE CASCADE,
+	lang TEXT NOT NULL,
+	url TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS links (
+	crawl_id INTEGER NOT NULL REFERENCES crawls(id) ON DELETE CASCADE,
+	source_page_id INTEGER NOT NULL REFERENCES pages(id) ON DELETE CASCADE,
+	target_page_id INTEGER REFERENCES pages(id) ON DELETE SET NULL,
+	source_url TEXT NOT NULL,
+	target_url TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS links_source ON links (source_page_id);
+CREATE INDEX IF NOT EXISTS links_target ON links (crawl_id, target_url);
+CREATE TABLE IF NOT EXISTS images (
+	page_id INTEGER NOT NULL REFERENCES pages(id) ON DELETE CASCADE,
+	position INTEGER NOT NULL,
+	url TEXT NOT NULL,
+	alt TEXT,
+	alt_missing INTEGER NOT NULL,
+	width INTEGER,
+	height INTEGER,
+	srcset TEXT,
+	lazy INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS page_tables (
+	page_id INTEGER NOT NULL REFERENCES pages(id) ON DELETE CASCADE,
+	position INTEGER NOT NULL,
+	caption TEXT,
+	header TEXT,
+	rows TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS field_values (
+	page_id INTEGER NOT NULL REFERENCES pages(id) ON DELETE CASCADE,
+	name TEXT NOT NULL,
+	position INTEGER NOT NULL,
+	value TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS captures (
+	page_id INTEGER PRIMARY KEY REFERENCES pages(id) ON DELETE CASCADE,
+	fetched_at TEXT NOT NULL,
+	request_method TEXT NOT NULL,
+	request_url TEXT NOT NULL,
+	request_headers TEXT,
+	protocol TEXT,
+	status_code INTEGER NOT NULL,
+	status_text TEXT,
+	response_url TEXT,
+	response_headers TEXT,
+	mime_type TEXT,
+	remote_ip TEXT,
+	body BLOB
+);
+CREATE VIRTUAL TABLE IF NOT EXISTS pages_fts USING fts5 (
+	title, description, main_text,
+	content = 'pages', content_rowid = 'id'
+);
+CREATE TRIGGER IF NOT EXISTS pages_fts_insert AFTER INSERT ON pages BEGIN
+	INSERT INTO pages_fts (rowid, title, description, main_text)
+	VALUES (new.id, new.title, new.description, new.main_text);
+END;
+CREATE TRIGGER IF NOT EXISTS pages_fts_delete AFTER DELETE ON pages BEGIN
+	INSERT INTO pages_fts (pages_fts, rowid, title, description, main_text)
+	VALUES ('delete', old.id, old.title, old.description, old.main_text);
+END;
+`
+
+// ReportSQLite describes the crawl written to the SQLite database in report.json.
+type ReportSQLite struct {
+	Path    string `json:"path"`
+	CrawlID int64  `json:"crawl_id"`
+	Pages   int    `json:"pages"`
+	Links   int    `json:"links"`
+}
+
+// writeSQLite appends the crawl to the SQLite database at path, creating the
+// file and schema when needed. The crawl is written in one transaction, so a
+// failed write leaves earlier crawls untouched.
+func writeSQLite(result *crawler.CrawlResult, path string) (*ReportSQLite, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return nil, fmt.Errorf("open sqlite database %s: %w", path, err)
+	}
+	if version > sqliteSchemaVersion {
+		return nil, fmt.Errorf("sqlite database %s has schema version %d (supported: %d)", path, version, sqliteSchemaVersion)
+	}
+	if _, err := db.Exec("PRAGMA foreign_keys = ON"); err != nil {
+		return nil, err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(sqliteSchema); err != nil {
+		return nil, fmt.Errorf("create sqlite schema: %w", err)
+	}
+	if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", sqliteSchemaVersion)); err != nil {
+		return nil, err
+	}
+
+	writer := sqliteWriter{tx: tx}
+	crawlID := writer.crawl(result)
+	for _, policy := range result.AIPolicies {
+		writer.exec(`INSERT INTO ai_policies (crawl_id, host, ai_txt, tdmrep_rules) VALUES (?, ?, ?, ?)`,
+			crawlID, policy.Host, policy.AITxt, jsonColumn(policy.TDMRepRules))
+	}
+
+	pageIDs := make([]int64, len(result.Pages))
+	byURL := map[string]int64{}
+	for i, page := range result.Pages {
+		pageIDs[i] = writer.page(crawlID, i, page)
+		byURL[page.URL] = pageIDs[i]
+		if page.FinalURL != "" {
+			byURL[page.FinalURL] = pageIDs[i]
+		}
+	}
+	links := 0
+	for i, page := range result.Pages {
+		source := pageLocation(page)
+		for _, target := range page.Links {
+			var targetID any
+			if id, ok := byURL[target]; ok {
+				targetID = id
+			}
+			writer.exec(`INSERT INTO links (crawl_id, source_page_id, target_page_id, source_url, target_url) VALUES (?, ?, ?, ?, ?)`,
+				crawlID, pageIDs[i], targetID, source, target)
+			links++
+		}
+	}
+	if writer.err != nil {
+		return nil, writer.err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &ReportSQLite{Path: path, CrawlID: crawlID, Pages: len(result.Pages), Links: links}, nil
+}
+
+// sqliteWriter keeps the first insert error so the row-writing code reads
+// straight through; later statements are skipped once an error occurred.
+type sqliteWriter struct {
+	tx  *sql.Tx
+	err error
+}
+
+func (w *sqliteWriter) exec(query string, args ...any) int64 {
+	if w.err != nil {
+		return 0
+	}
+	res, err := w.tx.Exec(query, args...)
+	if err != nil {
+		w.err = fmt.Errorf("write sqlite: %w", err)
+		return 0
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		w.err = fmt.Errorf("write sqlite: %w", err)
+	}
+	return id
+}
+
+func (w *sqliteWriter) crawl(result *crawler.CrawlResult) int64 {
+	totals := result.Totals
+	return w.exec(`INSERT INTO crawls (
+		domain, allowed_hosts, started_at, finished_at, strategy, max_pages, max_depth, fields,
+		clean, extractor, headful, pagerank_implementation,
+		visited, errors, skipped_external, skipped_out_of_scope, skipped_noindex,
+		skipped_nofollow, skipped_ai_optout, skipped_language
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		result.Domain, jsonColumn(result.AllowedHosts), sqliteTime(result.StartedAt), sqliteTime(result.FinishedAt),
+		string(result.Strategy), result.MaxPages, result.MaxDepth, jsonColumn(result.FieldNames),
+		result.Clean, nullString(string(result.Extractor)), result.Headful, nullString(result.PageRankImplementation),
+		totals.Visited, totals.Errors, totals.SkippedExternal, totals.SkippedOutOfScope, totals.SkippedNoIndex,
+		totals.SkippedNofollow, totals.SkippedAIOptOut, totals.SkippedLanguage)
+}
+
+func (w *sqliteWriter) page(crawlID int64, position int, page *crawler.Page) int64 {
+	id := w.exec(`INSERT INTO pages (
+		crawl_id, position, url, final_url, depth, status, title, description,
+		language, declared_language, content_language, detected_language,
+		content_root, content_confidence, main_text, main_html, body_html, raw_html,
+		error, out_path, score
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		crawlID, position, page.URL, nullString(page.FinalURL), page.Depth, page.Status,
+		nullString(page.Title), nullString(page.Description),
+		nullString(page.Language), nullString(page.DeclaredLanguage), nullString(page.ContentLanguage), nullString(page.DetectedLanguage),
+		nullString(page.ContentRoot), nullFloat(page.ContentConfidence),
+		nullString(page.MainText), nullString(page.MainHTML), nullString(page.BodyHTML), nullString(page.RawHTML),
+		nullString(page.Error), nullString(page.OutPath), nullFloat(page.Score))
+
+	for _, directive := range page.Robots {
+		w.exec(`INSERT INTO page_robots (page_id, directive) VALUES (?, ?)`, id, directive)
+	}
+	for _, signal := range page.AISignals {
+		w.exec(`INSERT INTO page_ai_signals (page_id, signal) VALUES (?, ?)`, id, signal)
+	}
+	for _, alt := range page.Alternates {
+		w.exec(`INSERT INTO alternates (page_id, lang, url) VALUES (?, ?, ?)`, id, alt.Lang, alt.URL)
+	}
+	for i, image := range page.Images {
+		w.exec(`INSERT INTO images (page_id, position, url, alt, alt_missing, width, height, srcset, lazy) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			id, i, image.URL, nullString(image.Alt), image.AltMissing, nullInt(image.Width), nullInt(image.Height),
+			jsonColumn(image.Srcset), image.Lazy)
+	}
+	for i, table := range page.Tables {
+		w.exec(`INSERT INTO page_tables (page_id, position, caption, header, rows) VALUES (?, ?, ?, ?, ?)`,
+			id, i, nullString(table.Caption), jsonColumn(table.Header), jsonColumn(table.Rows))
+	}
+	for _, field := range page.Fields {
+		for i, value := range field.Values {
+			w.exec(`INSERT INTO field_values (page_id, name, position, value) VALUES (?, ?, ?, ?)`, id, field.Name, i, value)
+		}
+	}
+	if capture := page.Capture; capture != nil {
+		w.exec(`INSERT INTO captures (
+			page_id, fetched_at, request_method, request_url, request_headers, protocol,
+			status_code, status_text, response_url, response_headers, mime_type, remote_ip, body
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			id, sqliteTime(capture.FetchedAt), capture.RequestMethod, capture.RequestURL, headersColumn(capture.RequestHeaders),
+			nullString(capture.Protocol), capture.StatusCode, nullString(capture.StatusText), nullString(capture.ResponseURL),
+			headersColumn(capture.ResponseHeaders), nullString(capture.MIMEType), nullString(capture.RemoteIP), capture.Body)
+	}
+	return id
+}
+
+func sqliteTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// jsonColumn stores list-valued data as JSON text, queryable with SQLite's
+// json_each. Empty values are stored as NULL.
+func jsonColumn[T any](value []T) any {
+	if len(value) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+	return string(data)
+}
+
+func headersColumn(headers http.Header) any {
+	if len(headers) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(headers)
+	if err != nil {
+		return nil
+	}
+	return string(data)
+}
+
+func nullString(value string) any {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+func nullInt(value int) any {
+	if value == 0 {
+		return nil
+	}
+	return value
+}
+
+func nullFloat(value *float64) any {
+	if value == nil {
+		return nil
+	}
+	return *value
+}
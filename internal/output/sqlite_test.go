@@ -0,0 +1,170 @@
+package output
+
+import (
+	"database/sql"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sbstn/sitecrawl/internal/crawler"
+)
+
+func TestWriteSQLiteAppendsCrawls(t *testing.T) {
+	tmpDir := t.TempDir()
+	dbPath := filepath.Join(tmpDir, "crawls.db")
+	score := 0.6
+	newResult := func(domain string) *crawler.CrawlResult {
+		return &crawler.CrawlResult{
+			Domain:       domain,
+			AllowedHosts: []string{domain},
+			StartedAt:    time.Date(2025, 3, 4, 5, 6, 7, 0, time.UTC),
+			Strategy:     crawler.StrategyPageRank,
+			Clean:        true,
+			AIPolicies:   []crawler.HostAIPolicy{{Host: domain, AITxt: true}},
+			Pages: []*crawler.Page{
+				{
+					URL:        "https://" + domain + "/",
+					FinalURL:   "https://" + domain + "/",
+					Status:     crawler.StatusOK,
+					Title:      "Home",
+					Robots:     []string{"noarchive"},
+					Links:      []string{"https://" + domain + "/docs", "https://" + domain + "/gone"},
+					Images:     []crawler.Image{{URL: "https://" + domain + "/logo.png", AltMissing: true}},
+					Tables:     []crawler.Table{{Header: []string{"Plan", "Price"}, Rows: [][]string{{"Pro", "$10"}}}},
+					Fields:     []crawler.FieldValue{{Name: "sku", Values: []string{"A1", "B2"}, Multiple: true}},
+					MainText:   "Welcome to the gardening handbook",
+					Score:      &score,
+					Alternates: []crawler.Alternate{{Lang: "de", URL: "https://" + domain + "/de/"}},
+				},
+				{
+					URL:      "https://" + domain + "/docs",
+					FinalURL: "https://" + domain + "/docs",
+					Status:   crawler.StatusOK,
+					Title:    "Docs",
+					Links:    []string{"https://" + domain + "/"},
+					MainText: "Pruning roses in autumn",
+				},
+				{URL: "https://" + domain + "/gone", Status: crawler.StatusError, Error: "timeout"},
+			},
+		}
+	}
+
+	for i, domain := range []string{"a.example", "b.example"} {
+		outDir := filepath.Join(tmpDir, domain)
+		if err := WriteWithOptions(newResult(domain), outDir, FormatMarkdown, Options{SQLite: dbPath}); err != nil {
+			t.Fatalf("unexpected write error: %v", err)
+		}
+		reportJSON, err := os.ReadFile(filepath.Join(outDir, "report.json"))
+		if err != nil {
+			t.Fatalf("unexpected read error: %v", err)
+		}
+		var rep struct {
+			SQLite ReportSQLite `json:"sqlite"`
+		}
+		if err := json.Unmarshal(reportJSON, &rep); err != nil {
+			t.Fatalf("unexpected report decode error: %v", err)
+		}
+		if rep.SQLite.CrawlID != int64(i+1) || rep.SQLite.Pages != 3 || rep.SQLite.Links != 3 {
+			t.Fatalf("unexpected sqlite report: %+v", rep.SQLite)
+		}
+	}
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("unexpected open error: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	counts := map[string]int{
+		"crawls":          2,
+		"ai_policies":     2,
+		"pages":           6,
+		"links":           6,
+		"page_robots":     2,
+		"alternates":      2,
+		"images":          2,
+		"page_tables":     2,
+		"field_values":    4,
+		"captures":        0,
+		"page_ai_signals": 0,
+	}
+	for table, want := range counts {
+		var got int
+		if err := db.QueryRow("SELECT count(*) FROM " + table).Scan(&got); err != nil {
+			t.Fatalf("unexpected count error for %s: %v", table, err)
+		}
+		if got != want {
+			t.Fatalf("expected %d rows in %s, got %d", want, table, got)
+		}
+	}
+
+	rows, err := db.Query(`SELECT c.domain, p.title FROM pages_fts
+		JOIN pages p ON p.id = pages_fts.rowid
+		JOIN crawls c ON c.id = p.crawl_id
+		WHERE pages_fts MATCH 'roses' ORDER BY c.id`)
+	if err != nil {
+		t.Fatalf("unexpected fts query error: %v", err)
+	}
+	var matches []string
+	for rows.Next() {
+		var domain, title string
+		if err := rows.Scan(&domain, &title); err != nil {
+			t.Fatalf("unexpected scan error: %v", err)
+		}
+		matches = append(matches, domain+" "+title)
+	}
+	rows.Close()
+	if len(matches) != 2 || matches[0] != "a.example Docs" || matches[1] != "b.example Docs" {
+		t.Fatalf("unexpected fts matches: %v", matches)
+	}
+
+	var resolved, dangling int
+	if err := db.QueryRow(`SELECT count(target_page_id), count(*) - count(target_page_id) FROM links WHERE crawl_id = 1`).Scan(&resolved, &dangling); err != nil {
+		t.Fatalf("unexpected links query error: %v", err)
+	}
+	if resolved != 3 || dangling != 0 {
+		t.Fatalf("expected all links to resolve to pages, got %d resolved, %d dangling", resolved, dangling)
+	}
+
+	var outPath string
+	var gotScore float64
+	if err := db.QueryRow(`SELECT out_path, score FROM pages WHERE crawl_id = 2 AND position = 0`).Scan(&outPath, &gotScore); err != nil {
+		t.Fatalf("unexpected page query error: %v", err)
+	}
+	if outPath != "index.md" || gotScore != score {
+		t.Fatalf("unexpected page row: out_path=%q score=%v", outPath, gotScore)
+	}
+
+	if _, err := db.Exec(`PRAGMA foreign_keys = ON`); err != nil {
+		t.Fatalf("unexpected pragma error: %v", err)
+	}
+	if _, err := db.Exec(`DELETE FROM crawls WHERE id = 1`); err != nil {
+		t.Fatalf("unexpected delete error: %v", err)
+	}
+	var remaining int
+	if err := db.QueryRow(`SELECT count(*) FROM pages_fts WHERE pages_fts MATCH 'roses'`).Scan(&remaining); err != nil {
+		t.Fatalf("unexpected fts query error: %v", err)
+	}
+	if remaining != 1 {
+		t.Fatalf("expected deleted crawl to leave the fts index, got %d matches", remaining)
+	}
+}
+
+func TestWriteSQLiteRejectsNewerSchema(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "crawls.db")
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("unexpected open error: %v", err)
+	}
+	if _, err := db.Exec(`PRAGMA user_version = 99`); err != nil {
+		t.Fatalf("unexpected pragma error: %v", err)
+	}
+	db.Close()
+
+	if _, err := writeSQLite(&crawler.CrawlResult{Domain: "example.com"}, dbPath); err == nil {
+		t.Fatalf("expected newer schema version to be rejected")
+	}
+}
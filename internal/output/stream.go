@@ -0,0 +1,373 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/sbstn/sitecrawl/internal/crawler"
+)
+
+const (
+	// PagesJSONLName receives one line per finished page while the crawl runs.
+	PagesJSONLName = "pages.jsonl"
+	// ReportName is the crawl summary, finalized when the crawl ends.
+	ReportName = "report.json"
+)
+
+// streamRecord is one pages.jsonl line: the page's report entry plus the
+// crawl counters as of that page, so the last line carries the totals.
+type streamRecord struct {
+	ReportPage
+	Totals ReportTotals `json:"totals"`
+}
+
+// StreamWriter implements crawler.PageSink. It writes each page file and its
+// pages.jsonl line as soon as the page is finished, and a provisional
+// report.json marked partial at the start; Finish writes the remaining
+// artifacts and the final report. Lines are written unbuffered, so they
+// survive the process being killed.
+//
+// An archive writer streams the same files into a single archive instead.
+// Archive entries cannot be rewritten, so it skips the provisional report
+// and adds pages.jsonl when the crawl finishes.
+type StreamWriter struct {
+	files   fileSink
+	format  Format
+	opts    Options
+	mapper  *FilenameMapper
+	jsonl   io.Writer
+	file    *os.File
+	archive *archiveSink
+	warc    *warcWriter
+	result  *crawler.CrawlResult
+	// retainContent keeps page content in memory after writing. Without it,
+	// content that no end-of-crawl artifact needs is dropped once written.
+	retainContent bool
+}
+
+// NewStreamWriter creates outDir and truncates pages.jsonl.
+func NewStreamWriter(outDir string, format Format, opts Options) (*StreamWriter, error) {
+	if outDir == "" {
+		return nil, fmt.Errorf("output directory is empty")
+	}
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return nil, err
+	}
+	var warc *warcWriter
+	if opts.WARC || format == FormatWARC {
+		var err error
+		if warc, err = newWARCWriter(outDir); err != nil {
+			return nil, err
+		}
+	}
+	jsonl, err := os.Create(filepath.Join(outDir, PagesJSONLName))
+	if err != nil {
+		if warc != nil {
+			warc.close()
+		}
+		return nil, err
+	}
+	return &StreamWriter{
+		files:  dirSink(outDir),
+		format: format,
+		opts:   opts,
+		mapper: NewLayoutMapper(format, opts.Layout),
+		jsonl:  jsonl,
+		file:   jsonl,
+		warc:   warc,
+	}, nil
+}
+
+// NewArchiveWriter creates the archive file at path and streams all output
+// files into it.
+func NewArchiveWriter(path string, archive ArchiveFormat, format Format, opts Options) (*StreamWriter, error) {
+	if path == "" {
+		return nil, fmt.Errorf("archive path is empty")
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, err
+		}
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	sink, err := newArchiveSink(file, archive)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	var warc *warcWriter
+	if opts.WARC || format == FormatWARC {
+		if warc, err = newWARCWriter(""); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+	return &StreamWriter{
+		files:   sink,
+		format:  format,
+		opts:    opts,
+		mapper:  NewLayoutMapper(format, opts.Layout),
+		jsonl:   &bytes.Buffer{},
+		file:    file,
+		archive: sink,
+		warc:    warc,
+	}, nil
+}
+
+// Begin writes a provisional report.json with the crawl metadata and no
+// pages, and starts the WARC file.
+func (w *StreamWriter) Begin(result *crawler.CrawlResult) error {
+	w.result = result
+	if w.warc != nil {
+		if err := w.warc.begin(result); err != nil {
+			return err
+		}
+	}
+	if w.archive != nil {
+		return nil
+	}
+	rep := buildReport(&crawler.CrawlResult{
+		Domain:       result.Domain,
+		AllowedHosts: result.AllowedHosts,
+		StartedAt:    result.StartedAt,
+		Strategy:     result.Strategy,
+		MaxPages:     result.MaxPages,
+		MaxDepth:     result.MaxDepth,
+		FieldNames:   result.FieldNames,
+		Clean:        result.Clean,
+		Extractor:    result.Extractor,
+		Headful:      result.Headful,
+	})
+	rep.Partial = true
+	return writeReport(rep, w.files)
+}
+
+// WritePage writes the page file and WARC records for a successful page, then
+// appends the page's pages.jsonl line.
+func (w *StreamWriter) WritePage(page *crawler.Page) error {
+	if page.Status == crawler.StatusOK && w.warc != nil {
+		if err := w.warc.writePage(page); err != nil {
+			return err
+		}
+	}
+	if page.Status == crawler.StatusOK && w.format != FormatWARC {
+		clean := w.result != nil && w.result.Clean
+		filename := w.mapper.FilenameForURL(pageLocation(page))
+		content, err := renderPage(page, w.format, clean)
+		if err != nil {
+			return err
+		}
+		if err := w.files.WriteFile(filename, []byte(content)); err != nil {
+			return err
+		}
+		if err := writeTableCSVs(page.Tables, w.files, filename); err != nil {
+			return err
+		}
+		page.OutPath = filename
+	}
+
+	record := streamRecord{ReportPage: toReportPage(page)}
+	if w.result != nil {
+		record.Totals = toReportTotals(w.result.Totals)
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if _, err := w.jsonl.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	if !w.retainContent && !w.opts.needsContent() {
+		releaseContent(page)
+	}
+	return nil
+}
+
+// Finish writes the end-of-crawl artifacts and the final report.json for
+// result, which must hold every page passed to WritePage.
+func (w *StreamWriter) Finish(result *crawler.CrawlResult) error {
+	files, opts := w.files, w.opts
+	if buffered, ok := w.jsonl.(*bytes.Buffer); ok {
+		if err := files.WriteFile(PagesJSONLName, buffered.Bytes()); err != nil {
+			return err
+		}
+	}
+	if len(result.FieldNames) > 0 {
+		if err := writeFieldsCSV(result, files); err != nil {
+			return err
+		}
+	}
+
+	rep := buildReport(result)
+	if opts.ExternalLinksCSV {
+		if err := writeExternalLinksCSV(result, files); err != nil {
+			return err
+		}
+		if rep.ExternalLinks == nil {
+			rep.ExternalLinks = &ReportExternalLinks{Domains: []ReportExternalDomain{}}
+		}
+		rep.ExternalLinks.Path = ExternalLinksCSVName
+	}
+	if opts.Chunks != nil {
+		total, err := writeChunks(result, files, *opts.Chunks)
+		if err != nil {
+			return err
+		}
+		rep.Chunks = &ReportChunks{
+			Path:          ChunksName,
+			Total:         total,
+			Tokenizer:     string(opts.Chunks.Tokenizer),
+			MaxTokens:     opts.Chunks.MaxTokens,
+			OverlapTokens: opts.Chunks.OverlapTokens,
+		}
+	}
+	if opts.LLMsTxt {
+		if err := writeLLMsTxt(result, files); err != nil {
+			return err
+		}
+		rep.LLMsTxt = []string{LLMsTxtName, LLMsFullTxtName}
+	}
+	if w.warc != nil {
+		warc, err := w.warc.finish(files, w.archive)
+		if err != nil {
+			return err
+		}
+		rep.WARC = warc
+	}
+	if opts.Pack != nil {
+		pack, err := writePack(result, files, *opts.Pack)
+		if err != nil {
+			return err
+		}
+		rep.Pack = pack
+	}
+	if result.LinkCheck != nil {
+		links, err := writeBrokenLinks(result.LinkCheck, files)
+		if err != nil {
+			return err
+		}
+		rep.BrokenLinks = links
+	}
+	if len(opts.Graph) > 0 && result.LinkGraph != nil {
+		graph, err := writeGraph(result, files, opts.Graph)
+		if err != nil {
+			return err
+		}
+		rep.Graph = graph
+	}
+	if opts.SQLite != "" {
+		db, err := writeSQLite(result, opts.SQLite)
+		if err != nil {
+			return err
+		}
+		rep.SQLite = db
+	}
+	if opts.Audit != nil {
+		audit, err := writeAudit(rep, files, *opts.Audit)
+		if err != nil {
+			return err
+		}
+		rep.Audit = audit
+	}
+	if err := writeReport(rep, files); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// Close closes pages.jsonl and the WARC file, or finishes the archive. It is
+// safe to call more than once; after a failed crawl it leaves whatever was
+// written so far.
+func (w *StreamWriter) Close() error {
+	if w.file == nil {
+		return nil
+	}
+	var err error
+	if w.warc != nil {
+		err = w.warc.close()
+	}
+	if w.archive != nil {
+		if closeErr := w.archive.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if closeErr := w.file.Close(); err == nil {
+		err = closeErr
+	}
+	w.file = nil
+	return err
+}
+
+// needsContent reports whether an end-of-crawl artifact reads page content.
+func (o Options) needsContent() bool {
+	return o.Chunks != nil || o.LLMsTxt || o.Pack != nil || o.SQLite != ""
+}
+
+// releaseContent drops the bulky page content once it has been written.
+func releaseContent(page *crawler.Page) {
+	page.MainText = ""
+	page.MainHTML = ""
+	page.BodyHTML = ""
+	page.RawHTML = ""
+	page.Capture = nil
+}
+
+// RecoverReport rebuilds report.json in outDir from the provisional report
+// and pages.jsonl after a crawl died before finishing. The recovered report
+// keeps crawl order, has no scores, and stays marked partial. A torn final
+// line is ignored.
+func RecoverReport(outDir string) error {
+	reportJSON, err := os.ReadFile(filepath.Join(outDir, ReportName))
+	if err != nil {
+		return err
+	}
+	var rep Report
+	if err := json.Unmarshal(reportJSON, &rep); err != nil {
+		return fmt.Errorf("parse %s: %w", ReportName, err)
+	}
+	if !rep.Partial {
+		return fmt.Errorf("%s is already complete", filepath.Join(outDir, ReportName))
+	}
+
+	jsonlPath := filepath.Join(outDir, PagesJSONLName)
+	data, err := os.ReadFile(jsonlPath)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(jsonlPath)
+	if err != nil {
+		return err
+	}
+
+	rep.Pages = []ReportPage{}
+	lines := bytes.Split(data, []byte("\n"))
+	// The last element is empty after a complete final line and a torn
+	// write otherwise; either way it is not a record.
+	lines = lines[:len(lines)-1]
+	for i, line := range lines {
+		var record streamRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			return fmt.Errorf("%s:%d: %w", PagesJSONLName, i+1, err)
+		}
+		record.Score = nil
+		rep.Pages = append(rep.Pages, record.ReportPage)
+		rep.Totals = record.Totals
+	}
+	rep.FinishedAt = info.ModTime().UTC()
+	return writeReport(rep, dirSink(outDir))
+}
+
+func writeReport(rep Report, files fileSink) error {
+	reportJSON, err := json.MarshalIndent(rep, "", "  ")
+	if err != nil {
+		return err
+	}
+	return files.WriteFile(ReportName, reportJSON)
+}
@@ -0,0 +1,142 @@
+package output
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sbstn/sitecrawl/internal/crawler"
+)
+
+func TestStreamWriterRecoversReportAfterCrash(t *testing.T) {
+	tmpDir := t.TempDir()
+	result := &crawler.CrawlResult{
+		Domain:    "example.com",
+		StartedAt: time.Date(2025, 3, 4, 5, 6, 7, 0, time.UTC),
+		Strategy:  crawler.StrategyPageRank,
+		Clean:     true,
+	}
+	writer, err := NewStreamWriter(tmpDir, FormatMarkdown, Options{})
+	if err != nil {
+		t.Fatalf("unexpected writer error: %v", err)
+	}
+	if err := writer.Begin(result); err != nil {
+		t.Fatalf("unexpected begin error: %v", err)
+	}
+
+	pages := []*crawler.Page{
+		{URL: "https://example.com/", FinalURL: "https://example.com/", Status: crawler.StatusOK, Title: "Home", MainText: "Welcome", MainHTML: "<p>Welcome</p>"},
+		{URL: "https://example.com/broken", Status: crawler.StatusError, Error: "timeout"},
+	}
+	for _, page := range pages {
+		result.Totals.Visited++
+		if page.Status == crawler.StatusError {
+			result.Totals.Errors++
+		}
+		result.Pages = append(result.Pages, page)
+		if err := writer.WritePage(page); err != nil {
+			t.Fatalf("unexpected write page error: %v", err)
+		}
+	}
+	if pages[0].OutPath != "index.md" {
+		t.Fatalf("expected page file to be written during the crawl, got out_path %q", pages[0].OutPath)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "index.md")); err != nil {
+		t.Fatalf("expected page file on disk: %v", err)
+	}
+	if pages[0].MainHTML != "" || pages[0].MainText != "" {
+		t.Fatalf("expected written content to be released from memory")
+	}
+
+	// Simulate the process dying mid-write: no Finish, and a torn last line.
+	jsonl, err := os.OpenFile(filepath.Join(tmpDir, PagesJSONLName), os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatalf("unexpected open error: %v", err)
+	}
+	if _, err := jsonl.WriteString(`{"url":"https://example.com/tor`); err != nil {
+		t.Fatalf("unexpected write error: %v", err)
+	}
+	jsonl.Close()
+
+	rep := readReport(t, tmpDir)
+	if !rep.Partial || len(rep.Pages) != 0 {
+		t.Fatalf("expected provisional partial report without pages, got %+v", rep)
+	}
+
+	if err := RecoverReport(tmpDir); err != nil {
+		t.Fatalf("unexpected recover error: %v", err)
+	}
+	rep = readReport(t, tmpDir)
+	if !rep.Partial || rep.Domain != "example.com" || rep.Strategy != "pagerank" {
+		t.Fatalf("unexpected recovered metadata: %+v", rep)
+	}
+	if len(rep.Pages) != 2 || rep.Pages[0].OutPath != "index.md" || rep.Pages[1].Error != "timeout" {
+		t.Fatalf("unexpected recovered pages: %+v", rep.Pages)
+	}
+	if rep.Totals.Visited != 2 || rep.Totals.Errors != 1 {
+		t.Fatalf("unexpected recovered totals: %+v", rep.Totals)
+	}
+	if rep.FinishedAt.IsZero() {
+		t.Fatalf("expected recovered finished_at from pages.jsonl")
+	}
+}
+
+func TestStreamWriterFinishWritesFinalReport(t *testing.T) {
+	tmpDir := t.TempDir()
+	result := &crawler.CrawlResult{Domain: "example.com", Strategy: crawler.StrategyLimit, Clean: true}
+	writer, err := NewStreamWriter(tmpDir, FormatMarkdown, Options{LLMsTxt: true})
+	if err != nil {
+		t.Fatalf("unexpected writer error: %v", err)
+	}
+	if err := writer.Begin(result); err != nil {
+		t.Fatalf("unexpected begin error: %v", err)
+	}
+	page := &crawler.Page{URL: "https://example.com/", FinalURL: "https://example.com/", Status: crawler.StatusOK, Title: "Home", MainText: "Welcome"}
+	result.Totals.Visited++
+	result.Pages = append(result.Pages, page)
+	if err := writer.WritePage(page); err != nil {
+		t.Fatalf("unexpected write page error: %v", err)
+	}
+	if page.MainText != "Welcome" {
+		t.Fatalf("expected content kept for llms-full.txt")
+	}
+	if err := writer.Finish(result); err != nil {
+		t.Fatalf("unexpected finish error: %v", err)
+	}
+
+	rep := readReport(t, tmpDir)
+	if rep.Partial || len(rep.Pages) != 1 || rep.Pages[0].OutPath != "index.md" {
+		t.Fatalf("unexpected final report: %+v", rep)
+	}
+	if err := RecoverReport(tmpDir); err == nil || !strings.Contains(err.Error(), "already complete") {
+		t.Fatalf("expected recovery of a complete report to fail, got %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(tmpDir, PagesJSONLName))
+	if err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+	var record streamRecord
+	if err := json.Unmarshal([]byte(strings.TrimSpace(string(data))), &record); err != nil {
+		t.Fatalf("expected one jsonl record, got %q: %v", data, err)
+	}
+	if record.URL != "https://example.com/" || record.Totals.Visited != 1 {
+		t.Fatalf("unexpected jsonl record: %+v", record)
+	}
+}
+
+func readReport(t *testing.T, outDir string) Report {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(outDir, ReportName))
+	if err != nil {
+		t.Fatalf("unexpected report read error: %v", err)
+	}
+	var rep Report
+	if err := json.Unmarshal(data, &rep); err != nil {
+		t.Fatalf("unexpected report decode error: %v", err)
+	}
+	return rep
+}
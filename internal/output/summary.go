@@ -0,0 +1,327 @@
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/sbstn/sitecrawl/internal/crawler"
+)
+
+// SummaryFormat selects how a report summary is printed.
+type SummaryFormat string
+
+const (
+	// SummaryTable prints aligned, human-readable sections.
+	SummaryTable SummaryFormat = "table"
+	// SummaryJSON prints the summary as one JSON object.
+	SummaryJSON SummaryFormat = "json"
+	// SummaryCSV prints one row per summary entry with a section column.
+	SummaryCSV SummaryFormat = "csv"
+)
+
+// ParseSummaryFormat validates and normalizes the report output flag.
+func ParseSummaryFormat(raw string) (SummaryFormat, error) {
+	switch SummaryFormat(strings.ToLower(strings.TrimSpace(raw))) {
+	case SummaryTable:
+		return SummaryTable, nil
+	case SummaryJSON:
+		return SummaryJSON, nil
+	case SummaryCSV:
+		return SummaryCSV, nil
+	default:
+		return "", fmt.Errorf("invalid output %q (allowed: table, json, csv)", raw)
+	}
+}
+
+// ReadReport reads report.json from path, which may be the file itself or
+// the crawl's output directory.
+func ReadReport(path string) (Report, error) {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		path = filepath.Join(path, ReportName)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Report{}, err
+	}
+	var rep Report
+	if err := json.Unmarshal(data, &rep); err != nil {
+		return Report{}, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return rep, nil
+}
+
+// ReportFilter selects the pages a summary covers. A page must match one of
+// Statuses and one of URLs when those are set.
+type ReportFilter struct {
+	Statuses []string
+	MinDepth int
+	// MaxDepth is inclusive; negative means no limit.
+	MaxDepth int
+	URLs     []crawler.URLPattern
+}
+
+// Match reports whether page passes the filter.
+func (f ReportFilter) Match(page ReportPage) bool {
+	if len(f.Statuses) > 0 && !slices.Contains(f.Statuses, page.Status) {
+		return false
+	}
+	if page.Depth < f.MinDepth || (f.MaxDepth >= 0 && page.Depth > f.MaxDepth) {
+		return false
+	}
+	if len(f.URLs) == 0 {
+		return true
+	}
+	for _, pattern := range f.URLs {
+		if pattern.Match(page.URL) || (page.FinalURL != "" && pattern.Match(page.FinalURL)) {
+			return true
+		}
+	}
+	return false
+}
+
+// ReportSummary answers the usual questions about a finished crawl.
+type ReportSummary struct {
+	Domain          string            `json:"domain"`
+	Strategy        string            `json:"strategy"`
+	Partial         bool              `json:"partial,omitempty"`
+	Pages           int               `json:"pages"`
+	TopPages        []SummaryPage     `json:"top_pages"`
+	Statuses        []StatusCount     `json:"statuses"`
+	Errors          []ErrorGroup      `json:"errors"`
+	Depths          []DepthCount      `json:"depths"`
+	MissingMetadata []MissingMetadata `json:"missing_metadata"`
+}
+
+// SummaryPage is one entry of the top pages by score.
+type SummaryPage struct {
+	URL   string  `json:"url"`
+	Title string  `json:"title,omitempty"`
+	Depth int     `json:"depth"`
+	Score float64 `json:"score"`
+}
+
+// StatusCount is the number of pages with one status.
+type StatusCount struct {
+	Status string `json:"status"`
+	Count  int    `json:"count"`
+}
+
+// ErrorGroup lists the pages that failed with the same message.
+type ErrorGroup struct {
+	Message string   `json:"message"`
+	Count   int      `json:"count"`
+	URLs    []string `json:"urls"`
+}
+
+// DepthCount is the number of pages at one crawl depth.
+type DepthCount struct {
+	Depth int `json:"depth"`
+	Count int `json:"count"`
+}
+
+// MissingMetadata is a successfully crawled page without a title or
+// description.
+type MissingMetadata struct {
+	URL                string `json:"url"`
+	MissingTitle       bool   `json:"missing_title"`
+	MissingDescription bool   `json:"missing_description"`
+}
+
+// Summarize builds the summary of the pages in rep that match filter. At
+// most top pages are listed by score; pages without a score are not ranked.
+func Summarize(rep Report, filter ReportFilter, top int) ReportSummary {
+	summary := ReportSummary{
+		Domain:          rep.Domain,
+		Strategy:        rep.Strategy,
+		Partial:         rep.Partial,
+		TopPages:        []SummaryPage{},
+		Statuses:        []StatusCount{},
+		Errors:          []ErrorGroup{},
+		Depths:          []DepthCount{},
+		MissingMetadata: []MissingMetadata{},
+	}
+	statuses := map[string]int{}
+	depths := map[int]int{}
+	errorIndex := map[string]int{}
+	for _, page := range rep.Pages {
+		if !filter.Match(page) {
+			continue
+		}
+		summary.Pages++
+		statuses[page.Status]++
+		depths[page.Depth]++
+		if page.Score != nil {
+			summary.TopPages = append(summary.TopPages, SummaryPage{
+				URL:   page.URL,
+				Title: page.Title,
+				Depth: page.Depth,
+				Score: *page.Score,
+			})
+		}
+		if page.Error != "" {
+			i, ok := errorIndex[page.Error]
+			if !ok {
+				i = len(summary.Errors)
+				errorIndex[page.Error] = i
+				summary.Errors = append(summary.Errors, ErrorGroup{Message: page.Error})
+			}
+			summary.Errors[i].Count++
+			summary.Errors[i].URLs = append(summary.Errors[i].URLs, page.URL)
+		}
+		if page.Status == crawler.StatusOK && (strings.TrimSpace(page.Title) == "" || strings.TrimSpace(page.Description) == "") {
+			summary.MissingMetadata = append(summary.MissingMetadata, MissingMetadata{
+				URL:                page.URL,
+				MissingTitle:       strings.TrimSpace(page.Title) == "",
+				MissingDescription: strings.TrimSpace(page.Description) == "",
+			})
+		}
+	}
+
+	sort.SliceStable(summary.TopPages, func(i, j int) bool {
+		if summary.TopPages[i].Score != summary.TopPages[j].Score {
+			return summary.TopPages[i].Score > summary.TopPages[j].Score
+		}
+		return summary.TopPages[i].URL < summary.TopPages[j].URL
+	})
+	if top >= 0 && len(summary.TopPages) > top {
+		summary.TopPages = summary.TopPages[:top]
+	}
+	for status, count := range statuses {
+		summary.Statuses = append(summary.Statuses, StatusCount{Status: status, Count: count})
+	}
+	sort.Slice(summary.Statuses, func(i, j int) bool {
+		if summary.Statuses[i].Count != summary.Statuses[j].Count {
+			return summary.Statuses[i].Count > summary.Statuses[j].Count
+		}
+		return summary.Statuses[i].Status < summary.Statuses[j].Status
+	})
+	// Groups keep first-seen order among equal counts.
+	sort.SliceStable(summary.Errors, func(i, j int) bool {
+		return summary.Errors[i].Count > summary.Errors[j].Count
+	})
+	for depth, count := range depths {
+		summary.Depths = append(summary.Depths, DepthCount{Depth: depth, Count: count})
+	}
+	sort.Slice(summary.Depths, func(i, j int) bool {
+		return summary.Depths[i].Depth < summary.Depths[j].Depth
+	})
+	return summary
+}
+
+// WriteSummary prints summary to w in format.
+func WriteSummary(w io.Writer, summary ReportSummary, format SummaryFormat) error {
+	switch format {
+	case SummaryJSON:
+		data, err := json.MarshalIndent(summary, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(append(data, '\n'))
+		return err
+	case SummaryCSV:
+		return writeSummaryCSV(w, summary)
+	case SummaryTable:
+		return writeSummaryTable(w, summary)
+	default:
+		return fmt.Errorf("unsupported summary format: %s", format)
+	}
+}
+
+// writeSummaryCSV writes every section into one table. Columns a section
+// does not use are left empty.
+func writeSummaryCSV(w io.Writer, summary ReportSummary) error {
+	out := csv.NewWriter(w)
+	rows := [][]string{{"section", "key", "count", "url", "depth", "score", "title"}}
+	for _, page := range summary.TopPages {
+		rows = append(rows, []string{"top_pages", "", "", page.URL, strconv.Itoa(page.Depth), formatScore(page.Score), page.Title})
+	}
+	for _, status := range summary.Statuses {
+		rows = append(rows, []string{"statuses", status.Status, strconv.Itoa(status.Count), "", "", "", ""})
+	}
+	for _, group := range summary.Errors {
+		for _, pageURL := range group.URLs {
+			rows = append(rows, []string{"errors", group.Message, strconv.Itoa(group.Count), pageURL, "", "", ""})
+		}
+	}
+	for _, depth := range summary.Depths {
+		rows = append(rows, []string{"depths", strconv.Itoa(depth.Depth), strconv.Itoa(depth.Count), "", "", "", ""})
+	}
+	for _, page := range summary.MissingMetadata {
+		rows = append(rows, []string{"missing_metadata", missingFields(page), "", page.URL, "", "", ""})
+	}
+	if err := out.WriteAll(rows); err != nil {
+		return err
+	}
+	return out.Error()
+}
+
+func writeSummaryTable(w io.Writer, summary ReportSummary) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	partial := ""
+	if summary.Partial {
+		partial = " (partial)"
+	}
+	fmt.Fprintf(tw, "%s: %d pages%s\n", summary.Domain, summary.Pages, partial)
+
+	fmt.Fprintf(tw, "\nTop pages by score\n")
+	if len(summary.TopPages) == 0 {
+		fmt.Fprintf(tw, "  no scored pages (strategy %s)\n", summary.Strategy)
+	}
+	for i, page := range summary.TopPages {
+		fmt.Fprintf(tw, "  %d\t%s\t%s\t%s\n", i+1, formatScore(page.Score), page.URL, page.Title)
+	}
+
+	fmt.Fprintf(tw, "\nStatus\n")
+	for _, status := range summary.Statuses {
+		fmt.Fprintf(tw, "  %s\t%d\n", status.Status, status.Count)
+	}
+
+	fmt.Fprintf(tw, "\nErrors\n")
+	if len(summary.Errors) == 0 {
+		fmt.Fprintf(tw, "  none\n")
+	}
+	for _, group := range summary.Errors {
+		fmt.Fprintf(tw, "  %d\t%s\n", group.Count, group.Message)
+		for _, pageURL := range group.URLs {
+			fmt.Fprintf(tw, "  \t  %s\n", pageURL)
+		}
+	}
+
+	fmt.Fprintf(tw, "\nDepth\n")
+	for _, depth := range summary.Depths {
+		fmt.Fprintf(tw, "  %d\t%d\n", depth.Depth, depth.Count)
+	}
+
+	fmt.Fprintf(tw, "\nMissing metadata\n")
+	if len(summary.MissingMetadata) == 0 {
+		fmt.Fprintf(tw, "  none\n")
+	}
+	for _, page := range summary.MissingMetadata {
+		fmt.Fprintf(tw, "  %s\t%s\n", missingFields(page), page.URL)
+	}
+	return tw.Flush()
+}
+
+func missingFields(page MissingMetadata) string {
+	switch {
+	case page.MissingTitle && page.MissingDescription:
+		return "title,description"
+	case page.MissingTitle:
+		return "title"
+	default:
+		return "description"
+	}
+}
+
+func formatScore(score float64) string {
+	return strconv.FormatFloat(score, 'f', 6, 64)
+}
@@ -0,0 +1,149 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sbstn/sitecrawl/internal/crawler"
+)
+
+func TestSummarize(t *testing.T) {
+	score := func(v float64) *float64 { return &v }
+	rep := Report{
+		Domain:   "example.com",
+		Strategy: "pagerank",
+		Pages: []ReportPage{
+			{URL: "https://example.com/", Depth: 0, Status: crawler.StatusOK, Title: "Home", Description: "Welcome", Score: score(0.4)},
+			{URL: "https://example.com/docs", Depth: 1, Status: crawler.StatusOK, Title: "Docs", Score: score(0.3)},
+			{URL: "https://example.com/blog", Depth: 1, Status: crawler.StatusOK, Score: score(0.2)},
+			{URL: "https://example.com/a", Depth: 2, Status: crawler.StatusError, Error: "timeout"},
+			{URL: "https://example.com/b", Depth: 2, Status: crawler.StatusError, Error: "net::ERR_NAME_NOT_RESOLVED"},
+			{URL: "https://example.com/c", Depth: 2, Status: crawler.StatusError, Error: "timeout"},
+			{URL: "https://example.com/private", Depth: 1, Status: crawler.StatusSkippedRobots},
+		},
+	}
+
+	t.Run("counts", func(t *testing.T) {
+		summary := Summarize(rep, ReportFilter{MaxDepth: -1}, 2)
+
+		if summary.Pages != 7 {
+			t.Fatalf("expected 7 pages, got %d", summary.Pages)
+		}
+		if len(summary.TopPages) != 2 || summary.TopPages[0].URL != "https://example.com/" || summary.TopPages[1].URL != "https://example.com/docs" {
+			t.Fatalf("unexpected top pages: %+v", summary.TopPages)
+		}
+		wantStatuses := []StatusCount{{Status: "error", Count: 3}, {Status: "ok", Count: 3}, {Status: "skipped_robots", Count: 1}}
+		if !reflect.DeepEqual(summary.Statuses, wantStatuses) {
+			t.Fatalf("unexpected statuses: %+v", summary.Statuses)
+		}
+		wantErrors := []ErrorGroup{
+			{Message: "timeout", Count: 2, URLs: []string{"https://example.com/a", "https://example.com/c"}},
+			{Message: "net::ERR_NAME_NOT_RESOLVED", Count: 1, URLs: []string{"https://example.com/b"}},
+		}
+		if !reflect.DeepEqual(summary.Errors, wantErrors) {
+			t.Fatalf("unexpected errors: %+v", summary.Errors)
+		}
+		wantDepths := []DepthCount{{Depth: 0, Count: 1}, {Depth: 1, Count: 3}, {Depth: 2, Count: 3}}
+		if !reflect.DeepEqual(summary.Depths, wantDepths) {
+			t.Fatalf("unexpected depths: %+v", summary.Depths)
+		}
+		wantMissing := []MissingMetadata{
+			{URL: "https://example.com/docs", MissingDescription: true},
+			{URL: "https://example.com/blog", MissingTitle: true, MissingDescription: true},
+		}
+		if !reflect.DeepEqual(summary.MissingMetadata, wantMissing) {
+			t.Fatalf("unexpected missing metadata: %+v", summary.MissingMetadata)
+		}
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			filter ReportFilter
+			want   int
+		}{
+			{name: "status", filter: ReportFilter{Statuses: []string{"error"}, MaxDepth: -1}, want: 3},
+			{name: "depth range", filter: ReportFilter{MinDepth: 1, MaxDepth: 1}, want: 3},
+			{name: "path glob", filter: ReportFilter{MaxDepth: -1, URLs: []crawler.URLPattern{crawler.CompileURLPattern("/docs*")}}, want: 1},
+			{name: "url glob", filter: ReportFilter{MaxDepth: -1, URLs: []crawler.URLPattern{crawler.CompileURLPattern("https://example.com/*")}}, want: 7},
+		}
+		for _, tt := range tests {
+			if got := Summarize(rep, tt.filter, 10).Pages; got != tt.want {
+				t.Fatalf("%s: expected %d pages, got %d", tt.name, tt.want, got)
+			}
+		}
+	})
+
+	t.Run("formats", func(t *testing.T) {
+		summary := Summarize(rep, ReportFilter{MaxDepth: -1}, 10)
+
+		var table bytes.Buffer
+		if err := WriteSummary(&table, summary, SummaryTable); err != nil {
+			t.Fatalf("write table: %v", err)
+		}
+		for _, want := range []string{"example.com: 7 pages", "Top pages by score", "0.400000", "timeout", "title,description"} {
+			if !strings.Contains(table.String(), want) {
+				t.Fatalf("expected table to contain %q, got:\n%s", want, table.String())
+			}
+		}
+
+		var csvOut bytes.Buffer
+		if err := WriteSummary(&csvOut, summary, SummaryCSV); err != nil {
+			t.Fatalf("write csv: %v", err)
+		}
+		lines := strings.Split(strings.TrimSpace(csvOut.String()), "\n")
+		if lines[0] != "section,key,count,url,depth,score,title" {
+			t.Fatalf("unexpected csv header: %q", lines[0])
+		}
+		// 3 top pages, 3 statuses, 3 error pages, 3 depths, 2 missing.
+		if len(lines) != 1+3+3+3+3+2 {
+			t.Fatalf("unexpected csv rows:\n%s", csvOut.String())
+		}
+
+		var jsonOut bytes.Buffer
+		if err := WriteSummary(&jsonOut, summary, SummaryJSON); err != nil {
+			t.Fatalf("write json: %v", err)
+		}
+		var decoded ReportSummary
+		if err := json.Unmarshal(jsonOut.Bytes(), &decoded); err != nil {
+			t.Fatalf("decode json: %v", err)
+		}
+		if !reflect.DeepEqual(decoded, summary) {
+			t.Fatalf("json round trip mismatch: %+v", decoded)
+		}
+	})
+}
+
+func TestReadReportAcceptsDirectoryOrFile(t *testing.T) {
+	outDir := t.TempDir()
+	rep := Report{Domain: "example.com", Pages: []ReportPage{{URL: "https://example.com/", Status: crawler.StatusOK}}}
+	if err := writeReport(rep, dirSink(outDir)); err != nil {
+		t.Fatalf("write report: %v", err)
+	}
+	for _, path := range []string{outDir, filepath.Join(outDir, ReportName)} {
+		got, err := ReadReport(path)
+		if err != nil {
+			t.Fatalf("read %s: %v", path, err)
+		}
+		if got.Domain != "example.com" || len(got.Pages) != 1 {
+			t.Fatalf("unexpected report from %s: %+v", path, got)
+		}
+	}
+	if _, err := ReadReport(filepath.Join(outDir, "missing.json")); !os.IsNotExist(err) {
+		t.Fatalf("expected not-exist error, got %v", err)
+	}
+}
+
+func TestParseSummaryFormat(t *testing.T) {
+	if got, err := ParseSummaryFormat(" CSV "); err != nil || got != SummaryCSV {
+		t.Fatalf("unexpected result: %q, %v", got, err)
+	}
+	if _, err := ParseSummaryFormat("yaml"); err == nil {
+		t.Fatalf("expected error for yaml")
+	}
+}
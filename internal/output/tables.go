@@ -0,0 +1,109 @@
+package output
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/sbstn/sitecrawl/internal/crawler"
+)
+
+// ReportTable is a data table of a page as listed in pages.jsonl.
+type ReportTable struct {
+	Caption string     `json:"caption,omitempty"`
+	Header  []string   `json:"header,omitempty"`
+	Rows    [][]string `json:"rows"`
+}
+
+// ReportTableLocation points to an extracted table and its CSV file.
+type ReportTableLocation struct {
+	PageURL string `json:"page_url"`
+	OutPath string `json:"out_path"`
+	Index   int    `json:"index"`
+	CSVPath string `json:"csv_path"`
+	Caption string `json:"caption,omitempty"`
+	Rows    int    `json:"rows"`
+	Columns int    `json:"columns"`
+}
+
+// ReportTables holds the table inventory of report.json.
+type ReportTables struct {
+	Total  int                   `json:"total"`
+	Tables []ReportTableLocation `json:"tables"`
+}
+
+// tableCSVName places the CSV for the index-th (1-based) table next to the
+// page file: docs_page.md -> docs_page.table-1.csv.
+func tableCSVName(pageFile string, index int) string {
+	return fmt.Sprintf("%s.table-%d.csv", strings.TrimSuffix(pageFile, path.Ext(pageFile)), index)
+}
+
+// writeTableCSVs writes one CSV per table of a page. The header row is
+// written only when the table has one.
+func writeTableCSVs(tables []crawler.Table, files fileSink, pageFile string) error {
+	for i, table := range tables {
+		var buf bytes.Buffer
+		writer := csv.NewWriter(&buf)
+		if len(table.Header) > 0 {
+			if err := writer.Write(table.Header); err != nil {
+				return err
+			}
+		}
+		if err := writer.WriteAll(table.Rows); err != nil {
+			return err
+		}
+		if err := files.WriteFile(tableCSVName(pageFile, i+1), buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func toReportTables(tables []crawler.Table) []ReportTable {
+	converted := make([]ReportTable, 0, len(tables))
+	for _, table := range tables {
+		rows := table.Rows
+		if rows == nil {
+			rows = [][]string{}
+		}
+		converted = append(converted, ReportTable{
+			Caption: table.Caption,
+			Header:  table.Header,
+			Rows:    rows,
+		})
+	}
+	return converted
+}
+
+// buildTableIndex lists every written table with its page and CSV file. It
+// returns nil when no written page has tables.
+func buildTableIndex(pages []*crawler.Page) *ReportTables {
+	index := &ReportTables{Tables: []ReportTableLocation{}}
+	for _, page := range pages {
+		if page.Status != crawler.StatusOK || page.OutPath == "" {
+			continue
+		}
+		pageURL := page.FinalURL
+		if pageURL == "" {
+			pageURL = page.URL
+		}
+		for i, table := range page.Tables {
+			index.Tables = append(index.Tables, ReportTableLocation{
+				PageURL: pageURL,
+				OutPath: page.OutPath,
+				Index:   i + 1,
+				CSVPath: tableCSVName(page.OutPath, i+1),
+				Caption: table.Caption,
+				Rows:    len(table.Rows),
+				Columns: table.Columns(),
+			})
+		}
+	}
+	if len(index.Tables) == 0 {
+		return nil
+	}
+	index.Total = len(index.Tables)
+	return index
+}
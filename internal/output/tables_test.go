@@ -0,0 +1,98 @@
+package output
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sbstn/sitecrawl/internal/crawler"
+)
+
+func TestWriteTables(t *testing.T) {
+	tmpDir := t.TempDir()
+	result := &crawler.CrawlResult{
+		Domain:   "example.com",
+		Strategy: crawler.StrategyLimit,
+		Clean:    true,
+		Pages: []*crawler.Page{
+			{
+				URL:      "https://example.com/pricing",
+				FinalURL: "https://example.com/pricing",
+				Status:   crawler.StatusOK,
+				MainText: "Pricing",
+				Tables: []crawler.Table{
+					{Caption: "Plans", Header: []string{"Plan", "Price"}, Rows: [][]string{{"Basic", "$5, monthly"}}},
+					{Rows: [][]string{{"a", "b", "c"}}},
+				},
+			},
+		},
+	}
+
+	if err := Write(result, tmpDir, FormatJSON); err != nil {
+		t.Fatalf("unexpected write error: %v", err)
+	}
+
+	first, err := os.ReadFile(filepath.Join(tmpDir, "pricing.table-1.csv"))
+	if err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+	if string(first) != "Plan,Price\nBasic,\"$5, monthly\"\n" {
+		t.Fatalf("unexpected csv: %q", first)
+	}
+	second, err := os.ReadFile(filepath.Join(tmpDir, "pricing.table-2.csv"))
+	if err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+	if string(second) != "a,b,c\n" {
+		t.Fatalf("unexpected csv: %q", second)
+	}
+
+	pageBytes, err := os.ReadFile(filepath.Join(tmpDir, "pricing.json"))
+	if err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+	var page struct {
+		Tables []struct {
+			Caption string     `json:"caption"`
+			Header  []string   `json:"header"`
+			Rows    [][]string `json:"rows"`
+		} `json:"tables"`
+	}
+	if err := json.Unmarshal(pageBytes, &page); err != nil {
+		t.Fatalf("unexpected json error: %v", err)
+	}
+	if len(page.Tables) != 2 || page.Tables[0].Caption != "Plans" || page.Tables[1].Rows[0][2] != "c" {
+		t.Fatalf("unexpected page tables: %+v", page.Tables)
+	}
+
+	reportBytes, err := os.ReadFile(filepath.Join(tmpDir, "report.json"))
+	if err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+	var parsed struct {
+		Pages []struct {
+			TablesCount int `json:"tables_count"`
+		} `json:"pages"`
+		Tables struct {
+			Total  int `json:"total"`
+			Tables []struct {
+				OutPath string `json:"out_path"`
+				Index   int    `json:"index"`
+				CSVPath string `json:"csv_path"`
+				Rows    int    `json:"rows"`
+				Columns int    `json:"columns"`
+			} `json:"tables"`
+		} `json:"tables"`
+	}
+	if err := json.Unmarshal(reportBytes, &parsed); err != nil {
+		t.Fatalf("unexpected json error: %v", err)
+	}
+	if parsed.Pages[0].TablesCount != 2 || parsed.Tables.Total != 2 {
+		t.Fatalf("expected 2 tables in report, got %+v", parsed)
+	}
+	location := parsed.Tables.Tables[1]
+	if location.OutPath != "pricing.json" || location.Index != 2 || location.CSVPath != "pricing.table-2.csv" || location.Rows != 1 || location.Columns != 3 {
+		t.Fatalf("unexpected table location: %+v", location)
+	}
+}
@@ -0,0 +1,44 @@
+package output
+
+import "testing"
+
+func TestUnifiedDiff(t *testing.T) {
+	oldText := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\n"
+	newText := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\n"
+
+	got := unifiedDiff("a/x.md", "b/x.md", oldText, newText, 1)
+	want := "--- a/x.md\n+++ b/x.md\n" +
+		"@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n" +
+		"@@ -10 +10,2 @@\n j\n+k\n"
+	if got != want {
+		t.Fatalf("unexpected diff:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Changes closer than two contexts share one hunk.
+	got = unifiedDiff("a", "b", "1\n2\n3\n4\n", "1\nX\n3\nY\n", 1)
+	want = "--- a\n+++ b\n@@ -1,4 +1,4 @@\n 1\n-2\n+X\n 3\n-4\n+Y\n"
+	if got != want {
+		t.Fatalf("unexpected merged diff:\n%s\nwant:\n%s", got, want)
+	}
+
+	if got := unifiedDiff("a", "b", "same\n", "same\n", 3); got != "" {
+		t.Fatalf("expected empty diff for equal texts, got %q", got)
+	}
+
+	got = unifiedDiff("/dev/null", "b", "", "new\n", 3)
+	want = "--- /dev/null\n+++ b\n@@ -0,0 +1 @@\n+new\n"
+	if got != want {
+		t.Fatalf("unexpected diff from empty:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestDiffLinesFindsCommonSubsequence(t *testing.T) {
+	ops := diffLines([]string{"a", "b", "c", "d"}, []string{"b", "x", "d"})
+	var got string
+	for _, op := range ops {
+		got += string(op.kind) + op.line + ";"
+	}
+	if want := "-a; b;-c;+x; d;"; got != want {
+		t.Fatalf("unexpected edit script %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,88 @@
+package output
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Tokenizer approximates the token count of a model family offline. Text is
+// pre-split like BPE tokenizers do (letters, digit groups, punctuation runs);
+// each letter run then costs its length divided by the number of letters a
+// family's token covers on average inside words. CJK, kana, and hangul cost
+// one token per character.
+type Tokenizer string
+
+const (
+	// TokenizerGPT approximates OpenAI cl100k/o200k vocabularies.
+	TokenizerGPT Tokenizer = "gpt"
+	// TokenizerClaude approximates Anthropic Claude vocabularies.
+	TokenizerClaude Tokenizer = "claude"
+	// TokenizerLlama approximates Llama 3 vocabularies.
+	TokenizerLlama Tokenizer = "llama"
+	// TokenizerGemini approximates Gemini SentencePiece vocabularies.
+	TokenizerGemini Tokenizer = "gemini"
+)
+
+// charsPerToken is the average number of word letters covered by one token.
+// Common short words are a single token in every family; larger vocabularies
+// cover longer words.
+var charsPerToken = map[Tokenizer]float64{
+	TokenizerGPT:    6.0,
+	TokenizerClaude: 5.0,
+	TokenizerLlama:  5.6,
+	TokenizerGemini: 6.2,
+}
+
+var pretokenPattern = regexp.MustCompile(`\p{L}+|\p{N}{1,3}|[^\s\p{L}\p{N}]+`)
+
+// ParseTokenizer validates and normalizes the tokenizer flag.
+func ParseTokenizer(raw string) (Tokenizer, error) {
+	tokenizer := Tokenizer(strings.ToLower(strings.TrimSpace(raw)))
+	if _, ok := charsPerToken[tokenizer]; !ok {
+		return "", fmt.Errorf("invalid tokenizer %q (allowed: gpt, claude, llama, gemini)", raw)
+	}
+	return tokenizer, nil
+}
+
+// Count returns the approximate number of tokens in text.
+func (t Tokenizer) Count(text string) int {
+	ratio, ok := charsPerToken[t]
+	if !ok {
+		ratio = charsPerToken[TokenizerGPT]
+	}
+	total := 0
+	for _, piece := range pretokenPattern.FindAllString(text, -1) {
+		first, _ := utf8.DecodeRuneInString(piece)
+		switch {
+		case unicode.IsLetter(first):
+			total += letterTokens(piece, ratio)
+		case unicode.IsDigit(first) || unicode.IsNumber(first):
+			total++
+		default:
+			// Punctuation runs merge into pairs, e.g. "](" or "**".
+			total += (utf8.RuneCountInString(piece) + 1) / 2
+		}
+	}
+	return total
+}
+
+func letterTokens(word string, ratio float64) int {
+	ideographic := 0
+	other := 0
+	for _, r := range word {
+		if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) {
+			ideographic++
+		} else {
+			other++
+		}
+	}
+	total := ideographic
+	if other > 0 {
+		total += int(math.Ceil(float64(other) / ratio))
+	}
+	return total
+}
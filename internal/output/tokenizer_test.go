@@ -0,0 +1,55 @@
+package output
+
+import "testing"
+
+func TestParseTokenizer(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Tokenizer
+		wantErr bool
+	}{
+		{"gpt", TokenizerGPT, false},
+		{" Claude ", TokenizerClaude, false},
+		{"llama", TokenizerLlama, false},
+		{"gemini", TokenizerGemini, false},
+		{"bert", "", true},
+	}
+
+	for _, tc := range tests {
+		got, err := ParseTokenizer(tc.in)
+		if tc.wantErr {
+			if err == nil {
+				t.Fatalf("expected error for %q", tc.in)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("unexpected error for %q: %v", tc.in, err)
+		}
+		if got != tc.want {
+			t.Fatalf("expected %q, got %q", tc.want, got)
+		}
+	}
+}
+
+func TestTokenizerCount(t *testing.T) {
+	tests := []struct {
+		tokenizer Tokenizer
+		text      string
+		want      int
+	}{
+		{TokenizerGPT, "", 0},
+		{TokenizerGPT, "Hello world", 2},
+		{TokenizerGPT, "conventions", 2},
+		{TokenizerClaude, "conventions", 3},
+		{TokenizerGPT, "12345", 2},
+		{TokenizerGPT, "a... b", 4},
+		{TokenizerGPT, "東京タワー", 5},
+	}
+
+	for _, tc := range tests {
+		if got := tc.tokenizer.Count(tc.text); got != tc.want {
+			t.Fatalf("%s.Count(%q): expected %d, got %d", tc.tokenizer, tc.text, tc.want, got)
+		}
+	}
+}
@@ -14,6 +14,9 @@ const (
 	FormatHTML Format = "html"
 	// FormatJSON writes structured JSON per crawled page.
 	FormatJSON Format = "json"
+	// FormatWARC writes a WARC 1.1 archive with a CDXJ index instead of page
+	// files. It can be combined with one page format.
+	FormatWARC Format = "warc"
 )
 
 // ParseFormat validates and normalizes the output format flag.
@@ -25,7 +28,35 @@ func ParseFormat(raw string) (Format, error) {
 		return FormatHTML, nil
 	case string(FormatJSON):
 		return FormatJSON, nil
+	case string(FormatWARC):
+		return FormatWARC, nil
 	default:
-		return "", fmt.Errorf("invalid format %q (allowed: md, html, json)", raw)
+		return "", fmt.Errorf("invalid format %q (allowed: md, html, json, warc)", raw)
 	}
 }
+
+// ParseFormats parses a comma-separated format list such as "md,warc" into
+// the page format and whether a WARC archive is requested. With only "warc",
+// the page format is FormatWARC and no page files are written.
+func ParseFormats(raw string) (Format, bool, error) {
+	var pageFormat Format
+	warc := false
+	for _, part := range strings.Split(raw, ",") {
+		format, err := ParseFormat(part)
+		if err != nil {
+			return "", false, err
+		}
+		if format == FormatWARC {
+			warc = true
+			continue
+		}
+		if pageFormat != "" && pageFormat != format {
+			return "", false, fmt.Errorf("invalid format %q (at most one of md, html, json)", raw)
+		}
+		pageFormat = format
+	}
+	if pageFormat == "" {
+		pageFormat = FormatWARC
+	}
+	return pageFormat, warc, nil
+}
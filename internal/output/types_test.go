@@ -11,6 +11,7 @@ func TestParseFormat(t *testing.T) {
 		{"md", FormatMarkdown, false},
 		{"HTML", FormatHTML, false},
 		{" json ", FormatJSON, false},
+		{"warc", FormatWARC, false},
 		{"pdf", "", true},
 	}
 
@@ -30,3 +31,35 @@ func TestParseFormat(t *testing.T) {
 		}
 	}
 }
+
+func TestParseFormats(t *testing.T) {
+	tests := []struct {
+		input    string
+		want     Format
+		wantWARC bool
+		wantErr  bool
+	}{
+		{"md", FormatMarkdown, false, false},
+		{"md,warc", FormatMarkdown, true, false},
+		{"warc, JSON", FormatJSON, true, false},
+		{"warc", FormatWARC, true, false},
+		{"md,html", "", false, true},
+		{"md,", "", false, true},
+	}
+
+	for _, tt := range tests {
+		got, warc, err := ParseFormats(tt.input)
+		if tt.wantErr {
+			if err == nil {
+				t.Fatalf("expected error for input %q", tt.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("unexpected error for input %q: %v", tt.input, err)
+		}
+		if got != tt.want || warc != tt.wantWARC {
+			t.Fatalf("expected %q/%v, got %q/%v for input %q", tt.want, tt.wantWARC, got, warc, tt.input)
+		}
+	}
+}
@@ -0,0 +1,460 @@
+package output
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sbstn/sitecrawl/internal/crawler"
+)
+
+const (
+	// WARCName is the gzip-per-record WARC 1.1 capture file.
+	WARCName = "crawl.warc.gz"
+	// CDXJName indexes the WARC's response and resource records.
+	CDXJName = "crawl.cdxj"
+
+	// renderedDOMScheme prefixes resource records holding the rendered DOM,
+	// keeping them apart from the original response in replay indexes.
+	renderedDOMScheme = "urn:rendered-dom:"
+)
+
+// hopByHopHeaders describe the original transfer and no longer match the
+// decoded payload stored in response records.
+var hopByHopHeaders = []string{"Content-Encoding", "Transfer-Encoding", "Content-Length", "Connection", "Keep-Alive"}
+
+// ReportWARC describes the WARC archive and its index in report.json.
+type ReportWARC struct {
+	Path    string `json:"path"`
+	Index   string `json:"index"`
+	Records int    `json:"records"`
+}
+
+type warcRecord struct {
+	recordType  string
+	targetURI   string
+	date        time.Time
+	contentType string
+	headers     [][2]string
+	block       []byte
+}
+
+type cdxjEntry struct {
+	key       string
+	timestamp string
+	fields    map[string]string
+}
+
+// warcWriter appends one gzip member per record to crawl.warc.gz as pages
+// are written, so page content need not stay in memory until the crawl ends.
+// Only the CDXJ entries are kept; the index is written sorted by Finish.
+type warcWriter struct {
+	file    *os.File
+	offset  int64
+	records int
+	index   []cdxjEntry
+	// spooled marks a temporary file that finish copies into an archive.
+	spooled bool
+}
+
+// newWARCWriter creates crawl.warc.gz in outDir, or a temporary file when the
+// output goes to an archive.
+func newWARCWriter(outDir string) (*warcWriter, error) {
+	if outDir == "" {
+		file, err := os.CreateTemp("", "sitecrawl-*.warc.gz")
+		if err != nil {
+			return nil, err
+		}
+		return &warcWriter{file: file, spooled: true}, nil
+	}
+	file, err := os.Create(filepath.Join(outDir, WARCName))
+	if err != nil {
+		return nil, err
+	}
+	return &warcWriter{file: file}, nil
+}
+
+// begin writes the warcinfo record describing the crawl.
+func (w *warcWriter) begin(result *crawler.CrawlResult) error {
+	info := "software: sitecrawl\r\nformat: WARC File Format 1.1\r\n" +
+		"conformsTo: https://iipc.github.io/warc-specifications/specifications/warc-format/warc-1.1/\r\n" +
+		"domain: " + result.Domain + "\r\n"
+	return w.write(warcRecord{
+		recordType:  "warcinfo",
+		date:        warcStartTime(result),
+		contentType: "application/warc-fields",
+		headers:     [][2]string{{"WARC-Filename", WARCName}},
+		block:       []byte(info),
+	}, false)
+}
+
+// finish closes crawl.warc.gz, copies a spooled file into the archive, and
+// writes crawl.cdxj.
+func (w *warcWriter) finish(files fileSink, archive *archiveSink) (*ReportWARC, error) {
+	if w.spooled {
+		if _, err := w.file.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		if err := archive.copyFile(WARCName, w.file, w.offset); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.close(); err != nil {
+		return nil, err
+	}
+	if err := files.WriteFile(CDXJName, w.cdxj()); err != nil {
+		return nil, err
+	}
+	return &ReportWARC{Path: WARCName, Index: CDXJName, Records: w.records}, nil
+}
+
+// close closes the file, removing it if it was spooled. It is safe to call
+// more than once.
+func (w *warcWriter) close() error {
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	if w.spooled {
+		os.Remove(w.file.Name())
+	}
+	w.file = nil
+	return err
+}
+
+// writePage appends the records of a written page: a request/response pair
+// when the HTTP exchange was captured, the rendered DOM as a resource record,
+// and a metadata record with crawl facts.
+func (w *warcWriter) writePage(page *crawler.Page) error {
+	pageURL := pageLocation(page)
+	date := time.Now().UTC()
+	capture := page.Capture
+	if capture != nil && !capture.FetchedAt.IsZero() {
+		date = capture.FetchedAt.UTC()
+	}
+
+	concurrentTo := ""
+	if capture != nil {
+		responseURL := capture.ResponseURL
+		if responseURL == "" {
+			responseURL = pageURL
+		}
+		responseID := newRecordID()
+		responseHeaders := [][2]string{{"WARC-Record-ID", responseID}}
+		if capture.RemoteIP != "" {
+			responseHeaders = append(responseHeaders, [2]string{"WARC-IP-Address", capture.RemoteIP})
+		}
+		responseHeaders = append(responseHeaders, [2]string{"WARC-Payload-Digest", warcDigest(capture.Body)})
+		if err := w.write(warcRecord{
+			recordType:  "response",
+			targetURI:   responseURL,
+			date:        date,
+			contentType: "application/http;msgtype=response",
+			headers:     responseHeaders,
+			block:       httpResponseBlock(capture),
+		}, true); err != nil {
+			return err
+		}
+		if err := w.write(warcRecord{
+			recordType:  "request",
+			targetURI:   responseURL,
+			date:        date,
+			contentType: "application/http;msgtype=request",
+			headers:     [][2]string{{"WARC-Concurrent-To", responseID}},
+			block:       httpRequestBlock(capture, responseURL),
+		}, false); err != nil {
+			return err
+		}
+		concurrentTo = responseID
+	}
+
+	if page.RawHTML != "" {
+		headers := [][2]string{{"WARC-Payload-Digest", warcDigest([]byte(page.RawHTML))}}
+		if concurrentTo != "" {
+			headers = append(headers, [2]string{"WARC-Concurrent-To", concurrentTo})
+		}
+		if err := w.write(warcRecord{
+			recordType:  "resource",
+			targetURI:   renderedDOMScheme + pageURL,
+			date:        date,
+			contentType: "text/html; charset=utf-8",
+			headers:     headers,
+			block:       []byte(page.RawHTML),
+		}, true); err != nil {
+			return err
+		}
+	}
+
+	var metadata strings.Builder
+	writeField := func(key, value string) {
+		if value != "" {
+			metadata.WriteString(key + ": " + strings.Join(strings.Fields(value), " ") + "\r\n")
+		}
+	}
+	writeField("title", page.Title)
+	writeField("description", page.Description)
+	writeField("depth", strconv.Itoa(page.Depth))
+	writeField("language", page.Language)
+	if page.URL != pageURL {
+		writeField("via", page.URL)
+	}
+	for _, link := range page.Links {
+		writeField("outlink", link)
+	}
+	var headers [][2]string
+	if concurrentTo != "" {
+		headers = append(headers, [2]string{"WARC-Concurrent-To", concurrentTo})
+	}
+	return w.write(warcRecord{
+		recordType:  "metadata",
+		targetURI:   pageURL,
+		date:        date,
+		contentType: "application/warc-fields",
+		headers:     headers,
+		block:       []byte(metadata.String()),
+	}, false)
+}
+
+// write appends one record as its own gzip member. Indexed records get a
+// CDXJ line pointing at the member's offset and compressed length.
+func (w *warcWriter) write(record warcRecord, indexed bool) error {
+	var header strings.Builder
+	header.WriteString("WARC/1.1\r\n")
+	header.WriteString("WARC-Type: " + record.recordType + "\r\n")
+	hasID := false
+	for _, field := range record.headers {
+		if field[0] == "WARC-Record-ID" {
+			hasID = true
+		}
+	}
+	if !hasID {
+		header.WriteString("WARC-Record-ID: " + newRecordID() + "\r\n")
+	}
+	header.WriteString("WARC-Date: " + record.date.UTC().Format(time.RFC3339) + "\r\n")
+	if record.targetURI != "" {
+		header.WriteString("WARC-Target-URI: " + record.targetURI + "\r\n")
+	}
+	for _, field := range record.headers {
+		header.WriteString(field[0] + ": " + field[1] + "\r\n")
+	}
+	header.WriteString("WARC-Block-Digest: " + warcDigest(record.block) + "\r\n")
+	header.WriteString("Content-Type: " + record.contentType + "\r\n")
+	header.WriteString("Content-Length: " + strconv.Itoa(len(record.block)) + "\r\n\r\n")
+
+	var member bytes.Buffer
+	gz := gzip.NewWriter(&member)
+	for _, part := range [][]byte{[]byte(header.String()), record.block, []byte("\r\n\r\n")} {
+		if _, err := gz.Write(part); err != nil {
+			return err
+		}
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	offset := w.offset
+	if _, err := w.file.Write(member.Bytes()); err != nil {
+		return err
+	}
+	w.offset += int64(member.Len())
+	w.records++
+
+	if indexed {
+		fields := map[string]string{
+			"url":      record.targetURI,
+			"mime":     mimeOnly(record.contentType),
+			"digest":   strings.TrimPrefix(warcDigest(record.block), "sha1:"),
+			"length":   strconv.Itoa(member.Len()),
+			"offset":   strconv.FormatInt(offset, 10),
+			"filename": WARCName,
+		}
+		for _, field := range record.headers {
+			if field[0] == "WARC-Payload-Digest" {
+				fields["digest"] = strings.TrimPrefix(field[1], "sha1:")
+			}
+		}
+		if record.recordType == "response" {
+			block := string(record.block)
+			if status, rest, ok := strings.Cut(block, " "); ok && strings.HasPrefix(status, "HTTP/") {
+				fields["status"], _, _ = strings.Cut(rest, " ")
+			}
+			if mime := responseMIME(record.block); mime != "" {
+				fields["mime"] = mime
+			}
+		} else {
+			fields["status"] = "200"
+		}
+		w.index = append(w.index, cdxjEntry{
+			key:       surtKey(record.targetURI),
+			timestamp: record.date.UTC().Format("20060102150405"),
+			fields:    fields,
+		})
+	}
+	return nil
+}
+
+// cdxj renders the index sorted by SURT key and timestamp.
+func (w *warcWriter) cdxj() []byte {
+	sort.SliceStable(w.index, func(i, j int) bool {
+		if w.index[i].key != w.index[j].key {
+			return w.index[i].key < w.index[j].key
+		}
+		return w.index[i].timestamp < w.index[j].timestamp
+	})
+	var out bytes.Buffer
+	for _, entry := range w.index {
+		encoded, err := json.Marshal(entry.fields)
+		if err != nil {
+			continue
+		}
+		out.WriteString(entry.key + " " + entry.timestamp + " ")
+		out.Write(encoded)
+		out.WriteByte('\n')
+	}
+	return out.Bytes()
+}
+
+// httpResponseBlock rebuilds the HTTP/1.1 response message. The payload is
+// stored decoded, so transfer headers are dropped and Content-Length is set
+// to the stored size.
+func httpResponseBlock(capture *crawler.HTTPCapture) []byte {
+	var block bytes.Buffer
+	statusText := capture.StatusText
+	if statusText == "" {
+		statusText = http.StatusText(capture.StatusCode)
+	}
+	fmt.Fprintf(&block, "HTTP/1.1 %d %s\r\n", capture.StatusCode, statusText)
+	headers := capture.ResponseHeaders.Clone()
+	if headers == nil {
+		headers = http.Header{}
+	}
+	for _, key := range hopByHopHeaders {
+		headers.Del(key)
+	}
+	if headers.Get("Content-Type") == "" && capture.MIMEType != "" {
+		headers.Set("Content-Type", capture.MIMEType)
+	}
+	headers.Set("Content-Length", strconv.Itoa(len(capture.Body)))
+	writeHTTPHeaders(&block, headers)
+	block.Write(capture.Body)
+	return block.Bytes()
+}
+
+func httpRequestBlock(capture *crawler.HTTPCapture, targetURL string) []byte {
+	var block bytes.Buffer
+	requestURI := "/"
+	host := ""
+	if parsed, err := url.Parse(targetURL); err == nil {
+		requestURI = parsed.RequestURI()
+		host = parsed.Host
+	}
+	fmt.Fprintf(&block, "%s %s HTTP/1.1\r\n", capture.RequestMethod, requestURI)
+	headers := capture.RequestHeaders.Clone()
+	if headers == nil {
+		headers = http.Header{}
+	}
+	if headers.Get("Host") == "" && host != "" {
+		headers.Set("Host", host)
+	}
+	writeHTTPHeaders(&block, headers)
+	return block.Bytes()
+}
+
+func writeHTTPHeaders(block *bytes.Buffer, headers http.Header) {
+	keys := make([]string, 0, len(headers))
+	for key := range headers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, value := range headers[key] {
+			block.WriteString(key + ": " + value + "\r\n")
+		}
+	}
+	block.WriteString("\r\n")
+}
+
+// responseMIME reads the Content-Type of a stored HTTP response block.
+func responseMIME(block []byte) string {
+	head, _, _ := bytes.Cut(block, []byte("\r\n\r\n"))
+	for _, line := range strings.Split(string(head), "\r\n")[1:] {
+		key, value, ok := strings.Cut(line, ":")
+		if ok && strings.EqualFold(strings.TrimSpace(key), "Content-Type") {
+			return mimeOnly(value)
+		}
+	}
+	return ""
+}
+
+func mimeOnly(contentType string) string {
+	mime, _, _ := strings.Cut(contentType, ";")
+	return strings.ToLower(strings.TrimSpace(mime))
+}
+
+// surtKey builds a Sort-friendly URI Reordering Transform key as used by
+// CDXJ indexes: example.com/a?b=1&a=2 -> com,example)/a?a=2&b=1. The scheme,
+// a leading "www.", default ports, and the fragment are dropped; the host and
+// path are lowercased.
+func surtKey(rawURL string) string {
+	if strings.HasPrefix(rawURL, "urn:") {
+		return rawURL
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" {
+		return strings.ToLower(rawURL)
+	}
+	host := strings.ToLower(parsed.Hostname())
+	host = strings.TrimPrefix(host, "www.")
+	parts := strings.Split(host, ".")
+	for i, j := 0, len(parts)-1; i < j; i, j = i+1, j-1 {
+		parts[i], parts[j] = parts[j], parts[i]
+	}
+	key := strings.Join(parts, ",")
+	if port := parsed.Port(); port != "" && !(port == "80" && parsed.Scheme == "http") && !(port == "443" && parsed.Scheme == "https") {
+		key += ":" + port
+	}
+	path := parsed.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	key += ")" + strings.ToLower(path)
+	if parsed.RawQuery != "" {
+		params := strings.Split(parsed.RawQuery, "&")
+		sort.Strings(params)
+		key += "?" + strings.ToLower(strings.Join(params, "&"))
+	}
+	return key
+}
+
+// warcDigest is the sha1 digest in the base32 form WARC tools expect.
+func warcDigest(data []byte) string {
+	sum := sha1.Sum(data)
+	return "sha1:" + base32.StdEncoding.EncodeToString(sum[:])
+}
+
+func newRecordID() string {
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+	id[6] = (id[6] & 0x0f) | 0x40
+	id[8] = (id[8] & 0x3f) | 0x80
+	return fmt.Sprintf("<urn:uuid:%x-%x-%x-%x-%x>", id[0:4], id[4:6], id[6:8], id[8:10], id[10:16])
+}
+
+func warcStartTime(result *crawler.CrawlResult) time.Time {
+	if result.StartedAt.IsZero() {
+		return time.Now().UTC()
+	}
+	return result.StartedAt.UTC()
+}
@@ -0,0 +1,247 @@
+package output
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sbstn/sitecrawl/internal/crawler"
+)
+
+func TestWriteWARC(t *testing.T) {
+	tmpDir := t.TempDir()
+	fetchedAt := time.Date(2025, 3, 4, 5, 6, 7, 0, time.UTC)
+	result := &crawler.CrawlResult{
+		Domain:    "example.com",
+		StartedAt: fetchedAt,
+		Strategy:  crawler.StrategyLimit,
+		Clean:     true,
+		Pages: []*crawler.Page{
+			{
+				URL:      "https://example.com/docs",
+				FinalURL: "https://example.com/docs",
+				Status:   crawler.StatusOK,
+				Title:    "Docs",
+				Links:    []string{"https://example.com/"},
+				MainText: "Docs",
+				RawHTML:  "<html><body>Rendered</body></html>",
+				Capture: &crawler.HTTPCapture{
+					FetchedAt:       fetchedAt,
+					RequestMethod:   http.MethodGet,
+					RequestURL:      "https://example.com/docs",
+					RequestHeaders:  http.Header{"User-Agent": {"sitecrawl"}},
+					StatusCode:      200,
+					ResponseURL:     "https://example.com/docs",
+					ResponseHeaders: http.Header{"Content-Type": {"text/html; charset=utf-8"}, "Content-Encoding": {"gzip"}},
+					RemoteIP:        "93.184.216.34",
+					Body:            []byte("<html><body>Original</body></html>"),
+				},
+			},
+			{URL: "https://example.com/missing", Status: crawler.StatusError},
+		},
+	}
+
+	if err := Write(result, tmpDir, FormatWARC); err != nil {
+		t.Fatalf("unexpected write error: %v", err)
+	}
+	entries, err := os.ReadDir(tmpDir)
+	if err != nil {
+		t.Fatalf("unexpected read dir error: %v", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if got := strings.Join(names, ","); got != "crawl.cdxj,crawl.warc.gz,pages.jsonl,report.json" {
+		t.Fatalf("expected only warc artifacts, got %s", got)
+	}
+
+	archive, err := os.ReadFile(filepath.Join(tmpDir, WARCName))
+	if err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+	reader := bytes.NewReader(archive)
+	var types []string
+	for reader.Len() > 0 {
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			t.Fatalf("unexpected gzip error: %v", err)
+		}
+		gz.Multistream(false)
+		record, err := io.ReadAll(gz)
+		if err != nil {
+			t.Fatalf("unexpected gzip read error: %v", err)
+		}
+		if !bytes.HasPrefix(record, []byte("WARC/1.1\r\n")) || !bytes.HasSuffix(record, []byte("\r\n\r\n")) {
+			t.Fatalf("malformed record: %q", record)
+		}
+		for _, line := range strings.Split(string(record), "\r\n") {
+			if value, ok := strings.CutPrefix(line, "WARC-Type: "); ok {
+				types = append(types, value)
+			}
+		}
+	}
+	if got := strings.Join(types, ","); got != "warcinfo,response,request,resource,metadata" {
+		t.Fatalf("unexpected record types %s", got)
+	}
+
+	index, err := os.ReadFile(filepath.Join(tmpDir, CDXJName))
+	if err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(index)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 cdxj lines, got %q", lines)
+	}
+	if !strings.HasPrefix(lines[0], "com,example)/docs 20250304050607 {") {
+		t.Fatalf("unexpected cdxj line %q", lines[0])
+	}
+	var fields map[string]string
+	if err := json.Unmarshal([]byte(lines[0][strings.Index(lines[0], "{"):]), &fields); err != nil {
+		t.Fatalf("unexpected json error: %v", err)
+	}
+	if fields["status"] != "200" || fields["mime"] != "text/html" || fields["filename"] != WARCName {
+		t.Fatalf("unexpected cdxj fields %+v", fields)
+	}
+
+	offset, _ := strconv.Atoi(fields["offset"])
+	length, _ := strconv.Atoi(fields["length"])
+	gz, err := gzip.NewReader(bytes.NewReader(archive[offset : offset+length]))
+	if err != nil {
+		t.Fatalf("unexpected gzip error: %v", err)
+	}
+	record, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatalf("unexpected gzip read error: %v", err)
+	}
+	_, block, _ := strings.Cut(string(record), "\r\n\r\n")
+	response, err := http.ReadResponse(bufio.NewReader(strings.NewReader(block)), nil)
+	if err != nil {
+		t.Fatalf("unexpected http parse error: %v", err)
+	}
+	body, _ := io.ReadAll(response.Body)
+	if string(body) != "<html><body>Original</body></html>" || response.Header.Get("Content-Encoding") != "" {
+		t.Fatalf("unexpected response %+v body %q", response.Header, body)
+	}
+}
+
+func TestSURTKey(t *testing.T) {
+	tests := map[string]string{
+		"https://www.Example.com/Docs?b=2&a=1#top": "com,example)/docs?a=1&b=2",
+		"http://example.com:8080/":                 "com,example:8080)/",
+		"https://sub.example.com":                  "com,example,sub)/",
+		"https://example.com:443/x":                "com,example)/x",
+		"urn:rendered-dom:https://example.com/":    "urn:rendered-dom:https://example.com/",
+	}
+	for input, want := range tests {
+		if got := surtKey(input); got != want {
+			t.Fatalf("surtKey(%q): expected %q, got %q", input, want, got)
+		}
+	}
+}
+
+func TestStreamWriterAppendsWARCRecordsPerPage(t *testing.T) {
+	tmpDir := t.TempDir()
+	result := &crawler.CrawlResult{Domain: "example.com", StartedAt: time.Date(2025, 3, 4, 5, 6, 7, 0, time.UTC)}
+	page := &crawler.Page{
+		URL:     "https://example.com/",
+		Status:  crawler.StatusOK,
+		Title:   "Home",
+		RawHTML: "<html><body>Home</body></html>",
+		Capture: &crawler.HTTPCapture{RequestMethod: http.MethodGet, StatusCode: 200, Body: []byte("<html>Home</html>")},
+	}
+
+	writer, err := NewStreamWriter(tmpDir, FormatMarkdown, Options{WARC: true})
+	if err != nil {
+		t.Fatalf("unexpected writer error: %v", err)
+	}
+	defer writer.Close()
+	if err := writer.Begin(result); err != nil {
+		t.Fatalf("unexpected begin error: %v", err)
+	}
+	before, err := os.Stat(filepath.Join(tmpDir, WARCName))
+	if err != nil {
+		t.Fatalf("expected the WARC file to exist after Begin: %v", err)
+	}
+	if err := writer.WritePage(page); err != nil {
+		t.Fatalf("unexpected write page error: %v", err)
+	}
+	after, err := os.Stat(filepath.Join(tmpDir, WARCName))
+	if err != nil {
+		t.Fatalf("unexpected stat error: %v", err)
+	}
+	if after.Size() <= before.Size() {
+		t.Fatalf("expected the page's records on disk before Finish, size %d -> %d", before.Size(), after.Size())
+	}
+	if page.RawHTML != "" || page.Capture != nil {
+		t.Fatalf("expected archived content to be released, got %+v", page)
+	}
+
+	result.Pages = []*crawler.Page{page}
+	if err := writer.Finish(result); err != nil {
+		t.Fatalf("unexpected finish error: %v", err)
+	}
+	if got := readReport(t, tmpDir).WARC; got == nil || got.Records != 5 {
+		t.Fatalf("expected 5 WARC records, got %+v", got)
+	}
+	index, err := os.ReadFile(filepath.Join(tmpDir, CDXJName))
+	if err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(index)), "\n"); len(lines) != 2 {
+		t.Fatalf("expected 2 cdxj lines, got %q", lines)
+	}
+}
+
+func TestArchiveWriterCopiesSpooledWARC(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "crawl.tar.gz")
+	result := &crawler.CrawlResult{Domain: "example.com", StartedAt: time.Date(2025, 3, 4, 5, 6, 7, 0, time.UTC)}
+	page := &crawler.Page{URL: "https://example.com/", Status: crawler.StatusOK, Title: "Home", RawHTML: "<html><body>Home</body></html>"}
+
+	writer, err := NewArchiveWriter(path, ArchiveTarGzip, FormatWARC, Options{})
+	if err != nil {
+		t.Fatalf("unexpected writer error: %v", err)
+	}
+	defer writer.Close()
+	if err := writer.Begin(result); err != nil {
+		t.Fatalf("unexpected begin error: %v", err)
+	}
+	if err := writer.WritePage(page); err != nil {
+		t.Fatalf("unexpected write page error: %v", err)
+	}
+	result.Pages = []*crawler.Page{page}
+	if err := writer.Finish(result); err != nil {
+		t.Fatalf("unexpected finish error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+
+	var archived string
+	for _, entry := range readArchive(t, ArchiveTarGzip, data) {
+		if entry.name == WARCName {
+			archived = entry.content
+		}
+	}
+	gz, err := gzip.NewReader(strings.NewReader(archived))
+	if err != nil {
+		t.Fatalf("expected a gzip WARC entry: %v", err)
+	}
+	records, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatalf("unexpected gzip read error: %v", err)
+	}
+	if !strings.Contains(string(records), "WARC-Target-URI: urn:rendered-dom:https://example.com/") {
+		t.Fatalf("expected the page's resource record in the archived WARC, got:\n%s", records)
+	}
+}
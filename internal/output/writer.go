@@ -3,8 +3,6 @@ package output
 import (
 	"encoding/json"
 	"fmt"
-	"os"
-	"path/filepath"
 	"sort"
 	"strings"
 	"time"
@@ -12,80 +10,148 @@ import (
 	"github.com/sbstn/sitecrawl/internal/crawler"
 )
 
-type reportPage struct {
-	URL         string   `json:"url"`
-	FinalURL    string   `json:"final_url"`
-	Depth       int      `json:"depth"`
-	Status      string   `json:"status"`
-	Title       string   `json:"title,omitempty"`
-	Description string   `json:"description,omitempty"`
-	OutPath     string   `json:"out_path,omitempty"`
-	LinksCount  int      `json:"links_count"`
-	Error       string   `json:"error,omitempty"`
-	Score       *float64 `json:"score,omitempty"`
+// ReportPage is one page entry of report.json.
+type ReportPage struct {
+	URL                string   `json:"url"`
+	FinalURL           string   `json:"final_url"`
+	Depth              int      `json:"depth"`
+	Status             string   `json:"status"`
+	Title              string   `json:"title,omitempty"`
+	Description        string   `json:"description,omitempty"`
+	Robots             []string `json:"robots,omitempty"`
+	AISignals          []string `json:"ai_signals,omitempty"`
+	Language           string   `json:"language,omitempty"`
+	DeclaredLanguage   string   `json:"declared_language,omitempty"`
+	DetectedLanguage   string   `json:"detected_language,omitempty"`
+	ContentRoot        string   `json:"content_root,omitempty"`
+	ContentConfidence  *float64 `json:"content_confidence,omitempty"`
+	OutPath            string   `json:"out_path,omitempty"`
+	H1Count            *int     `json:"h1_count,omitempty"`
+	Canonical          string   `json:"canonical,omitempty"`
+	WordCount          *int     `json:"word_count,omitempty"`
+	InboundLinks       *int     `json:"inbound_links,omitempty"`
+	LinksCount         int      `json:"links_count"`
+	ExternalLinksCount int      `json:"external_links_count,omitempty"`
+	TablesCount        int      `json:"tables_count,omitempty"`
+	Error              string   `json:"error,omitempty"`
+	Score              *float64 `json:"score,omitempty"`
 }
 
-type reportTotals struct {
+// ReportTotals holds the crawl counters of report.json.
+type ReportTotals struct {
 	Visited           int `json:"visited"`
 	Errors            int `json:"errors"`
 	SkippedExternal   int `json:"skipped_external"`
 	SkippedOutOfScope int `json:"skipped_out_of_scope"`
+	SkippedNoIndex    int `json:"skipped_noindex"`
+	SkippedNofollow   int `json:"skipped_nofollow"`
+	SkippedAIOptOut   int `json:"skipped_ai_optout"`
+	SkippedLanguage   int `json:"skipped_language"`
 }
 
-type report struct {
-	Domain                 string       `json:"domain"`
-	AllowedHosts           []string     `json:"allowed_hosts"`
-	StartedAt              time.Time    `json:"started_at"`
-	FinishedAt             time.Time    `json:"finished_at"`
-	Strategy               string       `json:"strategy"`
-	MaxPages               int          `json:"max_pages"`
-	MaxDepth               int          `json:"max_depth"`
-	Clean                  bool         `json:"clean"`
-	Headful                bool         `json:"headful"`
-	PageRankImplementation string       `json:"pagerank_implementation,omitempty"`
-	Pages                  []reportPage `json:"pages"`
-	Totals                 reportTotals `json:"totals"`
+// ReportAlternate is an hreflang alternate of a page.
+type ReportAlternate struct {
+	Lang string `json:"lang"`
+	URL  string `json:"url"`
+}
+
+// ReportTranslationSet groups pages that list each other as hreflang alternates.
+type ReportTranslationSet struct {
+	Members []ReportAlternate `json:"members"`
+}
+
+// ReportTDMRepRule is a TDMRep rule that applies to a host.
+type ReportTDMRepRule struct {
+	Location       string `json:"location"`
+	TDMReservation int    `json:"tdm_reservation"`
+	TDMPolicy      string `json:"tdm_policy,omitempty"`
+}
+
+// ReportAIPolicy holds the TDMRep rules and ai.txt presence of a host.
+type ReportAIPolicy struct {
+	Host        string             `json:"host"`
+	TDMRepRules []ReportTDMRepRule `json:"tdmrep_rules,omitempty"`
+	AITxt       bool               `json:"ai_txt"`
+}
+
+// Report is the structure of report.json, the crawl summary that downstream
+// tools read.
+type Report struct {
+	Domain                 string                 `json:"domain"`
+	AllowedHosts           []string               `json:"allowed_hosts"`
+	StartedAt              time.Time              `json:"started_at"`
+	FinishedAt             time.Time              `json:"finished_at"`
+	Strategy               string                 `json:"strategy"`
+	MaxPages               int                    `json:"max_pages"`
+	MaxDepth               int                    `json:"max_depth"`
+	FieldNames             []string               `json:"fields,omitempty"`
+	Clean                  bool                   `json:"clean"`
+	Extractor              string                 `json:"extractor,omitempty"`
+	Headful                bool                   `json:"headful"`
+	Partial                bool                   `json:"partial,omitempty"`
+	PageRankImplementation string                 `json:"pagerank_implementation,omitempty"`
+	AIPolicies             []ReportAIPolicy       `json:"ai_policies,omitempty"`
+	TranslationSets        []ReportTranslationSet `json:"translation_sets,omitempty"`
+	Images                 *ReportImages          `json:"images,omitempty"`
+	Tables                 *ReportTables          `json:"tables,omitempty"`
+	ExternalLinks          *ReportExternalLinks   `json:"external_links,omitempty"`
+	Chunks                 *ReportChunks          `json:"chunks,omitempty"`
+	LLMsTxt                []string               `json:"llms_txt,omitempty"`
+	Pack                   *ReportPack            `json:"pack,omitempty"`
+	WARC                   *ReportWARC            `json:"warc,omitempty"`
+	SQLite                 *ReportSQLite          `json:"sqlite,omitempty"`
+	Graph                  *ReportGraph           `json:"graph,omitempty"`
+	Rank                   *ReportRank            `json:"rank,omitempty"`
+	BrokenLinks            *ReportBrokenLinks     `json:"broken_links,omitempty"`
+	SitemapCoverage        *ReportSitemapCoverage `json:"sitemap_coverage,omitempty"`
+	Audit                  *ReportAudit           `json:"audit,omitempty"`
+	Pages                  []ReportPage           `json:"pages"`
+	Totals                 ReportTotals           `json:"totals"`
+}
+
+// Options enables optional artifacts written alongside the page files and
+// selects the page file layout. SQLite is a database path, which may lie
+// outside outDir so that several crawls can share one file.
+type Options struct {
+	Layout  Layout
+	Chunks  *ChunkOptions
+	LLMsTxt bool
+	Pack    *PackOptions
+	WARC    bool
+	SQLite  string
+	Graph   []GraphFormat
+	// ExternalLinksCSV writes external_links.csv.
+	ExternalLinksCSV bool
+	// Audit writes audit.json and audit.md.
+	Audit *AuditOptions
 }
 
 // Write serializes page outputs and writes report.json into outDir.
 func Write(result *crawler.CrawlResult, outDir string, format Format) error {
+	return WriteWithOptions(result, outDir, format, Options{})
+}
+
+// WriteWithOptions is Write with optional artifacts enabled by opts. It
+// writes the same files as a StreamWriter used during the crawl.
+func WriteWithOptions(result *crawler.CrawlResult, outDir string, format Format, opts Options) error {
 	if result == nil {
 		return fmt.Errorf("nil crawl result")
 	}
-	if outDir == "" {
-		return fmt.Errorf("output directory is empty")
+	writer, err := NewStreamWriter(outDir, format, opts)
+	if err != nil {
+		return err
 	}
-	if err := os.MkdirAll(outDir, 0o755); err != nil {
+	defer writer.Close()
+	writer.retainContent = true
+	if err := writer.Begin(result); err != nil {
 		return err
 	}
-
-	mapper := NewFilenameMapper(format)
 	for _, page := range result.Pages {
-		if page.Status != crawler.StatusOK {
-			continue
-		}
-		targetURL := page.FinalURL
-		if targetURL == "" {
-			targetURL = page.URL
-		}
-		filename := mapper.FilenameForURL(targetURL)
-		content, err := renderPage(page, format, result.Clean)
-		if err != nil {
-			return err
-		}
-		fullPath := filepath.Join(outDir, filename)
-		if err := os.WriteFile(fullPath, []byte(content), 0o644); err != nil {
+		if err := writer.WritePage(page); err != nil {
 			return err
 		}
-		page.OutPath = filename
-	}
-
-	rep := buildReport(result)
-	reportJSON, err := json.MarshalIndent(rep, "", "  ")
-	if err != nil {
-		return err
 	}
-	return os.WriteFile(filepath.Join(outDir, "report.json"), reportJSON, 0o644)
+	return writer.Finish(result)
 }
 
 func renderPage(page *crawler.Page, format Format, clean bool) (string, error) {
@@ -110,8 +176,7 @@ func renderPage(page *crawler.Page, format Format, clean bool) (string, error) {
 			builder.WriteString("\n\n")
 		}
 		if clean {
-			builder.WriteString(page.MainText)
-			builder.WriteString("\n")
+			builder.WriteString(pageMarkdown(page))
 		} else {
 			builder.WriteString("```html\n")
 			builder.WriteString(page.BodyHTML)
@@ -136,15 +201,23 @@ func renderPage(page *crawler.Page, format Format, clean bool) (string, error) {
 		return page.BodyHTML, nil
 	case FormatJSON:
 		payload := map[string]any{
-			"url":          page.URL,
-			"final_url":    page.FinalURL,
-			"title":        page.Title,
-			"description":  page.Description,
-			"links":        page.Links,
-			"links_count":  len(page.Links),
-			"clean":        clean,
-			"content":      page.MainText,
-			"content_html": page.MainHTML,
+			"url":            page.URL,
+			"final_url":      page.FinalURL,
+			"title":          page.Title,
+			"description":    page.Description,
+			"links":          page.Links,
+			"links_count":    len(page.Links),
+			"external_links": toReportExternalLinks(page.ExternalLinks),
+			"language":       page.Language,
+			"alternates":     toReportAlternates(page.Alternates),
+			"images":         toReportImages(page.Images),
+			"tables":         toReportTables(page.Tables),
+			"clean":          clean,
+			"content":        page.MainText,
+			"content_html":   page.MainHTML,
+		}
+		if len(page.Fields) > 0 {
+			payload["fields"] = fieldsObject(page.Fields)
 		}
 		if !clean {
 			payload["content"] = page.BodyHTML
@@ -160,21 +233,16 @@ func renderPage(page *crawler.Page, format Format, clean bool) (string, error) {
 	}
 }
 
-func buildReport(result *crawler.CrawlResult) report {
-	pages := make([]reportPage, 0, len(result.Pages))
+func buildReport(result *crawler.CrawlResult) Report {
+	inbound := inboundLinkCounts(result.LinkGraph)
+	pages := make([]ReportPage, 0, len(result.Pages))
 	for _, page := range result.Pages {
-		pages = append(pages, reportPage{
-			URL:         page.URL,
-			FinalURL:    page.FinalURL,
-			Depth:       page.Depth,
-			Status:      page.Status,
-			Title:       page.Title,
-			Description: page.Description,
-			OutPath:     page.OutPath,
-			LinksCount:  len(page.Links),
-			Error:       page.Error,
-			Score:       page.Score,
-		})
+		reportPage := toReportPage(page)
+		if inbound != nil && page.Status == crawler.StatusOK {
+			count := inbound[reportPageKey(reportPage)]
+			reportPage.InboundLinks = &count
+		}
+		pages = append(pages, reportPage)
 	}
 	if result.Strategy == crawler.StrategyPageRank {
 		sort.SliceStable(pages, func(i, j int) bool {
@@ -193,7 +261,31 @@ func buildReport(result *crawler.CrawlResult) report {
 		})
 	}
 
-	return report{
+	aiPolicies := make([]ReportAIPolicy, 0, len(result.AIPolicies))
+	for _, policy := range result.AIPolicies {
+		rules := make([]ReportTDMRepRule, 0, len(policy.TDMRepRules))
+		for _, rule := range policy.TDMRepRules {
+			rules = append(rules, ReportTDMRepRule{
+				Location:       rule.Location,
+				TDMReservation: rule.TDMReservation,
+				TDMPolicy:      rule.TDMPolicy,
+			})
+		}
+		aiPolicies = append(aiPolicies, ReportAIPolicy{
+			Host:        policy.Host,
+			TDMRepRules: rules,
+			AITxt:       policy.AITxt,
+		})
+	}
+
+	translationSets := make([]ReportTranslationSet, 0, len(result.TranslationSets))
+	for _, set := range result.TranslationSets {
+		translationSets = append(translationSets, ReportTranslationSet{
+			Members: toReportAlternates(set.Members),
+		})
+	}
+
+	return Report{
 		Domain:                 result.Domain,
 		AllowedHosts:           append([]string(nil), result.AllowedHosts...),
 		StartedAt:              result.StartedAt,
@@ -201,17 +293,87 @@ func buildReport(result *crawler.CrawlResult) report {
 		Strategy:               string(result.Strategy),
 		MaxPages:               result.MaxPages,
 		MaxDepth:               result.MaxDepth,
+		FieldNames:             result.FieldNames,
 		Clean:                  result.Clean,
+		Extractor:              string(result.Extractor),
 		Headful:                result.Headful,
 		PageRankImplementation: result.PageRankImplementation,
+		AIPolicies:             aiPolicies,
+		TranslationSets:        translationSets,
+		Images:                 buildImageAudit(result.Pages),
+		Tables:                 buildTableIndex(result.Pages),
+		ExternalLinks:          buildExternalLinkIndex(result.Pages),
+		SitemapCoverage:        toReportSitemapCoverage(result.SitemapCoverage),
 		Pages:                  pages,
-		Totals: reportTotals{
-			Visited:           result.Totals.Visited,
-			Errors:            result.Totals.Errors,
-			SkippedExternal:   result.Totals.SkippedExternal,
-			SkippedOutOfScope: result.Totals.SkippedOutOfScope,
-		},
+		Totals:                 toReportTotals(result.Totals),
+	}
+}
+
+func toReportPage(page *crawler.Page) ReportPage {
+	reportPage := ReportPage{
+		URL:                page.URL,
+		FinalURL:           page.FinalURL,
+		Depth:              page.Depth,
+		Status:             page.Status,
+		Title:              page.Title,
+		Description:        page.Description,
+		Robots:             page.Robots,
+		AISignals:          page.AISignals,
+		Language:           page.Language,
+		DeclaredLanguage:   page.DeclaredLanguage,
+		DetectedLanguage:   page.DetectedLanguage,
+		ContentRoot:        page.ContentRoot,
+		ContentConfidence:  page.ContentConfidence,
+		OutPath:            page.OutPath,
+		LinksCount:         len(page.Links),
+		ExternalLinksCount: len(page.ExternalLinks),
+		TablesCount:        len(page.Tables),
+		Canonical:          page.Canonical,
+		Error:              page.Error,
+		Score:              page.Score,
+	}
+	if page.Status == crawler.StatusOK {
+		h1Count, wordCount := len(page.H1), page.WordCount
+		reportPage.H1Count = &h1Count
+		reportPage.WordCount = &wordCount
+	}
+	return reportPage
+}
+
+// inboundLinkCounts counts the links each URL receives from other pages. It
+// returns nil without a link graph.
+func inboundLinkCounts(graph *crawler.LinkGraph) map[string]int {
+	if graph == nil {
+		return nil
+	}
+	counts := map[string]int{}
+	for _, edge := range graph.Edges() {
+		if edge.From != edge.To {
+			counts[edge.To]++
+		}
+	}
+	return counts
+}
+
+func toReportTotals(totals crawler.Totals) ReportTotals {
+	return ReportTotals{
+		Visited:           totals.Visited,
+		Errors:            totals.Errors,
+		SkippedExternal:   totals.SkippedExternal,
+		SkippedOutOfScope: totals.SkippedOutOfScope,
+		SkippedNoIndex:    totals.SkippedNoIndex,
+		SkippedNofollow:   totals.SkippedNofollow,
+		SkippedAIOptOut:   totals.SkippedAIOptOut,
+		SkippedLanguage:   totals.SkippedLanguage,
+	}
+}
+
+func toReportAlternates(alternates []crawler.Alternate) []ReportAlternate {
+	converted := make([]ReportAlternate, 0, len(alternates))
+	for _, alt := range alternates {
+		converted = append(converted, ReportAlternate{Lang: alt.Lang, URL: alt.URL})
 	}
+	return converted
 }
 
 func escapeHTML(value string) string {
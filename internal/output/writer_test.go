@@ -118,3 +118,106 @@ func TestReportIncludesAgentMetadataFields(t *testing.T) {
 		t.Fatalf("expected url/title/description metadata in report page entry")
 	}
 }
+
+func TestReportIncludesAIOptOutSignals(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	result := &crawler.CrawlResult{
+		Domain:       "example.com",
+		AllowedHosts: []string{"example.com", "www.example.com"},
+		Strategy:     crawler.StrategyLimit,
+		MaxPages:     2,
+		Clean:        true,
+		AIPolicies: []crawler.HostAIPolicy{
+			{
+				Host:        "example.com",
+				TDMRepRules: []crawler.TDMRepRule{{Location: "/*", TDMReservation: 1}},
+				AITxt:       true,
+			},
+		},
+		Pages: []*crawler.Page{
+			{
+				URL:       "https://example.com/",
+				FinalURL:  "https://example.com/",
+				Status:    crawler.StatusSkippedAIOptOut,
+				AISignals: []string{crawler.AISignalAITxt, crawler.AISignalTDMRep},
+			},
+		},
+		Totals: crawler.Totals{SkippedAIOptOut: 1},
+	}
+
+	if err := Write(result, tmpDir, FormatMarkdown); err != nil {
+		t.Fatalf("unexpected write error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "index.md")); !os.IsNotExist(err) {
+		t.Fatalf("expected no page file for opted-out page")
+	}
+
+	reportBytes, err := os.ReadFile(filepath.Join(tmpDir, "report.json"))
+	if err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+	var parsed struct {
+		AIPolicies []struct {
+			Host  string `json:"host"`
+			AITxt bool   `json:"ai_txt"`
+		} `json:"ai_policies"`
+		Pages []struct {
+			Status    string   `json:"status"`
+			AISignals []string `json:"ai_signals"`
+		} `json:"pages"`
+		Totals struct {
+			SkippedAIOptOut int `json:"skipped_ai_optout"`
+		} `json:"totals"`
+	}
+	if err := json.Unmarshal(reportBytes, &parsed); err != nil {
+		t.Fatalf("unexpected json error: %v", err)
+	}
+
+	if len(parsed.AIPolicies) != 1 || !parsed.AIPolicies[0].AITxt {
+		t.Fatalf("expected host ai policy in report, got %+v", parsed.AIPolicies)
+	}
+	if len(parsed.Pages) != 1 || parsed.Pages[0].Status != crawler.StatusSkippedAIOptOut || len(parsed.Pages[0].AISignals) != 2 {
+		t.Fatalf("expected opted-out page with signals, got %+v", parsed.Pages)
+	}
+	if parsed.Totals.SkippedAIOptOut != 1 {
+		t.Fatalf("expected skipped_ai_optout total 1, got %d", parsed.Totals.SkippedAIOptOut)
+	}
+}
+
+func TestWriteTreeLayout(t *testing.T) {
+	tmpDir := t.TempDir()
+	result := &crawler.CrawlResult{
+		Domain:   "example.com",
+		Strategy: crawler.StrategyLimit,
+		Clean:    true,
+		Pages: []*crawler.Page{
+			{URL: "https://example.com/", FinalURL: "https://example.com/", Status: crawler.StatusOK, MainText: "home"},
+			{
+				URL:      "https://example.com/docs/guides/setup",
+				FinalURL: "https://example.com/docs/guides/setup",
+				Status:   crawler.StatusOK,
+				MainText: "setup",
+				Tables:   []crawler.Table{{Rows: [][]string{{"a", "b"}}}},
+			},
+			{URL: "https://www.example.com/blog/", FinalURL: "https://www.example.com/blog/", Status: crawler.StatusOK, MainText: "blog"},
+		},
+	}
+
+	if err := WriteWithOptions(result, tmpDir, FormatMarkdown, Options{Layout: LayoutTree}); err != nil {
+		t.Fatalf("unexpected write error: %v", err)
+	}
+	for _, name := range []string{
+		"index.md",
+		"docs/guides/setup.md",
+		"docs/guides/setup.table-1.csv",
+		"www.example.com/blog/index.md",
+	} {
+		if _, err := os.Stat(filepath.Join(tmpDir, filepath.FromSlash(name))); err != nil {
+			t.Fatalf("expected %s to be written: %v", name, err)
+		}
+	}
+	if result.Pages[1].OutPath != "docs/guides/setup.md" {
+		t.Fatalf("expected slash-separated out_path, got %s", result.Pages[1].OutPath)
+	}
+}
@@ -39,3 +39,11 @@ func (n *Node) OutDegree() uint {
 func (n *Node) InDegree() uint {
 	return uint(len(n.Incoming))
 }
+
+// OutWeight is the sum of the weights of a node's outgoing edges.
+func (n *Node) OutWeight() (weight float64) {
+	for _, edge := range n.Outgoing {
+		weight += edge.Weight
+	}
+	return
+}
@@ -1,18 +1,29 @@
 package pagerank
 
 import (
+	"errors"
 	"fmt"
 	"math"
 	"sort"
 	"strconv"
 )
 
+// ErrNotConverged is returned by Run when MaxIter iterations do not reach
+// Tolerance.
+var ErrNotConverged = errors.New("PageRank did not converge")
+
 type PageRank struct {
 	Alpha     float64 // Alpha is the damping parameter for PageRank, default=0.85.
 	MaxIter   uint    // MaxIter is the max amount of iterations
 	Tolerance float64 // Tolerance
 	N         int     // N is the amount of nodes in the graph
 	Iteration uint    // Iteration is the counter of iterations of the implementation
+	// Personalization biases the random jump towards nodes in proportion to
+	// their weight; nodes without an entry get none. Nil jumps uniformly.
+	Personalization map[NodeID]float64
+	// Weighted distributes a node's rank over its outgoing edges in
+	// proportion to Edge.Weight instead of evenly.
+	Weighted  bool
 	sortIndex []NodeID
 	*Graph
 }
@@ -28,65 +39,137 @@ func NewPageRank(g *Graph) *PageRank {
 	return &pr
 }
 
-// CalcPageRank runs the rank iteration until tolerance is reached.
+// CalcPageRank runs the rank iteration until tolerance is reached. It panics
+// when Run returns an error.
 func (pr *PageRank) CalcPageRank() {
+	if err := pr.Run(); err != nil {
+		panic(err.Error())
+	}
+}
+
+// Run runs the rank iteration until tolerance is reached. When it does not
+// converge within MaxIter iterations, the ranks of the last iteration are
+// kept and ErrNotConverged is returned.
+func (pr *PageRank) Run() error {
+	if err := pr.validate(); err != nil {
+		return err
+	}
 	// check if edges are empty
 	if len(pr.Edges) == 0 {
-		return
+		return nil
 	}
 	// remove self loops
 	pr.RemoveSelfLoops()
 	// check if there are nodes provides
 	if len(pr.Nodes) == 0 {
-		return
+		return nil
 	}
 	// init nodes by setting the rank to 1/n
 	pr.InitializeNodes()
+	jump, err := pr.jumpProbabilities()
+	if err != nil {
+		return err
+	}
 	// iterate unit convergence
 	for pr.Iteration = uint(0); pr.Iteration <= pr.MaxIter; pr.Iteration++ {
-		// fmt.Println("--------------------------------------------")
-		// fmt.Println("\t Iteration: ", pr.Iteration, "/", pr.MaxIter)
 		// init the l1Err error
 		l1Err := 0.0
-		// iterate over nodes in graph
+		// compute every new rank from the previous iteration's ranks, so the
+		// result does not depend on map iteration order
+		newRanks := make(map[NodeID]float64, len(pr.Nodes))
 		for nodeKey := range pr.Nodes {
 			// calculate the sum of the surrounding nodes of the current node
 			rankSumOfConnectedNode := 0.0
 			// iterate over the incoming connections
 			for _, edge := range pr.Nodes[nodeKey].Incoming {
-				cursorNode := edge.From
-				// calculate the new rank based on the current rank and the out-degree
-				// (out-degree is the number of neighbors of pr node)
-				currentRankDividedByOutDegree := cursorNode.Rank / float64(cursorNode.OutDegree())
-				// fmt.Println(nodeKey, edge.From.Id, "\t\t", cursorNode.Rank, "/", float64(cursorNode.OutDegree()), "=", currentRankDividedByOutDegree)
-				// add the result to the sum
-				rankSumOfConnectedNode += currentRankDividedByOutDegree
-				// fmt.Println(nodeKey, edge.From.Id, rankSumOfConnectedNode)
+				rankSumOfConnectedNode += pr.share(edge)
 			}
-			newRank := 1/float64(pr.N) + pr.Alpha*rankSumOfConnectedNode
-			//fmt.Println(nodeKey, "new rank", newRank)
+			newRanks[nodeKey] = jump[nodeKey] + pr.Alpha*rankSumOfConnectedNode
+		}
+		for nodeKey, newRank := range newRanks {
 			// compute the L1 norm
-			absErr := math.Abs(newRank - pr.Nodes[nodeKey].Rank)
-			// fmt.Println(nodeKey, "absErr", newRank, "-", pr.Nodes[nodeKey].Rank, "=", absErr)
-			l1Err += absErr
+			l1Err += math.Abs(newRank - pr.Nodes[nodeKey].Rank)
 			// set the new rank value to the current node
 			pr.Nodes[nodeKey].Rank = newRank
 		}
-		// fmt.Println("\t new err", l1Err)
 		// check if the L1 error is smaller than the initial tolerance
-		// fmt.Println(l1Err, pr.Tolerance, l1Err < pr.Tolerance)
 		if l1Err < pr.Tolerance {
-			// fmt.Println("terminate")
-			// norm the results by dividing by the sum
-			rankSum := pr.SumTotalNodeRank()
-			// fmt.Println("terminate")
-			for _, node := range pr.Nodes {
-				node.Rank = node.Rank / rankSum
+			pr.normalize()
+			return nil
+		}
+	}
+	pr.normalize()
+	return fmt.Errorf("%w in %d iterations", ErrNotConverged, pr.MaxIter)
+}
+
+func (pr *PageRank) validate() error {
+	if math.IsNaN(pr.Alpha) || pr.Alpha < 0 || pr.Alpha >= 1 {
+		return fmt.Errorf("alpha must be in [0, 1), got %v", pr.Alpha)
+	}
+	if math.IsNaN(pr.Tolerance) || pr.Tolerance <= 0 {
+		return fmt.Errorf("tolerance must be positive, got %v", pr.Tolerance)
+	}
+	for id, weight := range pr.Personalization {
+		if math.IsNaN(weight) || math.IsInf(weight, 0) || weight < 0 {
+			return fmt.Errorf("personalization weight of %s must be finite and non-negative, got %v", id, weight)
+		}
+	}
+	if pr.Weighted {
+		for id, edge := range pr.Edges {
+			if math.IsNaN(edge.Weight) || math.IsInf(edge.Weight, 0) || edge.Weight < 0 {
+				return fmt.Errorf("weight of edge %s must be finite and non-negative, got %v", id, edge.Weight)
 			}
-			return
 		}
 	}
-	panic("PageRank did not converge in " + strconv.Itoa(int(pr.MaxIter)) + " iterations.")
+	return nil
+}
+
+// jumpProbabilities returns the random jump term of every node: 1/n, or the
+// node's share of the personalization weights.
+func (pr *PageRank) jumpProbabilities() (map[NodeID]float64, error) {
+	jump := make(map[NodeID]float64, len(pr.Nodes))
+	if pr.Personalization == nil {
+		for id := range pr.Nodes {
+			jump[id] = 1 / float64(pr.N)
+		}
+		return jump, nil
+	}
+	total := 0.0
+	for id := range pr.Nodes {
+		total += pr.Personalization[id]
+	}
+	if total == 0 {
+		return nil, errors.New("personalization has no positive weight on a graph node")
+	}
+	for id := range pr.Nodes {
+		jump[id] = pr.Personalization[id] / total
+	}
+	return jump, nil
+}
+
+// share is the rank an edge passes from its source to its target.
+func (pr *PageRank) share(edge *Edge) float64 {
+	from := edge.From
+	if !pr.Weighted {
+		// (out-degree is the number of neighbors of the source node)
+		return from.Rank / float64(from.OutDegree())
+	}
+	total := from.OutWeight()
+	if total == 0 {
+		return 0
+	}
+	return from.Rank * edge.Weight / total
+}
+
+// normalize divides every rank by the rank sum so that ranks add up to 1.
+func (pr *PageRank) normalize() {
+	rankSum := pr.SumTotalNodeRank()
+	if rankSum == 0 {
+		return
+	}
+	for _, node := range pr.Nodes {
+		node.Rank = node.Rank / rankSum
+	}
 }
 
 // RemoveSelfLoops deletes self-loop edges before rank computation.
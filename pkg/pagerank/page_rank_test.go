@@ -0,0 +1,92 @@
+package pagerank
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func chainGraph() *Graph {
+	g := NewGraph()
+	g.AddEdge("a", "b")
+	g.AddEdge("b", "c")
+	g.AddEdge("c", "a")
+	g.AddEdge("a", "c")
+	return g
+}
+
+func TestRunNormalizesRanks(t *testing.T) {
+	pr := NewPageRank(chainGraph())
+	if err := pr.Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sum := pr.SumTotalNodeRank(); math.Abs(sum-1) > 1e-9 {
+		t.Fatalf("expected ranks to sum to 1, got %v", sum)
+	}
+	if pr.GetNode("c").Rank <= pr.GetNode("b").Rank {
+		t.Fatalf("expected c (two inlinks) to outrank b, got %v <= %v", pr.GetNode("c").Rank, pr.GetNode("b").Rank)
+	}
+}
+
+func TestRunPersonalizationAndWeights(t *testing.T) {
+	base := NewPageRank(chainGraph())
+	if err := base.Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	personalized := NewPageRank(chainGraph())
+	personalized.Personalization = map[NodeID]float64{"b": 1}
+	if err := personalized.Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if personalized.GetNode("b").Rank <= base.GetNode("b").Rank {
+		t.Fatalf("expected personalization to raise b, got %v <= %v", personalized.GetNode("b").Rank, base.GetNode("b").Rank)
+	}
+
+	g := chainGraph()
+	g.GetEdge("a:b").Weight = 9
+	g.GetEdge("a:c").Weight = 1
+	g.GetEdge("b:c").Weight = 1
+	g.GetEdge("c:a").Weight = 1
+	weighted := NewPageRank(g)
+	weighted.Weighted = true
+	if err := weighted.Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if weighted.GetNode("b").Rank <= base.GetNode("b").Rank {
+		t.Fatalf("expected a heavy a->b edge to raise b, got %v <= %v", weighted.GetNode("b").Rank, base.GetNode("b").Rank)
+	}
+}
+
+func TestRunReportsErrorsInsteadOfPanicking(t *testing.T) {
+	pr := NewPageRank(chainGraph())
+	pr.MaxIter = 1
+	if err := pr.Run(); !errors.Is(err, ErrNotConverged) {
+		t.Fatalf("expected ErrNotConverged, got %v", err)
+	}
+	if sum := pr.SumTotalNodeRank(); math.Abs(sum-1) > 1e-9 {
+		t.Fatalf("expected last iteration to be normalized, got sum %v", sum)
+	}
+
+	tests := []struct {
+		name  string
+		setup func(pr *PageRank)
+	}{
+		{"alpha one", func(pr *PageRank) { pr.Alpha = 1 }},
+		{"negative alpha", func(pr *PageRank) { pr.Alpha = -0.1 }},
+		{"zero tolerance", func(pr *PageRank) { pr.Tolerance = 0 }},
+		{"negative personalization", func(pr *PageRank) { pr.Personalization = map[NodeID]float64{"a": -1} }},
+		{"empty personalization", func(pr *PageRank) { pr.Personalization = map[NodeID]float64{"x": 1} }},
+		{"negative weight", func(pr *PageRank) {
+			pr.Weighted = true
+			pr.GetEdge("a:b").Weight = -1
+		}},
+	}
+	for _, tt := range tests {
+		pr := NewPageRank(chainGraph())
+		tt.setup(pr)
+		if err := pr.Run(); err == nil {
+			t.Fatalf("%s: expected error", tt.name)
+		}
+	}
+}